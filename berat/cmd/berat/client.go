@@ -0,0 +1,74 @@
+package main
+
+import (
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+// Client is what the commands work on, either the database directly
+// or the JSON API of a running berat server
+type Client interface {
+	List(tag string) ([]models.Weight, error)
+	Show(id uint64) (*models.Weight, error)
+	Add(weight *models.Weight) (*models.Weight, error)
+	Edit(id uint64, weight *models.Weight) (*models.Weight, error)
+	Delete(id uint64) error
+	Stats() (*services.Stats, error)
+}
+
+// localClient works directly on a models.Repository through the same
+// service as the server, so the same business rules apply
+type localClient struct {
+	service *services.WeightService
+}
+
+func newLocalClient(repo models.Repository) *localClient {
+	return &localClient{service: services.NewWeightService(repo)}
+}
+
+func (lc *localClient) List(tag string) ([]models.Weight, error) {
+	weights, err := lc.service.List()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lc.service.LoadTags(*weights); err != nil {
+		return nil, err
+	}
+
+	if tag == "" {
+		return *weights, nil
+	}
+
+	return services.FilterByTag(*weights, tag), nil
+}
+
+func (lc *localClient) Show(id uint64) (*models.Weight, error) {
+	weight, err := lc.service.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	weights := []models.Weight{*weight}
+	if err := lc.service.LoadTags(weights); err != nil {
+		return nil, err
+	}
+
+	return &weights[0], nil
+}
+
+func (lc *localClient) Add(weight *models.Weight) (*models.Weight, error) {
+	return lc.service.Create(weight)
+}
+
+func (lc *localClient) Edit(id uint64, weight *models.Weight) (*models.Weight, error) {
+	return lc.service.Update(id, weight)
+}
+
+func (lc *localClient) Delete(id uint64) error {
+	return lc.service.Delete(id)
+}
+
+func (lc *localClient) Stats() (*services.Stats, error) {
+	return lc.service.Stats()
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+// command runs one subcommand with the arguments following its name
+type command struct {
+	usage string
+	run   func(c Client, out output, args []string) error
+}
+
+var commands = map[string]command{
+	"add":     {"add [-date YYYY-MM-DD] -max N -min N [-notes text] [-tags a,b]", runAdd},
+	"list":    {"list [-tag name]", runList},
+	"show":    {"show ID", runShow},
+	"edit":    {"edit ID [-date YYYY-MM-DD] [-max N] [-min N] [-notes text] [-tags a,b]", runEdit},
+	"delete":  {"delete ID", runDelete},
+	"stats":   {"stats", runStats},
+	"export":  {"export [-tag name] [-o file]", runExport},
+	"backup":  {"backup [-o file]", runBackup},
+	"restore": {"restore [-policy fail|skip|overwrite] [-verify] FILE", runRestore},
+	"check":   {"check [-repair]", runCheck},
+}
+
+// commandNames is the order the commands are listed in the usage
+var commandNames = []string{"add", "list", "show", "edit", "delete", "stats", "export", "backup", "restore", "check"}
+
+func runAdd(c Client, out output, args []string) error {
+	fs := newFlagSet("add")
+	date := fs.String("date", time.Now().Format(models.DateLayout), "date of the weight")
+	max := fs.Int("max", 0, "max weight")
+	min := fs.Int("min", 0, "min weight")
+	notes := fs.String("notes", "", "notes of the day")
+	tags := fs.String("tags", "", "comma separated tags")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	weight, err := c.Add(&models.Weight{
+		Date:  *date,
+		Max:   *max,
+		Min:   *min,
+		Notes: *notes,
+		Tags:  models.ParseTags(*tags),
+	})
+	if err != nil {
+		return err
+	}
+
+	return out.weight(weight)
+}
+
+func runList(c Client, out output, args []string) error {
+	fs := newFlagSet("list")
+	tag := fs.String("tag", "", "only the weights having the tag")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	weights, err := c.List(*tag)
+	if err != nil {
+		return err
+	}
+
+	return out.weights(weights)
+}
+
+func runShow(c Client, out output, args []string) error {
+	id, _, err := parseID(args)
+	if err != nil {
+		return err
+	}
+
+	weight, err := c.Show(id)
+	if err != nil {
+		return err
+	}
+
+	return out.weight(weight)
+}
+
+// runEdit only changes the values given as flags, the others are kept
+func runEdit(c Client, out output, args []string) error {
+	id, args, err := parseID(args)
+	if err != nil {
+		return err
+	}
+
+	fs := newFlagSet("edit")
+	date := fs.String("date", "", "date of the weight")
+	max := fs.Int("max", 0, "max weight")
+	min := fs.Int("min", 0, "min weight")
+	notes := fs.String("notes", "", "notes of the day")
+	tags := fs.String("tags", "", "comma separated tags, empty to remove all")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	weight, err := c.Show(id)
+	if err != nil {
+		return err
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "date":
+			weight.Date = *date
+		case "max":
+			weight.Max = *max
+		case "min":
+			weight.Min = *min
+		case "notes":
+			weight.Notes = *notes
+		case "tags":
+			weight.Tags = models.ParseTags(*tags)
+		}
+	})
+
+	weight, err = c.Edit(id, weight)
+	if err != nil {
+		return err
+	}
+
+	return out.weight(weight)
+}
+
+func runDelete(c Client, out output, args []string) error {
+	id, _, err := parseID(args)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Delete(id); err != nil {
+		return err
+	}
+
+	return out.message("Weight %d moved to trash", id)
+}
+
+func runStats(c Client, out output, args []string) error {
+	stats, err := c.Stats()
+	if err != nil {
+		return err
+	}
+
+	return out.stats(stats)
+}
+
+// runExport writes all the weights to the file, or to the standard output.
+// It is written as CSV unless the json format is chosen
+func runExport(c Client, out output, args []string) error {
+	fs := newFlagSet("export")
+	tag := fs.String("tag", "", "only the weights having the tag")
+	file := fs.String("o", "", "file to write, the standard output when empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	weights, err := c.List(*tag)
+	if err != nil {
+		return err
+	}
+
+	if out.format != FormatJSON {
+		out.format = FormatCSV
+	}
+
+	if *file == "" {
+		return out.weights(weights)
+	}
+
+	f, err := os.Create(*file)
+	if err != nil {
+		return err
+	}
+
+	out.w = f
+	if err := out.weights(weights); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// runBackup writes all the data of the database as a backup archive
+// to the file, or to the standard output
+func runBackup(c Client, out output, args []string) error {
+	fs := newFlagSet("backup")
+	file := fs.String("o", "", "file to write, the standard output when empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	lc, ok := c.(*localClient)
+	if !ok {
+		return errors.New("backup only works on the database, not with -remote")
+	}
+
+	data, err := lc.service.Backup()
+	if err != nil {
+		return err
+	}
+
+	if *file == "" {
+		return services.WriteArchive(out.w, data, time.Now())
+	}
+
+	f, err := os.Create(*file)
+	if err != nil {
+		return err
+	}
+
+	if err := services.WriteArchive(f, data, time.Now()); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return out.message("Backup written to %s", *file)
+}
+
+// runRestore imports a backup archive into the database. With -verify the
+// archive is only checked and the restore is planned without writing
+func runRestore(c Client, out output, args []string) error {
+	fs := newFlagSet("restore")
+	policy := fs.String("policy", services.RestoreFail, "on conflicting data: fail, skip or overwrite")
+	verify := fs.Bool("verify", false, "only check the archive and show what would be restored")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		return errors.New("missing backup file")
+	}
+
+	lc, ok := c.(*localClient)
+	if !ok {
+		return errors.New("restore only works on the database, not with -remote")
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	data, err := services.ReadArchive(f)
+	if err != nil {
+		return err
+	}
+
+	report, err := lc.service.RestoreArchive(data, *policy, *verify)
+	if report != nil {
+		if err := out.restoreReport(report); err != nil {
+			return err
+		}
+	}
+
+	return err
+}
+
+// runCheck reports the weight data violating the invariants and repairs
+// them with -repair. It fails while there are issues left, so it could be
+// used in scripts
+func runCheck(c Client, out output, args []string) error {
+	fs := newFlagSet("check")
+	repair := fs.Bool("repair", false, "repair the issues that could be repaired automatically")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	lc, ok := c.(*localClient)
+	if !ok {
+		return errors.New("check only works on the database, not with -remote")
+	}
+
+	report, err := lc.service.CheckWeights(*repair)
+	if err != nil {
+		return err
+	}
+
+	if err := out.checkReport(report); err != nil {
+		return err
+	}
+
+	left := len(report.Issues)
+	if report.Repaired {
+		left = report.Unrepairable()
+	}
+
+	if left == 0 {
+		return nil
+	}
+
+	if report.Repaired || left == report.Unrepairable() {
+		return fmt.Errorf("%d issues have to be fixed by hand", left)
+	}
+
+	return fmt.Errorf("%d issues found, run check -repair to repair them", left)
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+
+	return fs
+}
+
+// parseID reads the id from the first argument and returns the rest
+func parseID(args []string) (uint64, []string, error) {
+	if len(args) == 0 {
+		return 0, nil, errors.New("missing weight id")
+	}
+
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid weight id %q", args[0])
+	}
+
+	return id, args[1:], nil
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: berat [-remote URL] [-format table|json|csv] COMMAND")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Without -remote the database configured in .env is used directly.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, name := range commandNames {
+		fmt.Fprintln(w, "  "+commands[name].usage)
+	}
+}
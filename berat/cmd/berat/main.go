@@ -0,0 +1,85 @@
+// Command berat manages the weight data from the command line, either
+// directly on the database configured in .env or on a running berat server
+// through its JSON API with -remote
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/joho/godotenv"
+
+	"github.com/erizkiatama/berat/database"
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, connect); err != nil {
+		fmt.Fprintln(os.Stderr, "berat:", err)
+		os.Exit(1)
+	}
+}
+
+// run parses the global flags and runs the command on the client given by
+// connect, which gets the -remote URL
+func run(args []string, w io.Writer, connect func(remote string) (Client, error)) error {
+	fs := flag.NewFlagSet("berat", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	remote := fs.String("remote", os.Getenv("BERAT_REMOTE"), "URL of a berat server, the database is used when empty")
+	format := fs.String("format", FormatTable, "output format: table, json or csv")
+	if err := fs.Parse(args); err != nil {
+		usage(w)
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		usage(w)
+		return errors.New("missing command")
+	}
+
+	if !validFormat(*format) {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		usage(w)
+		return fmt.Errorf("unknown command %q", fs.Arg(0))
+	}
+
+	client, err := connect(*remote)
+	if err != nil {
+		return err
+	}
+
+	return cmd.run(client, output{format: *format, w: w}, fs.Args()[1:])
+}
+
+// connect returns the remote client when the URL is given, otherwise
+// the local client on the database and service configured in .env
+func connect(remote string) (Client, error) {
+	if remote != "" {
+		return newRemoteClient(remote), nil
+	}
+
+	godotenv.Load()
+
+	db, err := database.Open(database.ConfigFromEnv())
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to the database: %s", err)
+	}
+
+	service, err := services.NewConfiguredWeightService(&models.WeightRepository{DB: db}, services.ConfigFromEnv())
+	if err != nil {
+		return nil, err
+	}
+
+	client := &localClient{service: service}
+
+	return client, nil
+}
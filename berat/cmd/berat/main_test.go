@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/controllers"
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/models/mocks"
+	"github.com/erizkiatama/berat/services"
+)
+
+func localRun(t *testing.T, repo *mocks.WeightRepository, args ...string) (string, error) {
+	var out bytes.Buffer
+	err := run(args, &out, func(remote string) (Client, error) {
+		require.Empty(t, remote)
+		return newLocalClient(repo), nil
+	})
+
+	return out.String(), err
+}
+
+func TestList_As_CSV(t *testing.T) {
+	repo := new(mocks.WeightRepository)
+	repo.On("FindAll").Return(&[]models.Weight{
+		{ID: 1, Date: "2020-11-09", Max: 50, Min: 48, Difference: 2, Notes: "pizza, pasta"},
+		{ID: 2, Date: "2020-11-10", Max: 51, Min: 48, Difference: 3},
+	}, nil).Once()
+	repo.On("FindWeightTagNames").Return(map[uint64][]string{1: {"ate out"}}, nil).Once()
+
+	out, err := localRun(t, repo, "-format", "csv", "list")
+	require.NoError(t, err)
+	require.Equal(t, "id,date,max,min,difference,tags,notes\n"+
+		"1,2020-11-09,50,48,2,ate out,\"pizza, pasta\"\n"+
+		"2,2020-11-10,51,48,3,,\n", out)
+	repo.AssertExpectations(t)
+}
+
+func TestEdit_Keep_Values_Not_Given(t *testing.T) {
+	repo := new(mocks.WeightRepository)
+	weight := &models.Weight{ID: 1, Date: "2020-11-09", Max: 50, Min: 48, Difference: 2, Notes: "pizza"}
+	edited := &models.Weight{ID: 1, Date: "2020-11-09", Max: 52, Min: 48, Difference: 4, Notes: "pizza", Tags: []string{"ate out"}}
+
+	repo.On("FindByID", uint64(1)).Return(weight, nil).Twice()
+	repo.On("FindWeightTagNames").Return(map[uint64][]string{1: {"ate out"}}, nil).Once()
+	repo.On("FindReadingsByWeightID", uint64(1)).Return(&[]models.Reading{}, nil).Once()
+	repo.On("FindByDate", "2020-11-09").Return(weight, nil).Once()
+	repo.On("Transaction").Return().Once()
+	repo.On("Update", uint64(1), edited).Return(edited, nil).Once()
+	repo.On("SetWeightTags", uint64(1), []string{"ate out"}).Return(nil).Once()
+
+	out, err := localRun(t, repo, "edit", "1", "-max", "52")
+	require.NoError(t, err)
+	require.Contains(t, out, "2020-11-09  52   48   4")
+	repo.AssertExpectations(t)
+}
+
+func TestShow_When_Weight_Not_Found(t *testing.T) {
+	repo := new(mocks.WeightRepository)
+	repo.On("FindByID", uint64(9)).Return(&models.Weight{}, gorm.ErrRecordNotFound).Once()
+
+	_, err := localRun(t, repo, "show", "9")
+	require.Equal(t, services.ErrNotFound, err)
+}
+
+func TestRun_When_Command_Is_Unknown(t *testing.T) {
+	out, err := localRun(t, new(mocks.WeightRepository), "remove", "1")
+	require.EqualError(t, err, `unknown command "remove"`)
+	require.Contains(t, out, "Usage: berat")
+}
+
+func TestRemote_Return_Field_Errors_Of_The_API(t *testing.T) {
+	repo := new(mocks.WeightRepository)
+	router := mux.NewRouter()
+	controllers.NewAPIController(services.NewWeightService(repo), router)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	var out bytes.Buffer
+	err := run([]string{"-remote", server.URL, "add", "-date", "2020-11-09", "-max", "40", "-min", "48"}, &out, func(remote string) (Client, error) {
+		return newRemoteClient(remote), nil
+	})
+	require.Equal(t, models.ValidationErrors{"max": "Max weight could not be smaller than min weight"}, err)
+}
+
+func TestRemote_Show_Weight_As_JSON(t *testing.T) {
+	repo := new(mocks.WeightRepository)
+	repo.On("FindByID", uint64(1)).Return(&models.Weight{ID: 1, Date: "2020-11-09", Max: 50, Min: 48, Difference: 2}, nil).Once()
+	repo.On("FindWeightTagNames").Return(map[uint64][]string{}, nil).Once()
+
+	router := mux.NewRouter()
+	controllers.NewAPIController(services.NewWeightService(repo), router)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	var out bytes.Buffer
+	err := run([]string{"-remote", server.URL, "-format", "json", "show", "1"}, &out, func(remote string) (Client, error) {
+		return newRemoteClient(remote), nil
+	})
+	require.NoError(t, err)
+	require.Contains(t, out.String(), `"date": "2020-11-09"`)
+	repo.AssertExpectations(t)
+}
+
+func TestRemote_Delete_With_Production_Router(t *testing.T) {
+	repo := new(mocks.WeightRepository)
+	repo.On("FindByID", uint64(1)).Return(&models.Weight{ID: 1, Date: "2020-11-09", Max: 50, Min: 48, Difference: 2}, nil).Once()
+	repo.On("Delete", uint64(1)).Return(nil).Once()
+
+	template := template.Must(template.ParseGlob("../../views/*.html"))
+	server := httptest.NewServer(controllers.NewRouter(services.NewWeightService(repo), template))
+	defer server.Close()
+
+	var out bytes.Buffer
+	err := run([]string{"-remote", server.URL, "delete", "1"}, &out, func(remote string) (Client, error) {
+		return newRemoteClient(remote), nil
+	})
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "Weight 1 moved to trash")
+	repo.AssertExpectations(t)
+}
+
+func TestBackup_Then_Verify_Restore_Into_Same_Database(t *testing.T) {
+	repo := new(mocks.WeightRepository)
+	weights := []models.Weight{{ID: 1, Date: "2020-11-09", Max: 50, Min: 48, Difference: 2}}
+	repo.On("FindAll").Return(&weights, nil).Once()
+	repo.On("FindDeleted").Return(&[]models.Weight{}, nil).Twice()
+	repo.On("FindWeightTagNames").Return(map[uint64][]string{}, nil).Twice()
+	repo.On("FindReadingsByWeightID", uint64(1)).Return(&[]models.Reading{}, nil).Once()
+	repo.On("FindAllMeasurementTypes").Return(&[]models.MeasurementType{}, nil).Twice()
+	repo.On("FindAllMeasurements").Return(&[]models.Measurement{}, nil).Once()
+	repo.On("FindProfile").Return(nil, gorm.ErrRecordNotFound).Once()
+	repo.On("FindByDate", "2020-11-09").Return(&weights[0], nil).Once()
+
+	dir, err := ioutil.TempDir("", "berat")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "backup.json")
+	out, err := localRun(t, repo, "backup", "-o", file)
+	require.NoError(t, err)
+	require.Equal(t, "Backup written to "+file+"\n", out)
+
+	out, err = localRun(t, repo, "-format", "csv", "restore", "-verify", file)
+	require.NoError(t, err)
+	require.Contains(t, out, "weights,0,0,1,0\n")
+	repo.AssertExpectations(t)
+}
+
+func TestRestore_With_Remote(t *testing.T) {
+	var out bytes.Buffer
+	err := run([]string{"-remote", "http://localhost:8080", "restore", "backup.json"}, &out, func(remote string) (Client, error) {
+		return newRemoteClient(remote), nil
+	})
+	require.EqualError(t, err, "restore only works on the database, not with -remote")
+}
+
+func TestCheck_Fail_While_Issues_Are_Not_Repaired(t *testing.T) {
+	repo := new(mocks.WeightRepository)
+	repo.On("FindAllWithDeleted").Return(&[]models.Weight{
+		{ID: 1, Date: "2020/11/09", Max: 50, Min: 48, Difference: 2},
+		{ID: 2, Date: "2020-11-10", Max: 51, Min: 49, Difference: 2},
+	}, nil).Once()
+
+	out, err := localRun(t, repo, "check")
+	require.EqualError(t, err, "1 issues found, run check -repair to repair them")
+	require.Contains(t, out, "Rewrite the date as 2020-11-09")
+	require.Contains(t, out, "2 weights checked, 1 issues found\n")
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+// Output formats of the commands
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+)
+
+var weightHeader = []string{"id", "date", "max", "min", "difference", "tags", "notes"}
+
+// output writes the results of the commands in the chosen format
+type output struct {
+	format string
+	w      io.Writer
+}
+
+func validFormat(format string) bool {
+	return format == FormatTable || format == FormatJSON || format == FormatCSV
+}
+
+func (o output) weights(weights []models.Weight) error {
+	if o.format == FormatJSON {
+		return o.json(weights)
+	}
+
+	rows := make([][]string, 0, len(weights))
+	for _, weight := range weights {
+		rows = append(rows, []string{
+			strconv.FormatUint(weight.ID, 10),
+			weight.Date,
+			strconv.Itoa(weight.Max),
+			strconv.Itoa(weight.Min),
+			strconv.Itoa(weight.Difference),
+			strings.Join(weight.Tags, ", "),
+			weight.Notes,
+		})
+	}
+
+	return o.rows(weightHeader, rows)
+}
+
+func (o output) weight(weight *models.Weight) error {
+	if o.format == FormatJSON {
+		return o.json(weight)
+	}
+
+	return o.weights([]models.Weight{*weight})
+}
+
+func (o output) stats(stats *services.Stats) error {
+	if o.format == FormatJSON {
+		return o.json(stats)
+	}
+
+	header := []string{"count", "average_max", "average_min", "average_difference", "average_bmi"}
+	row := []string{
+		strconv.Itoa(stats.Count),
+		fmt.Sprintf("%.2f", stats.AverageMax),
+		fmt.Sprintf("%.2f", stats.AverageMin),
+		fmt.Sprintf("%.2f", stats.AverageDiff),
+		"",
+	}
+
+	if stats.AverageBMI != nil {
+		row[4] = fmt.Sprintf("%.1f", stats.AverageBMI.Value)
+		if stats.AverageBMI.Category != "" {
+			row[4] += " (" + stats.AverageBMI.Category + ")"
+		}
+	}
+
+	return o.rows(header, [][]string{row})
+}
+
+// restoreReport writes the counts of each kind of data, followed by
+// the conflicting data
+func (o output) restoreReport(report *services.RestoreReport) error {
+	if o.format == FormatJSON {
+		return o.json(report)
+	}
+
+	counts := []struct {
+		kind  string
+		count services.RestoreCount
+	}{
+		{"weights", report.Weights},
+		{"readings", report.Readings},
+		{"measurement_types", report.MeasurementTypes},
+		{"measurements", report.Measurements},
+		{"profile", report.Profile},
+	}
+
+	header := []string{"data", "created", "updated", "unchanged", "skipped"}
+	rows := make([][]string, 0, len(counts))
+	for _, c := range counts {
+		rows = append(rows, []string{
+			c.kind,
+			strconv.Itoa(c.count.Created),
+			strconv.Itoa(c.count.Updated),
+			strconv.Itoa(c.count.Unchanged),
+			strconv.Itoa(c.count.Skipped),
+		})
+	}
+
+	if err := o.rows(header, rows); err != nil {
+		return err
+	}
+
+	if o.format == FormatCSV {
+		return nil
+	}
+
+	for _, conflict := range report.Conflicts {
+		if _, err := fmt.Fprintf(o.w, "conflict (%s): %s\n", report.Policy, conflict); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkReport writes the issues followed by a summary
+func (o output) checkReport(report *services.CheckReport) error {
+	if o.format == FormatJSON {
+		return o.json(report)
+	}
+
+	if len(report.Issues) > 0 {
+		header := []string{"weight_id", "date", "kind", "problem", "repair"}
+		rows := make([][]string, 0, len(report.Issues))
+		for _, issue := range report.Issues {
+			rows = append(rows, []string{
+				strconv.FormatUint(issue.WeightID, 10),
+				issue.Date,
+				issue.Kind,
+				issue.Message,
+				issue.Repair,
+			})
+		}
+
+		if err := o.rows(header, rows); err != nil {
+			return err
+		}
+	}
+
+	if o.format == FormatCSV {
+		return nil
+	}
+
+	summary := fmt.Sprintf("%d weights checked, %d issues found", report.Checked, len(report.Issues))
+	if report.Repaired {
+		summary += fmt.Sprintf(", %d repaired", len(report.Issues)-report.Unrepairable())
+	}
+
+	_, err := fmt.Fprintln(o.w, summary)
+
+	return err
+}
+
+func (o output) message(format string, a ...interface{}) error {
+	if o.format == FormatJSON {
+		return o.json(map[string]string{"message": fmt.Sprintf(format, a...)})
+	}
+
+	_, err := fmt.Fprintf(o.w, format+"\n", a...)
+
+	return err
+}
+
+func (o output) json(v interface{}) error {
+	encoder := json.NewEncoder(o.w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(v)
+}
+
+// rows writes the header and rows as CSV, or as an aligned table
+func (o output) rows(header []string, rows [][]string) error {
+	if o.format == FormatCSV {
+		w := csv.NewWriter(o.w)
+		w.Write(header)
+		w.WriteAll(rows)
+
+		return w.Error()
+	}
+
+	w := tabwriter.NewWriter(o.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.ToUpper(strings.Join(header, "\t")))
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = strings.Join(strings.Fields(cell), " ")
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+
+	return w.Flush()
+}
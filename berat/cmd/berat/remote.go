@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/erizkiatama/berat/controllers"
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+// remoteClient works on a running berat server through its JSON API
+type remoteClient struct {
+	baseURL string
+	http    *http.Client
+}
+
+func newRemoteClient(baseURL string) *remoteClient {
+	return &remoteClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (rc *remoteClient) List(tag string) ([]models.Weight, error) {
+	path := "/api/weights"
+	if tag != "" {
+		path += "?tag=" + url.QueryEscape(tag)
+	}
+
+	var weights []models.Weight
+	err := rc.do(http.MethodGet, path, nil, &weights)
+
+	return weights, err
+}
+
+func (rc *remoteClient) Show(id uint64) (*models.Weight, error) {
+	weight := new(models.Weight)
+	if err := rc.do(http.MethodGet, fmt.Sprintf("/api/weights/%d", id), nil, weight); err != nil {
+		return nil, err
+	}
+
+	return weight, nil
+}
+
+func (rc *remoteClient) Add(weight *models.Weight) (*models.Weight, error) {
+	newWeight := new(models.Weight)
+	if err := rc.do(http.MethodPost, "/api/weights", weight, newWeight); err != nil {
+		return nil, err
+	}
+
+	return newWeight, nil
+}
+
+func (rc *remoteClient) Edit(id uint64, weight *models.Weight) (*models.Weight, error) {
+	newWeight := new(models.Weight)
+	if err := rc.do(http.MethodPut, fmt.Sprintf("/api/weights/%d", id), weight, newWeight); err != nil {
+		return nil, err
+	}
+
+	return newWeight, nil
+}
+
+func (rc *remoteClient) Delete(id uint64) error {
+	return rc.do(http.MethodDelete, fmt.Sprintf("/api/weights/%d", id), nil, nil)
+}
+
+func (rc *remoteClient) Stats() (*services.Stats, error) {
+	stats := new(services.Stats)
+	if err := rc.do(http.MethodGet, "/api/stats", nil, stats); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// do sends the request with the body as JSON and decodes the response
+// into out. A failed request returns the field errors of the API as
+// models.ValidationErrors, or its error message
+func (rc *remoteClient) do(method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, rc.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := rc.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		var errRes controllers.ErrorResponse
+		if err := json.NewDecoder(res.Body).Decode(&errRes); err != nil {
+			return fmt.Errorf("%s %s: %s", method, path, res.Status)
+		}
+
+		if len(errRes.Errors) > 0 {
+			return errRes.Errors
+		}
+
+		if len(errRes.Rules) > 0 {
+			return errRes.Rules
+		}
+
+		return errors.New(errRes.Error)
+	}
+
+	if out == nil || res.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	return json.NewDecoder(res.Body).Decode(out)
+}
@@ -0,0 +1,638 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+	"github.com/gorilla/mux"
+)
+
+// UpsertResponse is the JSON body sent after setting the weight data of
+// a date, telling whether the weight data was created or updated
+type UpsertResponse struct {
+	Created bool           `json:"created"`
+	Weight  *models.Weight `json:"weight"`
+}
+
+// ErrorResponse is the JSON body sent by the API when a request fails.
+// Errors holds the same field keyed failures shown on the HTML forms
+type ErrorResponse struct {
+	Error  string                  `json:"error"`
+	Errors models.ValidationErrors `json:"errors,omitempty"`
+	Rules  services.RuleErrors     `json:"rules,omitempty"`
+}
+
+// APIController is a wrapper for the JSON API controller
+// so it could use the same weight service as WeightController
+type APIController struct {
+	Service *services.WeightService
+	Router  *mux.Router
+}
+
+// NewAPIController creates new APIController
+// and defines the route that the controller have
+func NewAPIController(ws *services.WeightService, r *mux.Router) {
+	ac := &APIController{
+		Service: ws,
+		Router:  r,
+	}
+
+	// the API is exempt from CSRF, SameOrigin keeps browsers out instead
+	api := r.NewRoute().Subrouter()
+	api.Use(ac.SameOrigin)
+
+	api.HandleFunc("/api/weights", ac.List).Methods("GET")
+	api.HandleFunc("/api/weights", ac.Idempotent(ac.Create)).Methods("POST")
+	api.HandleFunc("/api/weights/bulk", ac.Idempotent(ac.CreateBulk)).Methods("POST")
+	api.HandleFunc("/api/weights/by-date/{date}", ac.UpsertByDate).Methods("PUT")
+	api.HandleFunc("/api/weights/{id}", ac.Show).Methods("GET")
+	api.HandleFunc("/api/weights/{id}", ac.Update).Methods("PUT")
+	api.HandleFunc("/api/weights/{id}", ac.Delete).Methods("DELETE")
+	api.HandleFunc("/api/weights/{id}/readings", ac.Readings).Methods("GET")
+	api.HandleFunc("/api/readings", ac.Idempotent(ac.CreateReading)).Methods("POST")
+	api.HandleFunc("/api/readings/{id}", ac.UpdateReading).Methods("PUT")
+	api.HandleFunc("/api/readings/{id}", ac.DeleteReading).Methods("DELETE")
+	api.HandleFunc("/api/measurement-types", ac.MeasurementTypes).Methods("GET")
+	api.HandleFunc("/api/measurements", ac.Measurements).Methods("GET")
+	api.HandleFunc("/api/measurements", ac.Idempotent(ac.CreateMeasurement)).Methods("POST")
+	api.HandleFunc("/api/measurements/{id}", ac.UpdateMeasurement).Methods("PUT")
+	api.HandleFunc("/api/measurements/{id}", ac.DeleteMeasurement).Methods("DELETE")
+	api.HandleFunc("/api/trash", ac.Trash).Methods("GET")
+	api.HandleFunc("/api/trash/{id}/restore", ac.RestoreTrash).Methods("POST")
+	api.HandleFunc("/api/trash/{id}", ac.PurgeTrash).Methods("DELETE")
+	api.HandleFunc("/api/tags", ac.TagReport).Methods("GET")
+	api.HandleFunc("/api/profile", ac.Profile).Methods("GET")
+	api.HandleFunc("/api/profile", ac.SaveProfile).Methods("PUT")
+	api.HandleFunc("/api/stats", ac.Stats).Methods("GET")
+	api.HandleFunc("/api/gaps", ac.Gaps).Methods("GET")
+	api.HandleFunc("/api/compare", ac.Compare).Methods("GET")
+	api.HandleFunc("/api/patterns", ac.Patterns).Methods("GET")
+	api.HandleFunc("/api/forecast", ac.Forecast).Methods("GET")
+}
+
+// List is the function to send all the weight data as JSON,
+// or only the ones having the tag query
+func (ac *APIController) List(w http.ResponseWriter, r *http.Request) {
+	weights, err := ac.Service.List()
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if err := ac.Service.LoadTags(*weights); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if tag := r.URL.Query().Get("tag"); tag != "" {
+		filtered := services.FilterByTag(*weights, tag)
+		weights = &filtered
+	}
+
+	writeJSON(w, http.StatusOK, weights)
+}
+
+// Show is the function to send a weight data based on id as JSON
+func (ac *APIController) Show(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Invalid weight id"})
+		return
+	}
+
+	weight, err := ac.Service.Get(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	weights := []models.Weight{*weight}
+	if err := ac.Service.LoadTags(weights); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, weights[0])
+}
+
+// Create is the function to insert a new weight data from JSON body
+func (ac *APIController) Create(w http.ResponseWriter, r *http.Request) {
+	weight, err := decodeWeight(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	newWeight, err := ac.Service.Create(weight)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/weights/%d", newWeight.ID))
+	writeJSON(w, http.StatusCreated, newWeight)
+}
+
+// CreateBulk is the function to insert many weight data from JSON body.
+// It responds 201 when every row is saved, 422 when none is saved
+// and 200 when only some are saved, with the result of every row
+func (ac *APIController) CreateBulk(w http.ResponseWriter, r *http.Request) {
+	mode, rows, err := BindWeights(r)
+	if err != nil {
+		writeServiceError(w, &badRequestError{err})
+		return
+	}
+
+	report, err := ac.Service.CreateAll(mode, rows)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	switch report.Created {
+	case len(report.Results):
+		status = http.StatusCreated
+	case 0:
+		status = http.StatusUnprocessableEntity
+	}
+
+	writeJSON(w, status, report)
+}
+
+// Update is the function to update an existing weight data from JSON body
+func (ac *APIController) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Invalid weight id"})
+		return
+	}
+
+	weight, err := decodeWeight(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	newWeight, err := ac.Service.Update(id, weight)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newWeight)
+}
+
+// UpsertByDate is the function to set the weight data of the date in the
+// address from JSON body. It responds 201 when the weight data is created
+// and 200 when it is updated
+func (ac *APIController) UpsertByDate(w http.ResponseWriter, r *http.Request) {
+	weight, err := decodeWeight(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	newWeight, created, err := ac.Service.Upsert(mux.Vars(r)["date"], weight)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+		w.Header().Set("Location", fmt.Sprintf("/api/weights/%d", newWeight.ID))
+	}
+
+	writeJSON(w, status, UpsertResponse{Created: created, Weight: newWeight})
+}
+
+// Delete is the function to move an existing weight data to the trash
+func (ac *APIController) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Invalid weight id"})
+		return
+	}
+
+	if err := ac.Service.Delete(id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Readings is the function to send all readings behind a weight data
+func (ac *APIController) Readings(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Invalid weight id"})
+		return
+	}
+
+	if _, err := ac.Service.Get(id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	readings, err := ac.Service.Readings(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, readings)
+}
+
+// CreateReading is the function to insert a new reading from JSON body
+func (ac *APIController) CreateReading(w http.ResponseWriter, r *http.Request) {
+	reading, err := decodeReading(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	newReading, err := ac.Service.AddReading(reading)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/readings/%d", newReading.ID))
+	writeJSON(w, http.StatusCreated, newReading)
+}
+
+// UpdateReading is the function to update an existing reading from JSON body
+func (ac *APIController) UpdateReading(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Invalid reading id"})
+		return
+	}
+
+	reading, err := decodeReading(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	newReading, err := ac.Service.UpdateReading(id, reading)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newReading)
+}
+
+// DeleteReading is the function to delete an existing reading
+func (ac *APIController) DeleteReading(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Invalid reading id"})
+		return
+	}
+
+	if _, err := ac.Service.DeleteReading(id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MeasurementTypes is the function to send all the measurement types
+func (ac *APIController) MeasurementTypes(w http.ResponseWriter, r *http.Request) {
+	types, err := ac.Service.MeasurementTypes()
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, types)
+}
+
+// Measurements is the function to send all the measurements
+func (ac *APIController) Measurements(w http.ResponseWriter, r *http.Request) {
+	measurements, err := ac.Service.Measurements()
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, measurements)
+}
+
+// CreateMeasurement is the function to insert a new measurement from JSON body
+func (ac *APIController) CreateMeasurement(w http.ResponseWriter, r *http.Request) {
+	measurement, err := decodeMeasurement(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	newMeasurement, err := ac.Service.CreateMeasurement(measurement)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/measurements/%d", newMeasurement.ID))
+	writeJSON(w, http.StatusCreated, newMeasurement)
+}
+
+// UpdateMeasurement is the function to update an existing measurement from JSON body
+func (ac *APIController) UpdateMeasurement(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Invalid measurement id"})
+		return
+	}
+
+	measurement, err := decodeMeasurement(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	newMeasurement, err := ac.Service.UpdateMeasurement(id, measurement)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newMeasurement)
+}
+
+// DeleteMeasurement is the function to delete an existing measurement
+func (ac *APIController) DeleteMeasurement(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Invalid measurement id"})
+		return
+	}
+
+	if err := ac.Service.DeleteMeasurement(id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Trash is the function to send all the deleted weight data
+func (ac *APIController) Trash(w http.ResponseWriter, r *http.Request) {
+	weights, err := ac.Service.Trash()
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, weights)
+}
+
+// RestoreTrash is the function to take a weight data out of the trash
+func (ac *APIController) RestoreTrash(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Invalid weight id"})
+		return
+	}
+
+	weight, err := ac.Service.Restore(id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, weight)
+}
+
+// PurgeTrash is the function to permanently delete a weight data in the trash
+func (ac *APIController) PurgeTrash(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Invalid weight id"})
+		return
+	}
+
+	if err := ac.Service.Purge(id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TagReport is the function to send the average Difference
+// of the days with and without each tag
+func (ac *APIController) TagReport(w http.ResponseWriter, r *http.Request) {
+	report, err := ac.Service.TagReport()
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// Profile is the function to send the profile
+func (ac *APIController) Profile(w http.ResponseWriter, r *http.Request) {
+	profile, err := ac.Service.Profile()
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, profile)
+}
+
+// SaveProfile is the function to create or replace the profile from JSON body
+func (ac *APIController) SaveProfile(w http.ResponseWriter, r *http.Request) {
+	profile, err := decodeProfile(r)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	newProfile, err := ac.Service.SaveProfile(profile)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newProfile)
+}
+
+// Stats is the function to send the summary of all the weight data
+func (ac *APIController) Stats(w http.ResponseWriter, r *http.Request) {
+	stats, err := ac.Service.Stats()
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// Gaps is the function to send the missing dates
+// and the current logging streak
+func (ac *APIController) Gaps(w http.ResponseWriter, r *http.Request) {
+	gaps, err := ac.Service.Gaps(time.Now())
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, gaps)
+}
+
+// Compare is the function to compare the averages of two periods, taken
+// from the same queries as the comparison view
+func (ac *APIController) Compare(w http.ResponseWriter, r *http.Request) {
+	first, second := comparePeriods(r.URL.Query())
+
+	comparison, err := ac.Service.Compare(first, second)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, comparison)
+}
+
+// Patterns is the function to send the averages of every day
+// of the week and month of the year against the overall trend
+func (ac *APIController) Patterns(w http.ResponseWriter, r *http.Request) {
+	patterns, err := ac.Service.Patterns()
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, patterns)
+}
+
+// Forecast is the function to send the forecast of the next days
+// with their prediction intervals, by the model query
+func (ac *APIController) Forecast(w http.ResponseWriter, r *http.Request) {
+	forecast, err := ac.Service.Forecast(r.URL.Query().Get("model"), time.Now())
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, forecast)
+}
+
+// decodeWeight binds the weight from the request body. The values that
+// could not be parsed are reported together with the validation failures
+func decodeWeight(r *http.Request) (*models.Weight, error) {
+	weight, errs, err := bindWeight(r)
+	if err != nil {
+		return nil, &badRequestError{err}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return weight, nil
+}
+
+// decodeReading binds the reading from the request body like decodeWeight
+func decodeReading(r *http.Request) (*models.Reading, error) {
+	reading, errs, err := bindReading(r)
+	if err != nil {
+		return nil, &badRequestError{err}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return reading, nil
+}
+
+// decodeMeasurement binds the measurement from the request body like decodeWeight
+func decodeMeasurement(r *http.Request) (*models.Measurement, error) {
+	measurement, errs, err := bindMeasurement(r)
+	if err != nil {
+		return nil, &badRequestError{err}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return measurement, nil
+}
+
+// decodeProfile binds the profile from the request body like decodeWeight
+func decodeProfile(r *http.Request) (*models.Profile, error) {
+	profile, errs, err := bindProfile(r)
+	if err != nil {
+		return nil, &badRequestError{err}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return profile, nil
+}
+
+// badRequestError marks errors caused by a malformed request body
+type badRequestError struct {
+	err error
+}
+
+func (e *badRequestError) Error() string {
+	return e.err.Error()
+}
+
+// writeServiceError sends the error with the status code matching it
+func writeServiceError(w http.ResponseWriter, err error) {
+	if errs, ok := err.(models.ValidationErrors); ok {
+		writeJSON(w, http.StatusUnprocessableEntity, ErrorResponse{Error: "Validation failed", Errors: errs})
+		return
+	}
+
+	if rules, ok := err.(services.RuleErrors); ok {
+		writeJSON(w, http.StatusUnprocessableEntity, ErrorResponse{Error: "Plausibility rules failed", Rules: rules})
+		return
+	}
+
+	status := http.StatusInternalServerError
+	if _, ok := err.(*badRequestError); ok {
+		status = http.StatusBadRequest
+	}
+
+	switch err {
+	case services.ErrNotFound, services.ErrReadingNotFound,
+		services.ErrMeasurementNotFound, services.ErrMeasurementTypeNotFound,
+		services.ErrProfileNotFound:
+		status = http.StatusNotFound
+	case services.ErrDuplicateDate, services.ErrDuplicateMeasurement,
+		services.ErrIdempotencyKeyInProgress, services.ErrDayEnteredByHand,
+		services.ErrDayMadeOfReadings:
+		status = http.StatusConflict
+	case services.ErrIdempotencyKeyReused, services.ErrNotEnoughHistory:
+		status = http.StatusUnprocessableEntity
+	}
+
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,374 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/erizkiatama/berat/models/mocks"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+
+	"github.com/erizkiatama/berat/controllers"
+)
+
+type APISuite struct {
+	suite.Suite
+	repo    *mocks.WeightRepository
+	service *services.WeightService
+	weight  *models.Weight
+	router  *mux.Router
+}
+
+func (s *APISuite) SetupSuite() {
+	s.repo = new(mocks.WeightRepository)
+	s.router = mux.NewRouter()
+	s.service = services.NewWeightService(s.repo)
+	controllers.NewAPIController(s.service, s.router)
+}
+
+func (s *APISuite) BeforeTest(_, _ string) {
+	s.weight = &models.Weight{
+		ID:         1,
+		Date:       "2020-11-09",
+		Max:        50,
+		Min:        48,
+		Difference: 2,
+	}
+}
+
+func (s *APISuite) AfterTest(_, _ string) {
+	s.repo.AssertExpectations(s.T())
+}
+
+func TestAPIInit(t *testing.T) {
+	suite.Run(t, new(APISuite))
+}
+
+func (s *APISuite) serveJSON(method, target, body string) *http.Response {
+	req, err := http.NewRequest(method, target, strings.NewReader(body))
+	require.NoError(s.T(), err)
+
+	req.Header.Set("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+
+	return rec.Result()
+}
+
+func (s *APISuite) Test_List_Return_All_Weights() {
+	s.repo.On("FindAll").Return(&[]models.Weight{*s.weight}, nil).Once()
+	s.repo.On("FindWeightTagNames").Return(map[uint64][]string{}, nil).Once()
+
+	res := s.serveJSON(http.MethodGet, "/api/weights", "")
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusOK, res.StatusCode)
+
+	var weights []models.Weight
+	require.NoError(s.T(), json.NewDecoder(res.Body).Decode(&weights))
+	require.Equal(s.T(), []models.Weight{*s.weight}, weights)
+}
+
+func (s *APISuite) Test_Show_When_Weight_Not_Found() {
+	s.repo.On("FindByID", s.weight.ID).Return(&models.Weight{}, gorm.ErrRecordNotFound).Once()
+
+	res := s.serveJSON(http.MethodGet, fmt.Sprintf("/api/weights/%d", s.weight.ID), "")
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusNotFound, res.StatusCode)
+}
+
+func (s *APISuite) Test_Create_When_Data_Is_Valid() {
+	s.weight.ID = 0
+
+	s.repo.On("FindByDate", s.weight.Date).Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("Save", s.weight).Return(&models.Weight{ID: 7}, nil).Once()
+
+	res := s.serveJSON(http.MethodPost, "/api/weights", `{"date":"2020-11-09","max":50,"min":48}`)
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusCreated, res.StatusCode)
+	require.Equal(s.T(), "/api/weights/7", res.Header.Get("Location"))
+}
+
+func (s *APISuite) Test_Create_When_Data_Is_Invalid_Return_Field_Errors() {
+	res := s.serveJSON(http.MethodPost, "/api/weights", `{"date":"","max":40,"min":48}`)
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusUnprocessableEntity, res.StatusCode)
+
+	var body controllers.ErrorResponse
+	require.NoError(s.T(), json.NewDecoder(res.Body).Decode(&body))
+	require.Equal(s.T(), models.ValidationErrors{
+		"date": "Required date",
+		"max":  "Max weight could not be smaller than min weight",
+	}, body.Errors)
+}
+
+func (s *APISuite) Test_Create_When_Rules_Fail_Return_Rule_Errors() {
+	s.service.Rules = services.Rules{MaxWeight: 300}
+	defer func() { s.service.Rules = services.Rules{} }()
+
+	res := s.serveJSON(http.MethodPost, "/api/weights", `{"date":"2020-11-09","max":750,"min":48}`)
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusUnprocessableEntity, res.StatusCode)
+
+	var body controllers.ErrorResponse
+	require.NoError(s.T(), json.NewDecoder(res.Body).Decode(&body))
+	require.Equal(s.T(), services.RuleErrors{
+		services.RuleMaxWeight: "Max weight could not be more than 300",
+	}, body.Rules)
+}
+
+func (s *APISuite) Test_Create_When_Value_Has_Wrong_Type() {
+	res := s.serveJSON(http.MethodPost, "/api/weights", `{"date":"2020-11-09","max":"abc","min":48}`)
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusUnprocessableEntity, res.StatusCode)
+
+	var body controllers.ErrorResponse
+	require.NoError(s.T(), json.NewDecoder(res.Body).Decode(&body))
+	require.Contains(s.T(), body.Errors, "max")
+}
+
+func (s *APISuite) Test_Create_When_Body_Is_Not_JSON() {
+	res := s.serveJSON(http.MethodPost, "/api/weights", `date=2020-11-09`)
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusBadRequest, res.StatusCode)
+}
+
+func (s *APISuite) Test_Create_When_Weight_Already_In_Database() {
+	s.repo.On("FindByDate", s.weight.Date).Return(s.weight, nil).Once()
+
+	res := s.serveJSON(http.MethodPost, "/api/weights", `{"date":"2020-11-09","max":50,"min":48}`)
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusConflict, res.StatusCode)
+}
+
+func (s *APISuite) Test_Update_When_Data_Is_Valid() {
+	s.repo.On("FindByID", s.weight.ID).Return(s.weight, nil).Once()
+	s.repo.On("FindReadingsByWeightID", s.weight.ID).Return(&[]models.Reading{}, nil).Once()
+	s.repo.On("FindByDate", s.weight.Date).Return(s.weight, nil).Once()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("Update", s.weight.ID, s.weight).Return(s.weight, nil).Once()
+	s.repo.On("SetWeightTags", uint64(1), []string(nil)).Return(nil).Once()
+
+	res := s.serveJSON(http.MethodPut, "/api/weights/1", `{"date":"2020-11-09","max":50,"min":48}`)
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusOK, res.StatusCode)
+}
+
+func (s *APISuite) Test_Update_When_Database_Error() {
+	newError := errors.New("Error updating the database")
+
+	s.repo.On("FindByID", s.weight.ID).Return(s.weight, nil).Once()
+	s.repo.On("FindReadingsByWeightID", s.weight.ID).Return(&[]models.Reading{}, nil).Once()
+	s.repo.On("FindByDate", s.weight.Date).Return(s.weight, nil).Once()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("Update", s.weight.ID, s.weight).Return(&models.Weight{}, newError).Once()
+
+	res := s.serveJSON(http.MethodPut, "/api/weights/1", `{"date":"2020-11-09","max":50,"min":48}`)
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusInternalServerError, res.StatusCode)
+
+	var body controllers.ErrorResponse
+	require.NoError(s.T(), json.NewDecoder(res.Body).Decode(&body))
+	require.Equal(s.T(), newError.Error(), body.Error)
+}
+
+func (s *APISuite) Test_Delete_When_Weight_Exist() {
+	s.repo.On("FindByID", s.weight.ID).Return(s.weight, nil).Once()
+	s.repo.On("Delete", s.weight.ID).Return(nil).Once()
+
+	res := s.serveJSON(http.MethodDelete, "/api/weights/1", "")
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusNoContent, res.StatusCode)
+}
+
+func (s *APISuite) Test_RestoreTrash_When_Date_Taken_By_Another_Weight() {
+	s.repo.On("FindDeletedByID", uint64(1)).Return(&models.Weight{ID: 1, Date: "2020-11-09"}, nil).Once()
+	s.repo.On("FindByDate", "2020-11-09").Return(&models.Weight{ID: 2, Date: "2020-11-09"}, nil).Once()
+
+	res := s.serveJSON(http.MethodPost, "/api/trash/1/restore", "")
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusConflict, res.StatusCode)
+}
+
+func (s *APISuite) Test_UpsertByDate_When_Date_Is_Malformed() {
+	res := s.serveJSON(http.MethodPut, "/api/weights/by-date/09-11-2020", `{"max":50,"min":48}`)
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusUnprocessableEntity, res.StatusCode)
+}
+
+func (s *APISuite) Test_Stats_Return_Averages() {
+	weights := []models.Weight{
+		{ID: 1, Date: "2020-11-09", Max: 50, Min: 48, Difference: 2},
+		{ID: 2, Date: "2020-11-10", Max: 52, Min: 48, Difference: 4},
+	}
+	s.repo.On("FindAll").Return(&weights, nil).Once()
+	s.repo.On("FindAllMeasurementTypes").Return(&[]models.MeasurementType{}, nil).Once()
+	s.repo.On("FindAllMeasurements").Return(&[]models.Measurement{}, nil).Once()
+	s.repo.On("FindProfile").Return(nil, gorm.ErrRecordNotFound).Once()
+
+	res := s.serveJSON(http.MethodGet, "/api/stats", "")
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusOK, res.StatusCode)
+
+	var stats services.Stats
+	require.NoError(s.T(), json.NewDecoder(res.Body).Decode(&stats))
+	require.Equal(s.T(), services.Stats{
+		Count:        2,
+		AverageMax:   51,
+		AverageMin:   48,
+		AverageDiff:  3,
+		Measurements: []services.MeasurementStats{},
+	}, stats)
+}
+
+func (s *APISuite) Test_CreateMeasurement_When_Date_Already_Taken() {
+	s.repo.On("FindMeasurementTypeByID", uint64(1)).
+		Return(&models.MeasurementType{ID: 1, Name: "Body Fat", Unit: "%", MinValue: 2, MaxValue: 75}, nil).Once()
+	s.repo.On("FindMeasurementByTypeAndDate", uint64(1), "2020-11-09").
+		Return(&models.Measurement{ID: 4, TypeID: 1, Date: "2020-11-09", Value: 22}, nil).Once()
+
+	res := s.serveJSON(http.MethodPost, "/api/measurements", `{"type_id": 1, "date": "2020-11-09", "value": 21.4}`)
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusConflict, res.StatusCode)
+}
+
+func (s *APISuite) Test_Gaps_Return_Missing_Dates() {
+	today := time.Now()
+	weights := []models.Weight{
+		{ID: 1, Date: today.AddDate(0, 0, -2).Format(models.DateLayout), Max: 50, Min: 48},
+		{ID: 2, Date: today.Format(models.DateLayout), Max: 50, Min: 48},
+	}
+	s.repo.On("FindAll").Return(&weights, nil).Once()
+
+	res := s.serveJSON(http.MethodGet, "/api/gaps", "")
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusOK, res.StatusCode)
+
+	var gaps services.Gaps
+	require.NoError(s.T(), json.NewDecoder(res.Body).Decode(&gaps))
+	require.Equal(s.T(), []string{today.AddDate(0, 0, -1).Format(models.DateLayout)}, gaps.Missing)
+	require.Equal(s.T(), 1, gaps.Streak)
+	require.True(s.T(), gaps.LoggedToday)
+}
+
+func (s *APISuite) Test_Compare_Return_Comparison() {
+	s.repo.On("FindAll").Return(&[]models.Weight{
+		{Date: "2020-10-31", Max: 50, Min: 48, Difference: 2},
+		{Date: "2020-11-01", Max: 55, Min: 51, Difference: 4},
+	}, nil).Once()
+
+	res := s.serveJSON(http.MethodGet, "/api/compare?first_from=2020-10-01&first_to=2020-10-31&second_from=2020-11-01&second_to=2020-11-30", "")
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusOK, res.StatusCode)
+
+	var body services.Comparison
+	require.NoError(s.T(), json.NewDecoder(res.Body).Decode(&body))
+	require.Equal(s.T(), 1, body.First.Count)
+	require.Equal(s.T(), "2020-11-30", body.Second.To)
+	require.InDelta(s.T(), 5, body.Delta.Max, 0.001)
+	require.InDelta(s.T(), 100, float64(*body.Delta.DiffPercent), 0.001)
+}
+
+func (s *APISuite) Test_Compare_When_Period_Is_Invalid() {
+	res := s.serveJSON(http.MethodGet, "/api/compare?second_from=yesterday", "")
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusUnprocessableEntity, res.StatusCode)
+
+	var body controllers.ErrorResponse
+	require.NoError(s.T(), json.NewDecoder(res.Body).Decode(&body))
+	require.Equal(s.T(), models.ValidationErrors{
+		"second_from": "Please fill the date correctly (YYYY-MM-DD)",
+	}, body.Errors)
+}
+
+func (s *APISuite) Test_Patterns_Return_Weekdays_And_Months() {
+	s.repo.On("FindAll").Return(&[]models.Weight{
+		{ID: 1, Date: "2020-11-07", Max: 52, Min: 50, Difference: 2},
+		{ID: 2, Date: "2020-11-09", Max: 50, Min: 48, Difference: 2},
+	}, nil).Once()
+
+	res := s.serveJSON(http.MethodGet, "/api/patterns", "")
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusOK, res.StatusCode)
+
+	var body services.Patterns
+	require.NoError(s.T(), json.NewDecoder(res.Body).Decode(&body))
+	require.Equal(s.T(), 2, body.Count)
+	require.Equal(s.T(), "Saturday", body.Weekdays[5].Name)
+	require.Equal(s.T(), 1, body.Weekdays[5].Count)
+	require.Equal(s.T(), 2, body.Months[10].Count)
+}
+
+func (s *APISuite) Test_Forecast_Return_Horizons() {
+	s.repo.On("FindAll").Return(&[]models.Weight{
+		{Date: "2020-11-01", Max: 61, Min: 59},
+		{Date: "2020-11-02", Max: 62, Min: 60},
+		{Date: "2020-11-03", Max: 63, Min: 61},
+	}, nil).Once()
+
+	res := s.serveJSON(http.MethodGet, "/api/forecast", "")
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusOK, res.StatusCode)
+
+	var body services.Forecast
+	require.NoError(s.T(), json.NewDecoder(res.Body).Decode(&body))
+	require.Equal(s.T(), services.ForecastLinear, body.Model)
+	require.Equal(s.T(), 0.95, body.Level)
+	require.Len(s.T(), body.Horizons, 3)
+	require.Equal(s.T(), 60, body.Horizons[1].Days)
+	require.LessOrEqual(s.T(), body.Horizons[1].Lower, body.Horizons[1].Value)
+}
+
+func (s *APISuite) Test_Forecast_When_Not_Enough_History() {
+	s.repo.On("FindAll").Return(&[]models.Weight{*s.weight}, nil).Once()
+
+	res := s.serveJSON(http.MethodGet, "/api/forecast?model=holt", "")
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusUnprocessableEntity, res.StatusCode)
+}
+
+func (s *APISuite) Test_Forecast_When_Model_Is_Unknown() {
+	res := s.serveJSON(http.MethodGet, "/api/forecast?model=arima", "")
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusUnprocessableEntity, res.StatusCode)
+
+	var body controllers.ErrorResponse
+	require.NoError(s.T(), json.NewDecoder(res.Body).Decode(&body))
+	require.Equal(s.T(), "The model must be linear or holt", body.Errors["model"])
+}
@@ -2,79 +2,187 @@ package controllers
 
 import (
 	"fmt"
+	"html/template"
 	"net/http"
+	"net/url"
 	"strconv"
-	"text/template"
+	"strings"
+	"time"
 
 	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
 	"github.com/gorilla/mux"
-	"github.com/jinzhu/gorm"
 )
 
 // Response is struct for sending response data to HTML templates
 type Response struct {
 	Data        interface{}
 	Error       string
+	Errors      models.ValidationErrors
+	Rules       services.RuleErrors
+	Form        url.Values
+	Action      string
+	Readings    *[]models.Reading
 	AverageMax  string
 	AverageMin  string
 	AverageDiff string
+	CSRFToken   string
+	Flash       *Flash
+
+	Types            *[]models.MeasurementType
+	Measurements     map[string]map[uint64]*models.Measurement
+	MeasurementStats []services.MeasurementStats
+
+	Profile    *models.Profile
+	BMI        map[uint64]*services.BMI
+	AverageBMI *services.BMI
+
+	Tag       string
+	Tags      *[]models.Tag
+	TagReport []services.TagStats
+
+	PurgeAfterDays int
+
+	Bulk *services.BulkReport
+
+	Gaps *services.Gaps
+
+	Anomaly          *services.Anomaly
+	Outliers         map[uint64]*services.Anomaly
+	ExcludedOutliers int
+
+	Changes    map[uint64]*services.DayChange
+	Comparison *services.Comparison
+
+	Patterns *services.Patterns
+
+	Forecast *services.Forecast
+	Chart    *Chart
 }
 
 // WeightController is a wrapper for our controller
-// so it could use repository and template
+// so it could use the weight service and template
 type WeightController struct {
-	WeightRepo models.Repository
-	Template   *template.Template
-	Router     *mux.Router
+	Service  *services.WeightService
+	Template *template.Template
+	Router   *mux.Router
 }
 
 // NewWeightController creates new WeightController
 // and defines the route that the controller have
-func NewWeightController(wr models.Repository, tmpl *template.Template, r *mux.Router) {
+func NewWeightController(ws *services.WeightService, tmpl *template.Template, r *mux.Router) {
 	wc := &WeightController{
-		WeightRepo: wr,
-		Template:   tmpl,
-		Router:     r,
+		Service:  ws,
+		Template: tmpl,
+		Router:   r,
 	}
 
+	r.Use(wc.Recover, wc.CSRF)
+	r.NotFoundHandler = http.HandlerFunc(wc.NotFound)
+	r.MethodNotAllowedHandler = http.HandlerFunc(wc.MethodNotAllowed)
+
 	r.HandleFunc("/", wc.Index).Methods("GET")
 	r.HandleFunc("/weight/new", wc.New).Methods("GET")
 	r.HandleFunc("/weight/insert", wc.Insert).Methods("POST")
+	r.HandleFunc("/weight/bulk", wc.NewBulk).Methods("GET")
+	r.HandleFunc("/weight/bulk/insert", wc.InsertBulk).Methods("POST")
+	r.HandleFunc("/weight/by-date/{date}", wc.UpsertByDate).Methods("PUT", "POST")
 	r.HandleFunc("/weight/{id}", wc.Detail).Methods("GET")
 	r.HandleFunc("/weight/{id}/edit", wc.Edit).Methods("GET")
 	r.HandleFunc("/weight/{id}/update", wc.Update).Methods("POST")
+	r.HandleFunc("/weight/{id}/delete", wc.Delete).Methods("POST")
+	r.HandleFunc("/reading/new", wc.NewReading).Methods("GET")
+	r.HandleFunc("/reading/insert", wc.InsertReading).Methods("POST")
+	r.HandleFunc("/reading/{id}/edit", wc.EditReading).Methods("GET")
+	r.HandleFunc("/reading/{id}/update", wc.UpdateReading).Methods("POST")
+	r.HandleFunc("/reading/{id}/delete", wc.DeleteReading).Methods("POST")
+	r.HandleFunc("/trash", wc.Trash).Methods("GET")
+	r.HandleFunc("/trash/{id}/restore", wc.RestoreTrash).Methods("POST")
+	r.HandleFunc("/trash/{id}/purge", wc.PurgeTrash).Methods("POST")
+	r.HandleFunc("/tags", wc.TagReport).Methods("GET")
+	r.HandleFunc("/compare", wc.Compare).Methods("GET")
+	r.HandleFunc("/patterns", wc.Patterns).Methods("GET")
+	r.HandleFunc("/forecast", wc.Forecast).Methods("GET")
+	r.HandleFunc("/calendar", wc.Calendar).Methods("GET")
+	r.HandleFunc("/profile", wc.EditProfile).Methods("GET")
+	r.HandleFunc("/profile/update", wc.UpdateProfile).Methods("POST")
+	r.HandleFunc("/measurements", wc.Measurements).Methods("GET")
+	r.HandleFunc("/measurement/new", wc.NewMeasurement).Methods("GET")
+	r.HandleFunc("/measurement/insert", wc.InsertMeasurement).Methods("POST")
+	r.HandleFunc("/measurement/{id}/edit", wc.EditMeasurement).Methods("GET")
+	r.HandleFunc("/measurement/{id}/update", wc.UpdateMeasurement).Methods("POST")
+	r.HandleFunc("/measurement/{id}/delete", wc.DeleteMeasurement).Methods("POST")
+	r.HandleFunc("/measurement-type/new", wc.NewMeasurementType).Methods("GET")
+	r.HandleFunc("/measurement-type/insert", wc.InsertMeasurementType).Methods("POST")
+	r.HandleFunc("/measurement-type/{id}/edit", wc.EditMeasurementType).Methods("GET")
+	r.HandleFunc("/measurement-type/{id}/update", wc.UpdateMeasurementType).Methods("POST")
+	r.HandleFunc("/measurement-type/{id}/delete", wc.DeleteMeasurementType).Methods("POST")
 }
 
-// Index is function for the index view,
-// showing all the weight data to the template
+// Index is function for the index view, showing all the weight data
+// to the template, or only the ones having the tag query
 func (wc *WeightController) Index(w http.ResponseWriter, r *http.Request) {
-	res := new(Response)
+	res := &Response{Tag: r.URL.Query().Get("tag")}
+
+	weights, err := wc.Service.List()
+	if err != nil {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusInternalServerError, "index.html", res)
+		return
+	}
+
+	if err := wc.Service.LoadTags(*weights); err != nil {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusInternalServerError, "index.html", res)
+		return
+	}
+
+	gaps := services.FindGaps(*weights, time.Now())
+	res.Gaps = &gaps
+	res.Outliers = services.FindOutliers(*weights)
+	res.Changes = services.DayChanges(*weights)
 
-	weights, err := wc.WeightRepo.FindAll()
+	if res.Tag != "" {
+		filtered := services.FilterByTag(*weights, res.Tag)
+		weights = &filtered
+	}
+
+	res.Tags, err = wc.Service.Tags()
 	if err != nil {
 		res.Error = err.Error()
-		w.WriteHeader(http.StatusInternalServerError)
-		wc.Template.ExecuteTemplate(w, "index.html", res)
+		wc.render(w, r, http.StatusInternalServerError, "index.html", res)
+		return
+	}
+
+	if err := wc.loadMeasurements(res); err != nil {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusInternalServerError, "index.html", res)
 		return
 	}
 
-	totalMax := 0.0
-	totalMin := 0.0
-	totalDiff := 0.0
-	size := float64(len(*weights))
+	if err := wc.loadBMI(res, *weights); err != nil {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusInternalServerError, "index.html", res)
+		return
+	}
 
-	for _, weight := range *weights {
-		totalMax += float64(weight.Max)
-		totalMin += float64(weight.Min)
-		totalDiff += float64(weight.Difference)
+	counted := *weights
+	if wc.Service.ExcludeOutliers {
+		counted = services.ExcludeOutliers(counted, res.Outliers)
+		res.ExcludedOutliers = len(*weights) - len(counted)
+		if res.Profile != nil {
+			res.AverageBMI = services.SummarizeBMI(res.Profile, counted)
+		}
 	}
 
+	stats := services.Summarize(counted)
+
 	res.Data = weights
-	res.AverageMax = fmt.Sprintf("%.2f", totalMax/size)
-	res.AverageMin = fmt.Sprintf("%.2f", totalMin/size)
-	res.AverageDiff = fmt.Sprintf("%.2f", totalDiff/size)
+	res.AverageMax = fmt.Sprintf("%.2f", stats.AverageMax)
+	res.AverageMin = fmt.Sprintf("%.2f", stats.AverageMin)
+	res.AverageDiff = fmt.Sprintf("%.2f", stats.AverageDiff)
 
-	wc.Template.ExecuteTemplate(w, "index.html", res)
+	wc.render(w, r, http.StatusOK, "index.html", res)
 }
 
 // Detail is function for the detail view,
@@ -85,96 +193,101 @@ func (wc *WeightController) Detail(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Redirect(w, r, "/", http.StatusBadRequest)
+		wc.renderError(w, r, http.StatusBadRequest, "Invalid weight id")
 		return
 	}
 
 	weightID := uint64(id)
 
-	weight, err := wc.WeightRepo.FindByID(weightID)
+	weight, err := wc.loadWeight(weightID)
+	if err != nil {
+		wc.renderServiceError(w, r, err)
+		return
+	}
+
+	readings, err := wc.Service.Readings(weightID)
 	if err != nil {
-		http.Redirect(w, r, "/", http.StatusNotFound)
+		wc.renderServiceError(w, r, err)
+		return
+	}
+
+	if err := wc.loadBMI(res, []models.Weight{*weight}); err != nil {
+		wc.renderServiceError(w, r, err)
 		return
 	}
 
 	res.Data = weight
-	wc.Template.ExecuteTemplate(w, "detail.html", res)
+	res.Readings = readings
+	wc.render(w, r, http.StatusOK, "detail.html", res)
 }
 
-// New is the function for showing new weight form in html template
+// New is the function for showing new weight form in html template,
+// the date is filled from the date query when it is given
 func (wc *WeightController) New(w http.ResponseWriter, r *http.Request) {
-	wc.Template.ExecuteTemplate(w, "new.html", nil)
+	res := new(Response)
+
+	if date := r.URL.Query().Get("date"); date != "" {
+		if _, err := time.Parse(models.DateLayout, date); err == nil {
+			res.Form = url.Values{"date": {date}}
+		}
+	}
+
+	wc.render(w, r, http.StatusOK, "new.html", res)
 }
 
 // Insert is the function to actually insert the data
 // after the new weight form is submitted
 func (wc *WeightController) Insert(w http.ResponseWriter, r *http.Request) {
 	res := new(Response)
-	weight := new(models.Weight)
 
 	if r.Method == "POST" {
-		date := r.FormValue("date")
-		max, err := strconv.Atoi(r.FormValue("max"))
+		weight, errs, err := bindWeight(r)
+		res.Form = r.PostForm
 		if err != nil {
-			res.Error = "Please fill the max value correctly"
-			w.WriteHeader(http.StatusUnprocessableEntity)
-			wc.Template.ExecuteTemplate(w, "new.html", res)
+			res.Error = err.Error()
+			wc.render(w, r, http.StatusBadRequest, "new.html", res)
 			return
-
 		}
 
-		min, err := strconv.Atoi(r.FormValue("min"))
-		if err != nil {
-			res.Error = "Please fill the min value correctly"
-			w.WriteHeader(http.StatusUnprocessableEntity)
-			wc.Template.ExecuteTemplate(w, "new.html", res)
+		if len(errs) > 0 {
+			res.Errors = errs
+			wc.render(w, r, http.StatusUnprocessableEntity, "new.html", res)
 			return
-
 		}
 
-		weight.Date = date
-		weight.Max = max
-		weight.Min = min
-		weight.Difference = weight.Max - weight.Min
-
-		err = weight.Validate()
-		if err != nil {
-			res.Error = err.Error()
-			w.WriteHeader(http.StatusBadRequest)
-			wc.Template.ExecuteTemplate(w, "new.html", res)
+		if !wc.confirmAnomaly(w, r, res, 0, weight, "new.html") {
 			return
+		}
 
+		newWeight, err := wc.Service.Create(weight)
+		if errs, ok := err.(models.ValidationErrors); ok {
+			res.Errors = errs
+			wc.render(w, r, http.StatusBadRequest, "new.html", res)
+			return
 		}
 
-		found, err := wc.WeightRepo.FindByDate(weight.Date)
-		if err != nil {
-			if err != gorm.ErrRecordNotFound {
-				res.Error = err.Error()
-				w.WriteHeader(http.StatusInternalServerError)
-				wc.Template.ExecuteTemplate(w, "new.html", res)
-				return
-			}
+		if rules, ok := err.(services.RuleErrors); ok {
+			res.Rules = rules
+			wc.render(w, r, http.StatusUnprocessableEntity, "new.html", res)
+			return
 		}
 
-		if found != nil {
-			res.Error = "Weight already in the database"
-			w.WriteHeader(http.StatusConflict)
-			wc.Template.ExecuteTemplate(w, "new.html", res)
+		if err == services.ErrDuplicateDate {
+			res.Error = err.Error()
+			wc.render(w, r, http.StatusConflict, "new.html", res)
 			return
 		}
 
-		newWeight, err := wc.WeightRepo.Save(weight)
 		if err != nil {
 			res.Error = err.Error()
-			w.WriteHeader(http.StatusInternalServerError)
-			wc.Template.ExecuteTemplate(w, "new.html", res)
+			wc.render(w, r, http.StatusInternalServerError, "new.html", res)
 			return
-
 		}
 
 		url := fmt.Sprintf("/weight/%d", newWeight.ID)
 
-		http.Redirect(w, r, url, http.StatusMovedPermanently)
+		setFlash(w, FlashSuccess, "Entry saved")
+		http.Redirect(w, r, url, http.StatusSeeOther)
 	}
 }
 
@@ -187,73 +300,256 @@ func (wc *WeightController) Edit(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Redirect(w, r, "/", http.StatusBadRequest)
+		wc.renderError(w, r, http.StatusBadRequest, "Invalid weight id")
 		return
 	}
 
 	weightID := uint64(id)
 
-	weight, err = wc.WeightRepo.FindByID(weightID)
+	weight, err = wc.loadWeight(weightID)
 	if err != nil {
-		http.Redirect(w, r, "/", http.StatusNotFound)
+		wc.renderServiceError(w, r, err)
 		return
 	}
 
 	res.Data = weight
-	wc.Template.ExecuteTemplate(w, "edit.html", res)
+	res.Form = url.Values{
+		"date":  {weight.Date},
+		"max":   {strconv.Itoa(weight.Max)},
+		"min":   {strconv.Itoa(weight.Min)},
+		"notes": {weight.Notes},
+		"tags":  {strings.Join(weight.Tags, ", ")},
+	}
+	wc.render(w, r, http.StatusOK, "edit.html", res)
 
 }
 
 // Update is the function to actually update the weight data
 // when edit weight form is submitted
 func (wc *WeightController) Update(w http.ResponseWriter, r *http.Request) {
-	weight := new(models.Weight)
-	res := &Response{Data: weight}
-	var (
-		date       string
-		max        int
-		min        int
-		difference int
-	)
+	res := &Response{Data: new(models.Weight)}
 
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Redirect(w, r, "/", http.StatusBadRequest)
+		wc.renderError(w, r, http.StatusBadRequest, "Invalid weight id")
 		return
 	}
 
+	weightID := uint64(id)
+
 	if r.Method == "POST" {
-		date = r.FormValue("date")
-		max, _ = strconv.Atoi(r.FormValue("max"))
-		min, _ = strconv.Atoi(r.FormValue("min"))
-		difference = max - min
-
-		weight.ID = uint64(id)
-		weight.Date = date
-		weight.Max = max
-		weight.Min = min
-		weight.Difference = difference
-
-		err := weight.Validate()
+		weight, errs, err := bindWeight(r)
+		res.Form = r.PostForm
+		if weight != nil {
+			weight.ID = weightID
+			res.Data = weight
+		}
+
 		if err != nil {
 			res.Error = err.Error()
-			w.WriteHeader(http.StatusBadRequest)
-			wc.Template.ExecuteTemplate(w, "edit.html", res)
+			wc.render(w, r, http.StatusBadRequest, "edit.html", res)
+			return
+		}
+
+		if len(errs) > 0 {
+			res.Errors = errs
+			wc.render(w, r, http.StatusUnprocessableEntity, "edit.html", res)
+			return
+		}
+
+		if !wc.confirmAnomaly(w, r, res, weightID, weight, "edit.html") {
+			return
+		}
+
+		newWeight, err := wc.Service.Update(weightID, weight)
+		if errs, ok := err.(models.ValidationErrors); ok {
+			res.Errors = errs
+			wc.render(w, r, http.StatusBadRequest, "edit.html", res)
+			return
+		}
+
+		if rules, ok := err.(services.RuleErrors); ok {
+			res.Rules = rules
+			wc.render(w, r, http.StatusUnprocessableEntity, "edit.html", res)
+			return
+		}
+
+		if err == services.ErrNotFound {
+			wc.renderServiceError(w, r, err)
 			return
+		}
+
+		if err == services.ErrDuplicateDate {
+			message := fmt.Sprintf("Weight for %s already in the database", weight.Date)
+			wc.renderError(w, r, http.StatusConflict, message)
+			return
+		}
 
+		if err == services.ErrDayMadeOfReadings {
+			res.Error = err.Error()
+			wc.render(w, r, http.StatusConflict, "edit.html", res)
+			return
 		}
 
-		newWeight, err := wc.WeightRepo.Update(weight.ID, weight)
 		if err != nil {
 			res.Error = err.Error()
-			w.WriteHeader(http.StatusInternalServerError)
-			wc.Template.ExecuteTemplate(w, "edit.html", res)
+			wc.render(w, r, http.StatusInternalServerError, "edit.html", res)
 			return
 		}
 
 		url := fmt.Sprintf("/weight/%d", newWeight.ID)
 
-		http.Redirect(w, r, url, http.StatusMovedPermanently)
+		setFlash(w, FlashSuccess, "Entry saved")
+		http.Redirect(w, r, url, http.StatusSeeOther)
+	}
+}
+
+// UpsertByDate is the function to set the weight data of the date in the
+// address, creating it or replacing its values. JSON requests are answered
+// like the API does, form requests are redirected to the detail page or get
+// the form again with the errors
+func (wc *WeightController) UpsertByDate(w http.ResponseWriter, r *http.Request) {
+	if isJSON(r) {
+		(&APIController{Service: wc.Service}).UpsertByDate(w, r)
+		return
+	}
+
+	date := mux.Vars(r)["date"]
+	res := &Response{Action: "/weight/by-date/" + url.PathEscape(date)}
+
+	weight, errs, err := bindWeight(r)
+	res.Form = r.PostForm
+	if res.Form != nil {
+		res.Form.Set("date", date)
+	}
+
+	if err != nil {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusBadRequest, "new.html", res)
+		return
+	}
+
+	if len(errs) > 0 {
+		res.Errors = errs
+		wc.render(w, r, http.StatusUnprocessableEntity, "new.html", res)
+		return
+	}
+
+	newWeight, created, err := wc.Service.Upsert(date, weight)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		res.Errors = errs
+		wc.render(w, r, http.StatusBadRequest, "new.html", res)
+		return
+	}
+
+	if rules, ok := err.(services.RuleErrors); ok {
+		res.Rules = rules
+		wc.render(w, r, http.StatusUnprocessableEntity, "new.html", res)
+		return
+	}
+
+	if err == services.ErrDayMadeOfReadings {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusConflict, "new.html", res)
+		return
+	}
+
+	if err != nil {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusInternalServerError, "new.html", res)
+		return
+	}
+
+	message := "Entry updated"
+	if created {
+		message = "Entry created"
+	}
+
+	setFlash(w, FlashSuccess, message)
+	http.Redirect(w, r, fmt.Sprintf("/weight/%d", newWeight.ID), http.StatusSeeOther)
+}
+
+// Delete is the function to move the weight data to the trash
+// when the delete button in detail page is submitted
+func (wc *WeightController) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		wc.renderError(w, r, http.StatusBadRequest, "Invalid weight id")
+		return
+	}
+
+	weightID := uint64(id)
+
+	err = wc.Service.Delete(weightID)
+	if err == services.ErrNotFound {
+		setFlash(w, FlashWarning, "Entry was already deleted")
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	if err != nil {
+		wc.renderServiceError(w, r, err)
+		return
+	}
+
+	setFlash(w, FlashSuccess, "Entry moved to trash")
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// loadWeight returns the weight data based on the id with its tags
+func (wc *WeightController) loadWeight(id uint64) (*models.Weight, error) {
+	weight, err := wc.Service.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	weights := []models.Weight{*weight}
+	if err := wc.Service.LoadTags(weights); err != nil {
+		return nil, err
+	}
+
+	return &weights[0], nil
+}
+
+// confirmAnomaly compares the submitted weight with the recent trend
+// unless the user already confirmed it or it is invalid or breaks a rule,
+// which is then reported by the service. When it looks like a typo the
+// form is shown again with the warning and false is returned, so the user
+// could fix it or confirm to save it anyway
+func (wc *WeightController) confirmAnomaly(w http.ResponseWriter, r *http.Request, res *Response, id uint64, weight *models.Weight, page string) bool {
+	if r.PostForm.Get("confirm_anomaly") != "" || weight.Validate() != nil || wc.Service.CheckRules(weight) != nil {
+		return true
+	}
+
+	anomaly, err := wc.Service.CheckAnomaly(id, weight)
+	if err != nil {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusInternalServerError, page, res)
+		return false
+	}
+
+	if anomaly == nil {
+		return true
+	}
+
+	res.Anomaly = anomaly
+	wc.render(w, r, http.StatusUnprocessableEntity, page, res)
+
+	return false
+}
+
+// render writes the status code and executes the named template,
+// filling in the data every page needs such as the CSRF token
+func (wc *WeightController) render(w http.ResponseWriter, r *http.Request, status int, name string, res *Response) {
+	if res == nil {
+		res = new(Response)
 	}
+
+	res.CSRFToken = CSRFToken(r)
+	res.Flash = popFlash(w, r)
+
+	w.WriteHeader(status)
+	wc.Template.ExecuteTemplate(w, name, res)
 }
@@ -1,8 +1,11 @@
 package controllers_test
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"html/template"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -10,32 +13,37 @@ import (
 	"strconv"
 	"strings"
 	"testing"
-	"text/template"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
 
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/erizkiatama/berat/models/mocks"
 
 	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
 
 	"github.com/erizkiatama/berat/controllers"
 )
 
 type Suite struct {
 	suite.Suite
-	repo   *mocks.WeightRepository
-	weight *models.Weight
-	router *mux.Router
+	repo    *mocks.WeightRepository
+	service *services.WeightService
+	weight  *models.Weight
+	router  *mux.Router
 }
 
 func (s *Suite) SetupSuite() {
 	template := template.Must(template.ParseGlob("../views/*.html"))
 	s.repo = new(mocks.WeightRepository)
 	s.router = mux.NewRouter()
-	controllers.NewWeightController(s.repo, template, s.router)
+	s.service = services.NewWeightService(s.repo)
+	controllers.NewWeightController(s.service, template, s.router)
 }
 
 func (s *Suite) BeforeTest(_, _ string) {
@@ -56,8 +64,28 @@ func TestInit(t *testing.T) {
 	suite.Run(t, new(Suite))
 }
 
+const csrfToken = "test-csrf-token"
+
+// newFormRequest builds a form POST request carrying a valid CSRF token
+func (s *Suite) newFormRequest(target string, v url.Values) *http.Request {
+	v.Set("csrf_token", csrfToken)
+
+	req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(v.Encode()))
+	require.NoError(s.T(), err)
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "csrf_token", Value: csrfToken})
+
+	return req
+}
+
 func (s *Suite) Test_Index_When_Database_Not_Empty() {
 	s.repo.On("FindAll").Return(&[]models.Weight{*s.weight}, nil).Once()
+	s.repo.On("FindWeightTagNames").Return(map[uint64][]string{}, nil).Once()
+	s.repo.On("FindAllTags").Return(&[]models.Tag{}, nil).Once()
+	s.repo.On("FindAllMeasurementTypes").Return(&[]models.MeasurementType{}, nil).Once()
+	s.repo.On("FindAllMeasurements").Return(&[]models.Measurement{}, nil).Once()
+	s.repo.On("FindProfile").Return(nil, gorm.ErrRecordNotFound).Once()
 
 	req, err := http.NewRequest(http.MethodGet, "/", nil)
 	require.NoError(s.T(), err)
@@ -83,6 +111,31 @@ func (s *Suite) Test_Index_When_Database_Not_Empty() {
 	require.Contains(s.T(), string(body), diff)
 }
 
+func (s *Suite) Test_Index_Show_Streak_And_Missing_Dates() {
+	today := time.Now()
+	weights := []models.Weight{
+		{ID: 1, Date: today.AddDate(0, 0, -3).Format(models.DateLayout), Max: 50, Min: 48},
+		{ID: 2, Date: today.AddDate(0, 0, -1).Format(models.DateLayout), Max: 50, Min: 48},
+	}
+	s.repo.On("FindAll").Return(&weights, nil).Once()
+	s.repo.On("FindWeightTagNames").Return(map[uint64][]string{}, nil).Once()
+	s.repo.On("FindAllTags").Return(&[]models.Tag{}, nil).Once()
+	s.repo.On("FindAllMeasurementTypes").Return(&[]models.MeasurementType{}, nil).Once()
+	s.repo.On("FindAllMeasurements").Return(&[]models.Measurement{}, nil).Once()
+	s.repo.On("FindProfile").Return(nil, gorm.ErrRecordNotFound).Once()
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	missing := today.AddDate(0, 0, -2).Format(models.DateLayout)
+	require.Contains(s.T(), body, "Rangkaian: <b>1 hari</b>")
+	require.Contains(s.T(), body, `<a href="/weight/new?date=`+today.Format(models.DateLayout)+`">Hari ini belum dicatat</a>`)
+	require.Contains(s.T(), body, "1 hari terlewat")
+	require.Contains(s.T(), body, `<a href="/weight/new?date=`+missing+`">`+missing+`</a>`)
+}
+
 func (s *Suite) Test_Index_When_Database_Error() {
 	newError := errors.New("Database transaction error")
 
@@ -105,7 +158,14 @@ func (s *Suite) Test_Index_When_Database_Error() {
 }
 
 func (s *Suite) Test_Detail_When_Weight_ID_Exist() {
+	readings := []models.Reading{
+		{ID: 5, WeightID: s.weight.ID, TakenAt: time.Date(2020, 11, 9, 6, 15, 0, 0, time.Local), Value: 48},
+	}
+
 	s.repo.On("FindByID", s.weight.ID).Return(s.weight, nil).Once()
+	s.repo.On("FindWeightTagNames").Return(map[uint64][]string{}, nil).Once()
+	s.repo.On("FindReadingsByWeightID", s.weight.ID).Return(&readings, nil).Once()
+	s.repo.On("FindProfile").Return(nil, gorm.ErrRecordNotFound).Once()
 
 	url := fmt.Sprintf("/weight/%d", s.weight.ID)
 	req, err := http.NewRequest(http.MethodGet, url, nil)
@@ -130,6 +190,27 @@ func (s *Suite) Test_Detail_When_Weight_ID_Exist() {
 	require.Contains(s.T(), string(body), max)
 	require.Contains(s.T(), string(body), min)
 	require.Contains(s.T(), string(body), diff)
+	require.Contains(s.T(), string(body), "06:15")
+	require.Contains(s.T(), string(body), "/reading/5/edit")
+	require.Contains(s.T(), string(body), "/reading/new?date="+s.weight.Date)
+	require.NotContains(s.T(), string(body), "/weight/1/edit")
+}
+
+func (s *Suite) Test_Detail_Of_Day_Entered_By_Hand_Link_To_Edit() {
+	s.repo.On("FindByID", s.weight.ID).Return(s.weight, nil).Once()
+	s.repo.On("FindWeightTagNames").Return(map[uint64][]string{}, nil).Once()
+	s.repo.On("FindReadingsByWeightID", s.weight.ID).Return(&[]models.Reading{}, nil).Once()
+	s.repo.On("FindProfile").Return(nil, gorm.ErrRecordNotFound).Once()
+
+	req, err := http.NewRequest(http.MethodGet, "/weight/1", nil)
+	require.NoError(s.T(), err)
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	require.Contains(s.T(), rec.Body.String(), "/weight/1/edit")
+	require.NotContains(s.T(), rec.Body.String(), "/reading/new")
 }
 
 func (s *Suite) Test_Detail_When_Invalid_Id() {
@@ -151,7 +232,7 @@ func (s *Suite) Test_Detail_When_Invalid_Id() {
 }
 
 func (s *Suite) Test_Detail_When_Weight_Id_Not_Found() {
-	s.repo.On("FindByID", s.weight.ID).Return(&models.Weight{}, errors.New("Record not found")).Once()
+	s.repo.On("FindByID", s.weight.ID).Return(&models.Weight{}, gorm.ErrRecordNotFound).Once()
 
 	url := fmt.Sprintf("/weight/%d", s.weight.ID)
 	req, err := http.NewRequest(http.MethodGet, url, nil)
@@ -170,6 +251,14 @@ func (s *Suite) Test_Detail_When_Weight_Id_Not_Found() {
 	require.Contains(s.T(), string(body), "Not Found")
 }
 
+func (s *Suite) Test_New_Fill_Date_From_Query() {
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/weight/new?date=2020-11-10", nil))
+
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	require.Contains(s.T(), rec.Body.String(), `name="date" value="2020-11-10"`)
+}
+
 func (s *Suite) Test_New_Function_Success_Return_Correct_Template() {
 	req, err := http.NewRequest(http.MethodGet, "/weight/new", nil)
 	require.NoError(s.T(), err)
@@ -185,12 +274,20 @@ func (s *Suite) Test_New_Function_Success_Return_Correct_Template() {
 	require.NoError(s.T(), err)
 	require.Contains(s.T(), string(body), "form")
 	require.Contains(s.T(), string(body), "action=\"insert\"")
+	require.Contains(s.T(), string(body), "name=\"csrf_token\"")
+
+	cookies := res.Cookies()
+	require.Len(s.T(), cookies, 1)
+	require.Equal(s.T(), "csrf_token", cookies[0].Name)
+	require.Contains(s.T(), string(body), cookies[0].Value)
 }
 
 func (s *Suite) Test_Insert_When_Data_Is_Valid() {
 	s.weight.ID = 0
 
-	s.repo.On("Save", s.weight).Return(s.weight, nil).Once()
+	s.repo.On("FindAll").Return(&[]models.Weight{}, nil).Once()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("Save", s.weight).Return(&models.Weight{ID: 1}, nil).Once()
 	s.repo.On("FindByDate", s.weight.Date).Return(nil, nil).Once()
 
 	v := url.Values{}
@@ -198,6 +295,24 @@ func (s *Suite) Test_Insert_When_Data_Is_Valid() {
 	v.Set("max", strconv.Itoa(s.weight.Max))
 	v.Set("min", strconv.Itoa(s.weight.Min))
 
+	req := s.newFormRequest("/weight/insert", v)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+
+	require.Equal(s.T(), http.StatusSeeOther, res.StatusCode)
+	require.Equal(s.T(), "/weight/1", res.Header.Get("Location"))
+	require.Equal(s.T(), "flash", res.Cookies()[0].Name)
+}
+
+func (s *Suite) Test_Insert_When_CSRF_Token_Is_Missing() {
+	v := url.Values{}
+	v.Set("date", s.weight.Date)
+	v.Set("max", strconv.Itoa(s.weight.Max))
+	v.Set("min", strconv.Itoa(s.weight.Min))
+
 	req, err := http.NewRequest(http.MethodPost, "/weight/insert", strings.NewReader(v.Encode()))
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	require.NoError(s.T(), err)
@@ -206,14 +321,38 @@ func (s *Suite) Test_Insert_When_Data_Is_Valid() {
 
 	s.router.ServeHTTP(rec, req)
 	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusForbidden, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), string(body), "403 Forbidden")
+}
+
+func (s *Suite) Test_Insert_Escape_The_Values_Sent_Back() {
+	tag := "<script>alert(1)</script><script>alert(2)</script>"
+
+	v := url.Values{}
+	v.Set("date", s.weight.Date)
+	v.Set("max", strconv.Itoa(s.weight.Max))
+	v.Set("min", strconv.Itoa(s.weight.Min))
+	v.Set("tags", tag)
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, s.newFormRequest("/weight/insert", v))
 
-	require.Equal(s.T(), http.StatusMovedPermanently, res.StatusCode)
+	require.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	require.NotContains(s.T(), rec.Body.String(), "<script>")
+	require.Contains(s.T(), rec.Body.String(), "&lt;script&gt;alert(1)")
 }
 
 func (s *Suite) Test_Insert_When_Data_Is_Invalid() {
 	s.weight.ID = 0
 	newError := errors.New("Error saving to database")
 
+	s.repo.On("FindAll").Return(&[]models.Weight{}, nil).Once()
+	s.repo.On("Transaction").Return().Once()
 	s.repo.On("Save", s.weight).Return(&models.Weight{}, newError).Once()
 	s.repo.On("FindByDate", s.weight.Date).Return(nil, nil).Once()
 
@@ -222,9 +361,7 @@ func (s *Suite) Test_Insert_When_Data_Is_Invalid() {
 	v.Set("max", strconv.Itoa(s.weight.Max))
 	v.Set("min", strconv.Itoa(s.weight.Min))
 
-	req, err := http.NewRequest(http.MethodPost, "/weight/insert", strings.NewReader(v.Encode()))
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	require.NoError(s.T(), err)
+	req := s.newFormRequest("/weight/insert", v)
 
 	rec := httptest.NewRecorder()
 
@@ -247,9 +384,7 @@ func (s *Suite) Test_Insert_When_Fail_To_Parse_Form_Data_Max() {
 	v.Set("max", "")
 	v.Set("min", strconv.Itoa(s.weight.Min))
 
-	req, err := http.NewRequest(http.MethodPost, "/weight/insert", strings.NewReader(v.Encode()))
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	require.NoError(s.T(), err)
+	req := s.newFormRequest("/weight/insert", v)
 
 	rec := httptest.NewRecorder()
 
@@ -272,9 +407,7 @@ func (s *Suite) Test_Insert_When_Fail_To_Parse_Form_Data_Min() {
 	v.Set("max", strconv.Itoa(s.weight.Max))
 	v.Set("min", "")
 
-	req, err := http.NewRequest(http.MethodPost, "/weight/insert", strings.NewReader(v.Encode()))
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	require.NoError(s.T(), err)
+	req := s.newFormRequest("/weight/insert", v)
 
 	rec := httptest.NewRecorder()
 
@@ -297,9 +430,7 @@ func (s *Suite) Test_Insert_When_Fail_To_Validate_Weight() {
 	v.Set("max", strconv.Itoa(s.weight.Min))
 	v.Set("min", strconv.Itoa(s.weight.Max))
 
-	req, err := http.NewRequest(http.MethodPost, "/weight/insert", strings.NewReader(v.Encode()))
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	require.NoError(s.T(), err)
+	req := s.newFormRequest("/weight/insert", v)
 
 	rec := httptest.NewRecorder()
 
@@ -314,9 +445,34 @@ func (s *Suite) Test_Insert_When_Fail_To_Validate_Weight() {
 	require.Contains(s.T(), string(body), newError.Error())
 }
 
+func (s *Suite) Test_Insert_When_Invalid_Keep_Submitted_Values() {
+	v := url.Values{}
+	v.Set("date", "")
+	v.Set("max", "abc")
+	v.Set("min", "47")
+
+	req := s.newFormRequest("/weight/insert", v)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusUnprocessableEntity, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), string(body), "value=\"abc\"")
+	require.Contains(s.T(), string(body), "value=\"47\"")
+	require.Contains(s.T(), string(body), "Required date")
+	require.Contains(s.T(), string(body), "Please fill the max value correctly")
+}
+
 func (s *Suite) Test_Insert_When_FindByDate_Is_Error() {
 	newError := errors.New("Error finding weight by date")
 
+	s.repo.On("FindAll").Return(&[]models.Weight{}, nil).Once()
 	s.repo.On("FindByDate", s.weight.Date).Return(&models.Weight{}, newError).Once()
 
 	v := url.Values{}
@@ -324,9 +480,7 @@ func (s *Suite) Test_Insert_When_FindByDate_Is_Error() {
 	v.Set("max", strconv.Itoa(s.weight.Max))
 	v.Set("min", strconv.Itoa(s.weight.Min))
 
-	req, err := http.NewRequest(http.MethodPost, "/weight/insert", strings.NewReader(v.Encode()))
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	require.NoError(s.T(), err)
+	req := s.newFormRequest("/weight/insert", v)
 
 	rec := httptest.NewRecorder()
 
@@ -344,6 +498,7 @@ func (s *Suite) Test_Insert_When_FindByDate_Is_Error() {
 func (s *Suite) Test_Insert_When_Weight_Already_In_Database() {
 	newError := errors.New("Weight already in the database")
 
+	s.repo.On("FindAll").Return(&[]models.Weight{}, nil).Once()
 	s.repo.On("FindByDate", s.weight.Date).Return(s.weight, nil).Once()
 
 	v := url.Values{}
@@ -351,9 +506,7 @@ func (s *Suite) Test_Insert_When_Weight_Already_In_Database() {
 	v.Set("max", strconv.Itoa(s.weight.Max))
 	v.Set("min", strconv.Itoa(s.weight.Min))
 
-	req, err := http.NewRequest(http.MethodPost, "/weight/insert", strings.NewReader(v.Encode()))
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	require.NoError(s.T(), err)
+	req := s.newFormRequest("/weight/insert", v)
 
 	rec := httptest.NewRecorder()
 
@@ -370,6 +523,7 @@ func (s *Suite) Test_Insert_When_Weight_Already_In_Database() {
 
 func (s *Suite) Test_Edit_With_Valid_Id_And_Weight_Exist() {
 	s.repo.On("FindByID", s.weight.ID).Return(s.weight, nil).Once()
+	s.repo.On("FindWeightTagNames").Return(map[uint64][]string{}, nil).Once()
 
 	url := fmt.Sprintf("/weight/%d/edit", s.weight.ID)
 	req, err := http.NewRequest(http.MethodGet, url, nil)
@@ -413,7 +567,7 @@ func (s *Suite) Test_Edit_With_Invalid_Id() {
 }
 
 func (s *Suite) Test_Edit_With_Weight_Not_Exist() {
-	s.repo.On("FindByID", s.weight.ID).Return(&models.Weight{}, errors.New("Record not found")).Once()
+	s.repo.On("FindByID", s.weight.ID).Return(&models.Weight{}, gorm.ErrRecordNotFound).Once()
 
 	url := fmt.Sprintf("/weight/%d/edit", s.weight.ID)
 	req, err := http.NewRequest(http.MethodGet, url, nil)
@@ -433,7 +587,13 @@ func (s *Suite) Test_Edit_With_Weight_Not_Exist() {
 }
 
 func (s *Suite) Test_Update_When_Data_Is_Valid() {
+	s.repo.On("FindAll").Return(&[]models.Weight{}, nil).Once()
+	s.repo.On("FindByID", s.weight.ID).Return(s.weight, nil).Once()
+	s.repo.On("FindReadingsByWeightID", s.weight.ID).Return(&[]models.Reading{}, nil).Once()
+	s.repo.On("FindByDate", s.weight.Date).Return(s.weight, nil).Once()
+	s.repo.On("Transaction").Return().Once()
 	s.repo.On("Update", s.weight.ID, s.weight).Return(s.weight, nil).Once()
+	s.repo.On("SetWeightTags", uint64(1), []string(nil)).Return(nil).Once()
 
 	v := url.Values{}
 	v.Set("date", s.weight.Date)
@@ -441,21 +601,49 @@ func (s *Suite) Test_Update_When_Data_Is_Valid() {
 	v.Set("min", strconv.Itoa(s.weight.Min))
 
 	url := fmt.Sprintf("/weight/%d/update", s.weight.ID)
-	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(v.Encode()))
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	require.NoError(s.T(), err)
+	req := s.newFormRequest(url, v)
 
 	rec := httptest.NewRecorder()
 
 	s.router.ServeHTTP(rec, req)
 	res := rec.Result()
 
-	require.Equal(s.T(), http.StatusMovedPermanently, res.StatusCode)
+	require.Equal(s.T(), http.StatusSeeOther, res.StatusCode)
+	require.Equal(s.T(), "/weight/1", res.Header.Get("Location"))
+	require.Equal(s.T(), "flash", res.Cookies()[0].Name)
+}
+
+func (s *Suite) Test_Update_When_CSRF_Token_Does_Not_Match() {
+	v := url.Values{}
+	v.Set("date", s.weight.Date)
+	v.Set("max", strconv.Itoa(s.weight.Max))
+	v.Set("min", strconv.Itoa(s.weight.Min))
+
+	url := fmt.Sprintf("/weight/%d/update", s.weight.ID)
+	req := s.newFormRequest(url, v)
+	req.Header.Set("Cookie", "csrf_token=another-session-token")
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusForbidden, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), string(body), "403 Forbidden")
 }
 
 func (s *Suite) Test_Update_When_Data_Is_Invalid() {
 	newError := errors.New("Error updating the database")
 
+	s.repo.On("FindAll").Return(&[]models.Weight{}, nil).Once()
+	s.repo.On("FindByID", s.weight.ID).Return(s.weight, nil).Once()
+	s.repo.On("FindReadingsByWeightID", s.weight.ID).Return(&[]models.Reading{}, nil).Once()
+	s.repo.On("FindByDate", s.weight.Date).Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("Transaction").Return().Once()
 	s.repo.On("Update", s.weight.ID, s.weight).Return(&models.Weight{}, newError).Once()
 
 	v := url.Values{}
@@ -464,9 +652,7 @@ func (s *Suite) Test_Update_When_Data_Is_Invalid() {
 	v.Set("min", strconv.Itoa(s.weight.Min))
 
 	url := fmt.Sprintf("/weight/%d/update", s.weight.ID)
-	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(v.Encode()))
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	require.NoError(s.T(), err)
+	req := s.newFormRequest(url, v)
 
 	rec := httptest.NewRecorder()
 
@@ -484,9 +670,11 @@ func (s *Suite) Test_Update_When_Data_Is_Invalid() {
 func (s *Suite) Test_Update_When_Fail_To_Validate_Weight() {
 	newError := errors.New("Required date")
 
-	req, err := http.NewRequest(http.MethodPost, "/weight/1/update", nil)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	require.NoError(s.T(), err)
+	v := url.Values{}
+	v.Set("max", strconv.Itoa(s.weight.Max))
+	v.Set("min", strconv.Itoa(s.weight.Min))
+
+	req := s.newFormRequest("/weight/1/update", v)
 
 	rec := httptest.NewRecorder()
 
@@ -501,9 +689,31 @@ func (s *Suite) Test_Update_When_Fail_To_Validate_Weight() {
 	require.Contains(s.T(), string(body), newError.Error())
 }
 
-func (s *Suite) Test_Update_When_Invalid_Id() {
-	req, err := http.NewRequest(http.MethodPost, "/weight/xyz/update", nil)
+func (s *Suite) Test_Update_When_Fail_To_Parse_Form_Data() {
+	v := url.Values{}
+	v.Set("date", s.weight.Date)
+	v.Set("max", "abc")
+	v.Set("min", strconv.Itoa(s.weight.Min))
+
+	req := s.newFormRequest("/weight/1/update", v)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusUnprocessableEntity, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
 	require.NoError(s.T(), err)
+	require.Contains(s.T(), string(body), "Please fill the max value correctly")
+	require.NotContains(s.T(), string(body), "Required max weight")
+	require.Contains(s.T(), string(body), "value=\"abc\"")
+}
+
+func (s *Suite) Test_Update_When_Invalid_Id() {
+	req := s.newFormRequest("/weight/xyz/update", url.Values{})
 
 	rec := httptest.NewRecorder()
 
@@ -513,3 +723,524 @@ func (s *Suite) Test_Update_When_Invalid_Id() {
 
 	require.Equal(s.T(), http.StatusBadRequest, res.StatusCode)
 }
+
+func (s *Suite) Test_Detail_When_Database_Error() {
+	s.repo.On("FindByID", s.weight.ID).Return(&models.Weight{}, errors.New("connection refused")).Once()
+
+	req, err := http.NewRequest(http.MethodGet, "/weight/1", nil)
+	require.NoError(s.T(), err)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusInternalServerError, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), string(body), "500 Internal Server Error")
+}
+
+func (s *Suite) Test_Edit_When_Database_Error() {
+	s.repo.On("FindByID", s.weight.ID).Return(&models.Weight{}, errors.New("connection refused")).Once()
+
+	req, err := http.NewRequest(http.MethodGet, "/weight/1/edit", nil)
+	require.NoError(s.T(), err)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusInternalServerError, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), string(body), "500 Internal Server Error")
+}
+
+func (s *Suite) Test_Update_When_Date_Belongs_To_Another_Weight() {
+	other := &models.Weight{ID: 2, Date: s.weight.Date}
+	s.repo.On("FindAll").Return(&[]models.Weight{}, nil).Once()
+	s.repo.On("FindByID", s.weight.ID).Return(s.weight, nil).Once()
+	s.repo.On("FindReadingsByWeightID", s.weight.ID).Return(&[]models.Reading{}, nil).Once()
+	s.repo.On("FindByDate", s.weight.Date).Return(other, nil).Once()
+
+	v := url.Values{}
+	v.Set("date", s.weight.Date)
+	v.Set("max", strconv.Itoa(s.weight.Max))
+	v.Set("min", strconv.Itoa(s.weight.Min))
+
+	req := s.newFormRequest("/weight/1/update", v)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusConflict, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), string(body), "409 Conflict")
+}
+
+func (s *Suite) Test_Update_When_Day_Is_Made_Of_Readings() {
+	s.repo.On("FindAll").Return(&[]models.Weight{}, nil).Once()
+	s.repo.On("FindByID", s.weight.ID).Return(s.weight, nil).Once()
+	s.repo.On("FindReadingsByWeightID", s.weight.ID).Return(&[]models.Reading{{ID: 3, WeightID: 1}}, nil).Once()
+
+	v := url.Values{}
+	v.Set("date", s.weight.Date)
+	v.Set("max", strconv.Itoa(s.weight.Max))
+	v.Set("min", strconv.Itoa(s.weight.Min))
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, s.newFormRequest("/weight/1/update", v))
+
+	require.Equal(s.T(), http.StatusConflict, rec.Code)
+	require.Contains(s.T(), rec.Body.String(), services.ErrDayMadeOfReadings.Error())
+}
+
+func (s *Suite) Test_Unknown_Route_Return_Not_Found_Page() {
+	req, err := http.NewRequest(http.MethodGet, "/weight/1/unknown", nil)
+	require.NoError(s.T(), err)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusNotFound, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), string(body), "404 Not Found")
+}
+
+func (s *Suite) Test_Wrong_Method_Return_Method_Not_Allowed_Page() {
+	req, err := http.NewRequest(http.MethodGet, "/weight/1/update", nil)
+	require.NoError(s.T(), err)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusMethodNotAllowed, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), string(body), "405 Method Not Allowed")
+}
+
+func (s *Suite) Test_Panic_Is_Recovered_With_Internal_Server_Error_Page() {
+	s.router.HandleFunc("/panic", func(http.ResponseWriter, *http.Request) {
+		panic("something went wrong")
+	})
+
+	req, err := http.NewRequest(http.MethodGet, "/panic", nil)
+	require.NoError(s.T(), err)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusInternalServerError, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), string(body), "500 Internal Server Error")
+}
+
+func (s *Suite) Test_Index_Show_Flash_Message_Once() {
+	s.repo.On("FindAll").Return(&[]models.Weight{*s.weight}, nil).Once()
+	s.repo.On("FindWeightTagNames").Return(map[uint64][]string{}, nil).Once()
+	s.repo.On("FindAllTags").Return(&[]models.Tag{}, nil).Once()
+	s.repo.On("FindAllMeasurementTypes").Return(&[]models.MeasurementType{}, nil).Once()
+	s.repo.On("FindAllMeasurements").Return(&[]models.Measurement{}, nil).Once()
+	s.repo.On("FindProfile").Return(nil, gorm.ErrRecordNotFound).Once()
+
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(s.T(), err)
+
+	flash := base64.RawURLEncoding.EncodeToString([]byte(`{"kind":"success","message":"Entry saved"}`))
+	req.AddCookie(&http.Cookie{Name: "flash", Value: flash})
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusOK, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), string(body), "Entry saved")
+
+	require.Contains(s.T(), string(body), "class=\"flash success\"")
+
+	cookies := res.Cookies()
+	require.Len(s.T(), cookies, 2)
+	require.Equal(s.T(), "flash", cookies[1].Name)
+	require.True(s.T(), cookies[1].MaxAge < 0)
+}
+
+func (s *Suite) Test_Delete_When_Weight_Exist() {
+	s.repo.On("FindByID", s.weight.ID).Return(s.weight, nil).Once()
+	s.repo.On("Delete", s.weight.ID).Return(nil).Once()
+
+	req := s.newFormRequest("/weight/1/delete", url.Values{})
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+
+	require.Equal(s.T(), http.StatusSeeOther, res.StatusCode)
+	require.Equal(s.T(), "/", res.Header.Get("Location"))
+	require.Equal(s.T(), "flash", res.Cookies()[0].Name)
+}
+
+func (s *Suite) Test_Delete_When_Weight_Not_Exist() {
+	s.repo.On("FindByID", s.weight.ID).Return(&models.Weight{}, gorm.ErrRecordNotFound).Once()
+
+	req := s.newFormRequest("/weight/1/delete", url.Values{})
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+
+	require.Equal(s.T(), http.StatusSeeOther, res.StatusCode)
+	require.Equal(s.T(), "/", res.Header.Get("Location"))
+}
+
+func (s *Suite) Test_Delete_When_Database_Error() {
+	s.repo.On("FindByID", s.weight.ID).Return(s.weight, nil).Once()
+	s.repo.On("Delete", s.weight.ID).Return(errors.New("connection refused")).Once()
+
+	req := s.newFormRequest("/weight/1/delete", url.Values{})
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusInternalServerError, res.StatusCode)
+}
+
+func (s *Suite) Test_UpsertByDate_Form_Redirect_To_Detail() {
+	s.repo.On("FindByDate", "2020-11-09").Return(s.weight, nil).Once()
+	s.repo.On("FindReadingsByWeightID", s.weight.ID).Return(&[]models.Reading{}, nil).Once()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("UpsertByDate", mock.AnythingOfType("*models.Weight")).
+		Run(func(args mock.Arguments) { args.Get(0).(*models.Weight).ID = 4 }).
+		Return(false, nil).Once()
+	s.repo.On("SetWeightTags", uint64(4), []string(nil)).Return(nil).Once()
+
+	req := s.newFormRequest("/weight/by-date/2020-11-09", url.Values{"max": {"50"}, "min": {"48"}})
+	req.Method = http.MethodPut
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+
+	require.Equal(s.T(), http.StatusSeeOther, res.StatusCode)
+	require.Equal(s.T(), "/weight/4", res.Header.Get("Location"))
+}
+
+func (s *Suite) Test_UpsertByDate_Form_Without_CSRF_Token() {
+	req, err := http.NewRequest(http.MethodPost, "/weight/by-date/2020-11-09", strings.NewReader("max=50&min=48"))
+	require.NoError(s.T(), err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+
+	require.Equal(s.T(), http.StatusForbidden, rec.Code)
+}
+
+func (s *Suite) Test_UpsertByDate_Form_Show_Errors_Again() {
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, s.newFormRequest("/weight/by-date/2020-11-09", url.Values{"max": {"abc"}, "min": {"48"}}))
+
+	require.Equal(s.T(), http.StatusUnprocessableEntity, rec.Code)
+
+	body := rec.Body.String()
+	require.Contains(s.T(), body, `action="/weight/by-date/2020-11-09"`)
+	require.Contains(s.T(), body, `class="error"`)
+	require.Contains(s.T(), body, `value="2020-11-09"`)
+}
+
+func (s *Suite) Test_UpsertByDate_Form_When_Day_Is_Made_Of_Readings() {
+	s.repo.On("FindByDate", "2020-11-09").Return(s.weight, nil).Once()
+	s.repo.On("FindReadingsByWeightID", s.weight.ID).Return(&[]models.Reading{{ID: 3, WeightID: 1}}, nil).Once()
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, s.newFormRequest("/weight/by-date/2020-11-09", url.Values{"max": {"50"}, "min": {"48"}}))
+
+	require.Equal(s.T(), http.StatusConflict, rec.Code)
+	require.Contains(s.T(), rec.Body.String(), services.ErrDayMadeOfReadings.Error())
+}
+
+func (s *Suite) Test_UpsertByDate_JSON_Report_Created() {
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("FindByDate", "2020-11-09").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("UpsertByDate", mock.AnythingOfType("*models.Weight")).Return(true, nil).Once()
+	s.repo.On("SetWeightTags", uint64(0), []string(nil)).Return(nil).Once()
+
+	req, err := http.NewRequest(http.MethodPut, "/weight/by-date/2020-11-09", strings.NewReader(`{"max":50,"min":48}`))
+	require.NoError(s.T(), err)
+	req.Header.Set("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusCreated, res.StatusCode)
+
+	var body controllers.UpsertResponse
+	require.NoError(s.T(), json.NewDecoder(res.Body).Decode(&body))
+	require.True(s.T(), body.Created)
+	require.Equal(s.T(), "2020-11-09", body.Weight.Date)
+}
+
+// steadyWeights returns ten days of weight data from 2020-11-01
+// slowly going down
+func steadyWeights() []models.Weight {
+	weights := make([]models.Weight, 10)
+	for i := range weights {
+		max := 76 - i/3
+		weights[i] = models.Weight{ID: uint64(i + 1), Date: fmt.Sprintf("2020-11-%02d", i+1), Max: max, Min: max - 2}
+	}
+
+	return weights
+}
+
+func (s *Suite) Test_Insert_Warn_When_Weight_Looks_Like_A_Typo() {
+	weights := steadyWeights()
+	s.repo.On("FindAll").Return(&weights, nil).Once()
+
+	v := url.Values{}
+	v.Set("date", "2020-11-11")
+	v.Set("max", "750")
+	v.Set("min", "72")
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, s.newFormRequest("/weight/insert", v))
+
+	require.Equal(s.T(), http.StatusUnprocessableEntity, rec.Code)
+	require.Contains(s.T(), rec.Body.String(), "is it a typo?")
+	require.Contains(s.T(), rec.Body.String(), `name="confirm_anomaly" value="1"`)
+	require.Contains(s.T(), rec.Body.String(), `name="max" value="750"`)
+}
+
+func (s *Suite) Test_Insert_When_Rules_Fail() {
+	s.service.Rules = services.Rules{MaxWeight: 300}
+	defer func() { s.service.Rules = services.Rules{} }()
+
+	v := url.Values{}
+	v.Set("date", "2020-11-11")
+	v.Set("max", "750")
+	v.Set("min", "72")
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, s.newFormRequest("/weight/insert", v))
+
+	require.Equal(s.T(), http.StatusUnprocessableEntity, rec.Code)
+	require.Contains(s.T(), rec.Body.String(), "Max weight could not be more than 300")
+	require.NotContains(s.T(), rec.Body.String(), "confirm_anomaly")
+}
+
+func (s *Suite) Test_Insert_Save_Anomaly_When_Confirmed() {
+	weight := &models.Weight{Date: "2020-11-11", Max: 750, Min: 72, Difference: 678}
+	s.repo.On("FindByDate", "2020-11-11").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("Save", weight).Return(&models.Weight{ID: 11}, nil).Once()
+
+	v := url.Values{}
+	v.Set("date", "2020-11-11")
+	v.Set("max", "750")
+	v.Set("min", "72")
+	v.Set("confirm_anomaly", "1")
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, s.newFormRequest("/weight/insert", v))
+
+	require.Equal(s.T(), http.StatusSeeOther, rec.Code)
+	require.Equal(s.T(), "/weight/11", rec.Header().Get("Location"))
+}
+
+func (s *Suite) Test_Index_Flag_Outliers() {
+	weights := steadyWeights()
+	weights[7].Max = 750
+	s.repo.On("FindAll").Return(&weights, nil).Once()
+	s.repo.On("FindWeightTagNames").Return(map[uint64][]string{}, nil).Once()
+	s.repo.On("FindAllTags").Return(&[]models.Tag{}, nil).Once()
+	s.repo.On("FindAllMeasurementTypes").Return(&[]models.MeasurementType{}, nil).Once()
+	s.repo.On("FindAllMeasurements").Return(&[]models.Measurement{}, nil).Once()
+	s.repo.On("FindProfile").Return(nil, gorm.ErrRecordNotFound).Once()
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	require.Equal(s.T(), 1, strings.Count(rec.Body.String(), `class="outlier"`))
+	require.Contains(s.T(), rec.Body.String(), `<a href="/weight/8">2020-11-08</a> <span class="outlier"`)
+}
+
+func (s *Suite) Test_Index_Show_Day_Over_Day_Change() {
+	weights := []models.Weight{
+		{ID: 1, Date: "2020-11-08", Max: 50, Min: 48, Difference: 2},
+		{ID: 2, Date: "2020-11-09", Max: 52, Min: 47, Difference: 5},
+	}
+	s.repo.On("FindAll").Return(&weights, nil).Once()
+	s.repo.On("FindWeightTagNames").Return(map[uint64][]string{}, nil).Once()
+	s.repo.On("FindAllTags").Return(&[]models.Tag{}, nil).Once()
+	s.repo.On("FindAllMeasurementTypes").Return(&[]models.MeasurementType{}, nil).Once()
+	s.repo.On("FindAllMeasurements").Return(&[]models.Measurement{}, nil).Once()
+	s.repo.On("FindProfile").Return(nil, gorm.ErrRecordNotFound).Once()
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	require.Contains(s.T(), rec.Body.String(), `<td title="sejak 2020-11-08">&#43;2</td>`)
+	require.Contains(s.T(), rec.Body.String(), `<td title="sejak 2020-11-08">-1</td>`)
+}
+
+func (s *Suite) Test_Compare_Two_Periods() {
+	s.repo.On("FindAll").Return(&[]models.Weight{
+		{Date: "2020-10-31", Max: 50, Min: 48, Difference: 2},
+		{Date: "2020-11-01", Max: 55, Min: 51, Difference: 4},
+	}, nil).Once()
+
+	target := "/compare?first_from=2020-10-01&first_to=2020-10-31&second_from=2020-11-01&second_to=2020-11-30"
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	require.Contains(s.T(), rec.Body.String(), "<th>2020-10-01 - 2020-10-31</th>")
+	require.Contains(s.T(), rec.Body.String(), "<td>&#43;5.00</td>")
+	require.Contains(s.T(), rec.Body.String(), "<td>&#43;10.0%</td>")
+	require.Contains(s.T(), rec.Body.String(), "<td>&#43;100.0%</td>")
+}
+
+func (s *Suite) Test_Compare_When_Period_Is_Invalid() {
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/compare?first_from=2020-11-30&first_to=2020-11-01", nil))
+
+	require.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	require.Contains(s.T(), rec.Body.String(), "The end could not be before the start")
+	require.Contains(s.T(), rec.Body.String(), `name="first_from" value="2020-11-30"`)
+}
+
+func (s *Suite) Test_Patterns_Show_Weekdays_And_Months() {
+	s.repo.On("FindAll").Return(&[]models.Weight{
+		{ID: 1, Date: "2020-11-07", Max: 52, Min: 50, Difference: 2},
+		{ID: 2, Date: "2020-11-09", Max: 50, Min: 48, Difference: 2},
+	}, nil).Once()
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/patterns", nil))
+
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	require.Contains(s.T(), rec.Body.String(), "<td>Saturday</td>\n            <td>1</td>\n            \n            <td>52.00</td>")
+	require.Contains(s.T(), rec.Body.String(), "<td>November</td>\n            <td>2</td>")
+}
+
+// risingWeights returns the last 10 days until today, one more each day
+func risingWeights() []models.Weight {
+	weights := []models.Weight{}
+	today := time.Now()
+	for i := 0; i < 10; i++ {
+		weights = append(weights, models.Weight{
+			ID:   uint64(i + 1),
+			Date: today.AddDate(0, 0, i-9).Format(models.DateLayout),
+			Max:  61 + i,
+			Min:  59 + i,
+		})
+	}
+
+	return weights
+}
+
+func (s *Suite) Test_Forecast_Show_Chart_And_Horizons() {
+	weights := risingWeights()
+	s.repo.On("FindAll").Return(&weights, nil).Once()
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/forecast?model=holt", nil))
+
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	require.Contains(s.T(), rec.Body.String(), `<polygon class="band" points="`)
+	require.Contains(s.T(), rec.Body.String(), `<polyline class="history" points="48.0,`)
+	require.Contains(s.T(), rec.Body.String(), `<option value="holt" selected>`)
+
+	in30 := time.Now().AddDate(0, 0, 30).Format(models.DateLayout)
+	require.Contains(s.T(), rec.Body.String(), "<td>30</td>\n            <td>"+in30+"</td>\n            <td>99.0</td>")
+}
+
+func (s *Suite) Test_Forecast_When_Not_Enough_History() {
+	s.repo.On("FindAll").Return(&[]models.Weight{*s.weight}, nil).Once()
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/forecast", nil))
+
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	require.Contains(s.T(), rec.Body.String(), services.ErrNotEnoughHistory.Error())
+	require.NotContains(s.T(), rec.Body.String(), "<svg")
+}
+
+func (s *Suite) Test_Calendar_Link_Logged_And_Missing_Days() {
+	s.repo.On("FindAll").Return(&[]models.Weight{
+		{ID: 4, Date: "2020-11-02", Max: 50, Min: 48},
+		{ID: 7, Date: "2020-11-04", Max: 50, Min: 48},
+	}, nil).Once()
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/calendar?year=2020&month=11", nil))
+
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	require.Contains(s.T(), rec.Body.String(), `<a href="/calendar?year=2020&amp;month=10">&laquo;</a> November 2020 <a href="/calendar?year=2020&amp;month=12">&raquo;</a>`)
+	require.Contains(s.T(), rec.Body.String(), `<a href="/weight/4">2</a>`)
+	require.Contains(s.T(), rec.Body.String(), `<td class="missing"><a href="/weight/new?date=2020-11-03">3</a></td>`)
+	require.Contains(s.T(), rec.Body.String(), `<td class="empty"><a href="/weight/new?date=2020-11-01">1</a></td>`)
+}
+
+func (s *Suite) Test_Calendar_Of_A_Year() {
+	s.repo.On("FindAll").Return(&[]models.Weight{}, nil).Once()
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/calendar?year=2020", nil))
+
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	require.Equal(s.T(), 12, strings.Count(rec.Body.String(), `<th colspan="7">`))
+	require.Contains(s.T(), rec.Body.String(), `<a href="/calendar?year=2021">&raquo;</a>`)
+}
+
+func (s *Suite) Test_Calendar_Default_To_This_Month() {
+	s.repo.On("FindAll").Return(&[]models.Weight{}, nil).Once()
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/calendar?month=13", nil))
+
+	now := time.Now()
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	require.Contains(s.T(), rec.Body.String(), fmt.Sprintf(`<th colspan="7">%s %d</th>`, now.Month(), now.Year()))
+}
@@ -0,0 +1,427 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+// BindWeight decodes the weight fields of a form or JSON request into
+// models.Weight. Both bodies go through the same parsing, so a value that
+// could not be parsed is reported the same way in ValidationErrors keyed
+// by its field. The weight is returned even when some values failed, with
+// those fields left as zero. Any other error means the body is malformed
+func BindWeight(r *http.Request) (*models.Weight, error) {
+	values, err := requestValues(r, "date", "max", "min", "notes", "tags")
+	if err != nil {
+		return nil, err
+	}
+
+	return weightFromValues(values)
+}
+
+// bulkFields are the fields of every row of a bulk weight request
+var bulkFields = []string{"date", "max", "min", "notes", "tags"}
+
+// BindWeights decodes the rows of a bulk weight request, either a JSON body
+// {"mode": "best_effort", "weights": [{"date": ...}]} or a form repeating
+// the date, max, min, notes and tags fields once per line of the grid. Every
+// row is parsed like BindWeight does with its failures kept in the row. Form
+// lines without max, min, notes and tags are the unused lines of the grid
+// and are left out. Any error means the body is malformed
+func BindWeights(r *http.Request) (mode string, rows []services.BulkRow, err error) {
+	if isJSON(r) {
+		var body struct {
+			Mode    string                       `json:"mode"`
+			Weights []map[string]json.RawMessage `json:"weights"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return "", nil, fmt.Errorf("Invalid JSON body: %s", err)
+		}
+
+		for i, raw := range body.Weights {
+			rows = append(rows, bulkRow(i+1, jsonValues(raw, bulkFields...)))
+		}
+
+		return strings.TrimSpace(body.Mode), rows, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return "", nil, err
+	}
+
+	for i := range r.PostForm["date"] {
+		values := make(map[string]string, len(bulkFields))
+		for _, field := range bulkFields {
+			if i < len(r.PostForm[field]) {
+				values[field] = r.PostForm[field][i]
+			}
+		}
+
+		if strings.TrimSpace(values["max"]+values["min"]+values["notes"]+values["tags"]) == "" {
+			continue
+		}
+
+		rows = append(rows, bulkRow(i+1, values))
+	}
+
+	return strings.TrimSpace(r.PostForm.Get("mode")), rows, nil
+}
+
+// bulkRow parses the values of one line of a bulk weight request
+func bulkRow(line int, values map[string]string) services.BulkRow {
+	weight, err := weightFromValues(values)
+	errs, _ := err.(models.ValidationErrors)
+
+	return services.BulkRow{Line: line, Weight: weight, Errors: errs}
+}
+
+// weightFromValues parses the raw weight fields into models.Weight,
+// reporting the values that could not be parsed in ValidationErrors
+func weightFromValues(values map[string]string) (*models.Weight, error) {
+	var err error
+	weight := new(models.Weight)
+	errs := models.ValidationErrors{}
+
+	weight.Date = strings.TrimSpace(values["date"])
+	if weight.Date != "" {
+		if _, err := time.Parse(models.DateLayout, weight.Date); err != nil {
+			errs.Add("date", "Please fill the date correctly (YYYY-MM-DD)")
+		}
+	}
+
+	weight.Max, err = strconv.Atoi(strings.TrimSpace(values["max"]))
+	if err != nil {
+		errs.Add("max", "Please fill the max value correctly")
+	}
+
+	weight.Min, err = strconv.Atoi(strings.TrimSpace(values["min"]))
+	if err != nil {
+		errs.Add("min", "Please fill the min value correctly")
+	}
+
+	weight.Notes = strings.TrimSpace(values["notes"])
+
+	weight.Tags, err = parseTags(values["tags"])
+	if err != nil {
+		errs.Add("tags", "Please fill the tags as comma separated names")
+	}
+
+	if len(errs) > 0 {
+		return weight, errs
+	}
+
+	return weight, nil
+}
+
+// bindWeight binds the weight of the request. When some values could not
+// be parsed, the weight is validated too so every failure of the form is
+// returned at once in errs. err is only returned for a malformed body
+func bindWeight(r *http.Request) (weight *models.Weight, errs models.ValidationErrors, err error) {
+	weight, err = BindWeight(r)
+	if weight == nil {
+		return nil, nil, err
+	}
+
+	parseErrs, ok := err.(models.ValidationErrors)
+	if !ok {
+		return weight, nil, nil
+	}
+
+	if err := weight.Validate(); err != nil {
+		for field, message := range err.(models.ValidationErrors) {
+			parseErrs.Add(field, message)
+		}
+	}
+
+	return weight, parseErrs, nil
+}
+
+// ReadingTimeLayout is the format of the datetime-local input
+const ReadingTimeLayout = "2006-01-02T15:04"
+
+// readingTimeLayouts are all the accepted formats of Reading.TakenAt
+var readingTimeLayouts = []string{ReadingTimeLayout, "2006-01-02T15:04:05", time.RFC3339}
+
+// BindReading decodes the reading fields of a form or JSON request into
+// models.Reading the same way BindWeight does. A reading time without
+// timezone is taken in the local timezone of the server
+func BindReading(r *http.Request) (*models.Reading, error) {
+	values, err := requestValues(r, "taken_at", "value")
+	if err != nil {
+		return nil, err
+	}
+
+	reading := new(models.Reading)
+	errs := models.ValidationErrors{}
+
+	takenAt := strings.TrimSpace(values["taken_at"])
+	if takenAt != "" {
+		reading.TakenAt, err = parseReadingTime(takenAt)
+		if err != nil {
+			errs.Add("taken_at", "Please fill the reading time correctly (YYYY-MM-DDTHH:MM)")
+		}
+	}
+
+	reading.Value, err = strconv.Atoi(strings.TrimSpace(values["value"]))
+	if err != nil {
+		errs.Add("value", "Please fill the weight value correctly")
+	}
+
+	if len(errs) > 0 {
+		return reading, errs
+	}
+
+	return reading, nil
+}
+
+// bindReading binds the reading of the request like bindWeight does
+func bindReading(r *http.Request) (reading *models.Reading, errs models.ValidationErrors, err error) {
+	reading, err = BindReading(r)
+	if reading == nil {
+		return nil, nil, err
+	}
+
+	parseErrs, ok := err.(models.ValidationErrors)
+	if !ok {
+		return reading, nil, nil
+	}
+
+	if err := reading.Validate(); err != nil {
+		for field, message := range err.(models.ValidationErrors) {
+			parseErrs.Add(field, message)
+		}
+	}
+
+	return reading, parseErrs, nil
+}
+
+// BindMeasurement decodes the measurement fields of a form or JSON request
+// into models.Measurement the same way BindWeight does
+func BindMeasurement(r *http.Request) (*models.Measurement, error) {
+	values, err := requestValues(r, "type_id", "date", "value")
+	if err != nil {
+		return nil, err
+	}
+
+	measurement := new(models.Measurement)
+	errs := models.ValidationErrors{}
+
+	measurement.TypeID, err = strconv.ParseUint(strings.TrimSpace(values["type_id"]), 10, 64)
+	if err != nil {
+		errs.Add("type_id", "Please choose a measurement type")
+	}
+
+	measurement.Date = strings.TrimSpace(values["date"])
+	if measurement.Date != "" {
+		if _, err := time.Parse(models.DateLayout, measurement.Date); err != nil {
+			errs.Add("date", "Please fill the date correctly (YYYY-MM-DD)")
+		}
+	}
+
+	measurement.Value, err = strconv.ParseFloat(strings.TrimSpace(values["value"]), 64)
+	if err != nil {
+		errs.Add("value", "Please fill the value correctly")
+	}
+
+	if len(errs) > 0 {
+		return measurement, errs
+	}
+
+	return measurement, nil
+}
+
+// bindMeasurement binds the measurement of the request like bindWeight does.
+// The range of the value depends on its type, so it is checked by the service
+func bindMeasurement(r *http.Request) (measurement *models.Measurement, errs models.ValidationErrors, err error) {
+	measurement, err = BindMeasurement(r)
+	if measurement == nil {
+		return nil, nil, err
+	}
+
+	parseErrs, ok := err.(models.ValidationErrors)
+	if !ok {
+		return measurement, nil, nil
+	}
+
+	if measurement.Date == "" {
+		parseErrs.Add("date", "Required date")
+	}
+
+	return measurement, parseErrs, nil
+}
+
+// BindMeasurementType decodes the measurement type fields of a form or
+// JSON request into models.MeasurementType the same way BindWeight does
+func BindMeasurementType(r *http.Request) (*models.MeasurementType, error) {
+	values, err := requestValues(r, "name", "unit", "min_value", "max_value")
+	if err != nil {
+		return nil, err
+	}
+
+	mt := new(models.MeasurementType)
+	errs := models.ValidationErrors{}
+
+	mt.Name = strings.TrimSpace(values["name"])
+	mt.Unit = strings.TrimSpace(values["unit"])
+
+	mt.MinValue, err = strconv.ParseFloat(strings.TrimSpace(values["min_value"]), 64)
+	if err != nil {
+		errs.Add("min_value", "Please fill the min value correctly")
+	}
+
+	mt.MaxValue, err = strconv.ParseFloat(strings.TrimSpace(values["max_value"]), 64)
+	if err != nil {
+		errs.Add("max_value", "Please fill the max value correctly")
+	}
+
+	if len(errs) > 0 {
+		return mt, errs
+	}
+
+	return mt, nil
+}
+
+// bindMeasurementType binds the measurement type of the request like bindWeight does
+func bindMeasurementType(r *http.Request) (mt *models.MeasurementType, errs models.ValidationErrors, err error) {
+	mt, err = BindMeasurementType(r)
+	if mt == nil {
+		return nil, nil, err
+	}
+
+	parseErrs, ok := err.(models.ValidationErrors)
+	if !ok {
+		return mt, nil, nil
+	}
+
+	if err := mt.Validate(); err != nil {
+		for field, message := range err.(models.ValidationErrors) {
+			parseErrs.Add(field, message)
+		}
+	}
+
+	return mt, parseErrs, nil
+}
+
+// BindProfile decodes the profile fields of a form or JSON request
+// into models.Profile the same way BindWeight does
+func BindProfile(r *http.Request) (*models.Profile, error) {
+	values, err := requestValues(r, "height", "birth_date", "sex")
+	if err != nil {
+		return nil, err
+	}
+
+	profile := new(models.Profile)
+	errs := models.ValidationErrors{}
+
+	profile.Height, err = strconv.ParseFloat(strings.TrimSpace(values["height"]), 64)
+	if err != nil {
+		errs.Add("height", "Please fill the height correctly")
+	}
+
+	profile.BirthDate = strings.TrimSpace(values["birth_date"])
+	profile.Sex = strings.ToLower(strings.TrimSpace(values["sex"]))
+
+	if len(errs) > 0 {
+		return profile, errs
+	}
+
+	return profile, nil
+}
+
+// bindProfile binds the profile of the request like bindWeight does
+func bindProfile(r *http.Request) (profile *models.Profile, errs models.ValidationErrors, err error) {
+	profile, err = BindProfile(r)
+	if profile == nil {
+		return nil, nil, err
+	}
+
+	parseErrs, ok := err.(models.ValidationErrors)
+	if !ok {
+		return profile, nil, nil
+	}
+
+	if err := profile.Validate(); err != nil {
+		for field, message := range err.(models.ValidationErrors) {
+			parseErrs.Add(field, message)
+		}
+	}
+
+	return profile, parseErrs, nil
+}
+
+// parseTags reads the tags from comma separated names,
+// or from a JSON array of names
+func parseTags(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "[") {
+		return models.ParseTags(value), nil
+	}
+
+	var names []string
+	if err := json.Unmarshal([]byte(value), &names); err != nil {
+		return nil, err
+	}
+
+	return models.ParseTags(strings.Join(names, ",")), nil
+}
+
+func parseReadingTime(value string) (time.Time, error) {
+	var err error
+	for _, layout := range readingTimeLayouts {
+		var t time.Time
+		t, err = time.ParseInLocation(layout, value, time.Local)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, err
+}
+
+// requestValues reads the raw fields as strings from the form or JSON body
+func requestValues(r *http.Request, fields ...string) (map[string]string, error) {
+	values := make(map[string]string, len(fields))
+
+	if !isJSON(r) {
+		for _, field := range fields {
+			values[field] = r.PostFormValue(field)
+		}
+
+		return values, nil
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("Invalid JSON body: %s", err)
+	}
+
+	return jsonValues(body, fields...), nil
+}
+
+// jsonValues reads the raw fields of a JSON object as strings
+func jsonValues(body map[string]json.RawMessage, fields ...string) map[string]string {
+	values := make(map[string]string, len(fields))
+
+	for _, field := range fields {
+		raw, ok := body[field]
+		if !ok || string(raw) == "null" {
+			continue
+		}
+
+		var text string
+		if err := json.Unmarshal(raw, &text); err != nil {
+			text = string(raw)
+		}
+
+		values[field] = text
+	}
+
+	return values
+}
@@ -0,0 +1,222 @@
+package controllers_test
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+
+	"github.com/erizkiatama/berat/controllers"
+)
+
+func newFormBindRequest(t *testing.T, date, max, min string) *http.Request {
+	v := url.Values{}
+	v.Set("date", date)
+	v.Set("max", max)
+	v.Set("min", min)
+
+	req, err := http.NewRequest(http.MethodPost, "/", strings.NewReader(v.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return req
+}
+
+func newJSONBindRequest(t *testing.T, body string) *http.Request {
+	req, err := http.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	return req
+}
+
+func TestBindWeight(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    *http.Request
+		weight *models.Weight
+		errs   models.ValidationErrors
+	}{
+		{
+			name:   "form with valid values",
+			req:    newFormBindRequest(t, "2020-11-09", "50", " 48 "),
+			weight: &models.Weight{Date: "2020-11-09", Max: 50, Min: 48},
+		},
+		{
+			name:   "json with valid values",
+			req:    newJSONBindRequest(t, `{"date":"2020-11-09","max":50,"min":48}`),
+			weight: &models.Weight{Date: "2020-11-09", Max: 50, Min: 48},
+		},
+		{
+			name:   "json with numbers as strings",
+			req:    newJSONBindRequest(t, `{"date":"2020-11-09","max":"50","min":"48"}`),
+			weight: &models.Weight{Date: "2020-11-09", Max: 50, Min: 48},
+		},
+		{
+			name:   "json with notes and tags as array",
+			req:    newJSONBindRequest(t, `{"date":"2020-11-09","max":50,"min":48,"notes":" pizza ","tags":["Ate Out","sick","ate out"]}`),
+			weight: &models.Weight{Date: "2020-11-09", Max: 50, Min: 48, Notes: "pizza", Tags: []string{"ate out", "sick"}},
+		},
+		{
+			name:   "json with tags as comma separated names",
+			req:    newJSONBindRequest(t, `{"date":"2020-11-09","max":50,"min":48,"tags":"after run, ,sick"}`),
+			weight: &models.Weight{Date: "2020-11-09", Max: 50, Min: 48, Tags: []string{"after run", "sick"}},
+		},
+		{
+			name:   "form with letters as max",
+			req:    newFormBindRequest(t, "2020-11-09", "abc", "48"),
+			weight: &models.Weight{Date: "2020-11-09", Max: 0, Min: 48},
+			errs:   models.ValidationErrors{"max": "Please fill the max value correctly"},
+		},
+		{
+			name:   "json with letters as max",
+			req:    newJSONBindRequest(t, `{"date":"2020-11-09","max":"abc","min":48}`),
+			weight: &models.Weight{Date: "2020-11-09", Max: 0, Min: 48},
+			errs:   models.ValidationErrors{"max": "Please fill the max value correctly"},
+		},
+		{
+			name:   "form with decimal min",
+			req:    newFormBindRequest(t, "2020-11-09", "50", "48.5"),
+			weight: &models.Weight{Date: "2020-11-09", Max: 50, Min: 0},
+			errs:   models.ValidationErrors{"min": "Please fill the min value correctly"},
+		},
+		{
+			name:   "json with decimal min",
+			req:    newJSONBindRequest(t, `{"date":"2020-11-09","max":50,"min":48.5}`),
+			weight: &models.Weight{Date: "2020-11-09", Max: 50, Min: 0},
+			errs:   models.ValidationErrors{"min": "Please fill the min value correctly"},
+		},
+		{
+			name:   "form with empty numbers",
+			req:    newFormBindRequest(t, "2020-11-09", "", ""),
+			weight: &models.Weight{Date: "2020-11-09"},
+			errs: models.ValidationErrors{
+				"max": "Please fill the max value correctly",
+				"min": "Please fill the min value correctly",
+			},
+		},
+		{
+			name:   "json with missing and null numbers",
+			req:    newJSONBindRequest(t, `{"date":"2020-11-09","max":null}`),
+			weight: &models.Weight{Date: "2020-11-09"},
+			errs: models.ValidationErrors{
+				"max": "Please fill the max value correctly",
+				"min": "Please fill the min value correctly",
+			},
+		},
+		{
+			name:   "form with malformed date",
+			req:    newFormBindRequest(t, "09/11/2020", "50", "48"),
+			weight: &models.Weight{Date: "09/11/2020", Max: 50, Min: 48},
+			errs:   models.ValidationErrors{"date": "Please fill the date correctly (YYYY-MM-DD)"},
+		},
+		{
+			name:   "json with number as date",
+			req:    newJSONBindRequest(t, `{"date":20201109,"max":50,"min":48}`),
+			weight: &models.Weight{Date: "20201109", Max: 50, Min: 48},
+			errs:   models.ValidationErrors{"date": "Please fill the date correctly (YYYY-MM-DD)"},
+		},
+		{
+			name:   "form with empty date is left to validation",
+			req:    newFormBindRequest(t, "", "50", "48"),
+			weight: &models.Weight{Max: 50, Min: 48},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weight, err := controllers.BindWeight(tt.req)
+			require.Equal(t, tt.weight, weight)
+
+			if tt.errs == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Equal(t, tt.errs, err)
+		})
+	}
+}
+
+func TestBindWeight_When_JSON_Is_Malformed(t *testing.T) {
+	bodies := []string{
+		`{"date":"2020-11-09","max":50`,
+		`["2020-11-09",50,48]`,
+		`date=2020-11-09&max=50&min=48`,
+	}
+
+	for _, body := range bodies {
+		weight, err := controllers.BindWeight(newJSONBindRequest(t, body))
+		require.Error(t, err)
+		require.Nil(t, weight)
+
+		_, ok := err.(models.ValidationErrors)
+		require.False(t, ok)
+	}
+}
+
+func TestBindReading(t *testing.T) {
+	takenAt := time.Date(2020, 11, 9, 7, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		name    string
+		req     *http.Request
+		reading *models.Reading
+		errs    models.ValidationErrors
+	}{
+		{
+			name:    "form with datetime-local time",
+			req:     newFormReadingRequest(t, "2020-11-09T07:30", "49"),
+			reading: &models.Reading{TakenAt: takenAt, Value: 49},
+		},
+		{
+			name:    "json with RFC3339 time",
+			req:     newJSONBindRequest(t, `{"taken_at":"2020-11-09T07:30:00Z","value":49}`),
+			reading: &models.Reading{TakenAt: time.Date(2020, 11, 9, 7, 30, 0, 0, time.UTC), Value: 49},
+		},
+		{
+			name:    "form with malformed time",
+			req:     newFormReadingRequest(t, "09/11/2020 07:30", "49"),
+			reading: &models.Reading{Value: 49},
+			errs:    models.ValidationErrors{"taken_at": "Please fill the reading time correctly (YYYY-MM-DDTHH:MM)"},
+		},
+		{
+			name:    "json with letters as value",
+			req:     newJSONBindRequest(t, `{"taken_at":"2020-11-09T07:30","value":"abc"}`),
+			reading: &models.Reading{TakenAt: takenAt},
+			errs:    models.ValidationErrors{"value": "Please fill the weight value correctly"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reading, err := controllers.BindReading(tt.req)
+			require.True(t, tt.reading.TakenAt.Equal(reading.TakenAt))
+			require.Equal(t, tt.reading.Value, reading.Value)
+
+			if tt.errs == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Equal(t, tt.errs, err)
+		})
+	}
+}
+
+func newFormReadingRequest(t *testing.T, takenAt, value string) *http.Request {
+	v := url.Values{}
+	v.Set("taken_at", takenAt)
+	v.Set("value", value)
+
+	req, err := http.NewRequest(http.MethodPost, "/", strings.NewReader(v.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return req
+}
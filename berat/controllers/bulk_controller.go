@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+// DefaultBulkDays is the number of lines of the bulk entry grid
+// when the days query is empty
+const DefaultBulkDays = 31
+
+// BulkLine is one line of the bulk entry grid,
+// with the result of the line once the grid is submitted
+type BulkLine struct {
+	Line   int
+	Date   string
+	Max    string
+	Min    string
+	Notes  string
+	Tags   string
+	Result *services.BulkResult
+}
+
+// NewBulk is the function for showing the bulk entry grid. It has one line
+// per day for the days query starting from the from query, by default the
+// last DefaultBulkDays days until today
+func (wc *WeightController) NewBulk(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	days, err := strconv.Atoi(query.Get("days"))
+	if err != nil || days < 1 || days > services.MaxBulkRows {
+		days = DefaultBulkDays
+	}
+
+	from, err := time.Parse(models.DateLayout, query.Get("from"))
+	if err != nil {
+		from = time.Now().AddDate(0, 0, 1-days)
+	}
+
+	lines := make([]BulkLine, days)
+	for i := range lines {
+		lines[i] = BulkLine{Line: i + 1, Date: from.AddDate(0, 0, i).Format(models.DateLayout)}
+	}
+
+	res := &Response{
+		Data: lines,
+		Form: url.Values{"mode": {services.BulkAllOrNothing}},
+	}
+	wc.render(w, r, http.StatusOK, "bulk.html", res)
+}
+
+// InsertBulk is the function to actually insert the filled lines of the
+// bulk entry grid. The grid is shown again with the result of every line
+// unless all of them are saved
+func (wc *WeightController) InsertBulk(w http.ResponseWriter, r *http.Request) {
+	res := new(Response)
+
+	mode, rows, err := BindWeights(r)
+	res.Form = r.PostForm
+	if err != nil {
+		res.Error = err.Error()
+		res.Data = bulkLines(r.PostForm, nil)
+		wc.render(w, r, http.StatusBadRequest, "bulk.html", res)
+		return
+	}
+
+	report, err := wc.Service.CreateAll(mode, rows)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		res.Errors = errs
+		res.Data = bulkLines(r.PostForm, nil)
+		wc.render(w, r, http.StatusBadRequest, "bulk.html", res)
+		return
+	}
+
+	if err != nil {
+		res.Error = err.Error()
+		res.Data = bulkLines(r.PostForm, nil)
+		wc.render(w, r, http.StatusInternalServerError, "bulk.html", res)
+		return
+	}
+
+	if report.Created == len(report.Results) {
+		setFlash(w, FlashSuccess, fmt.Sprintf("%d entries saved", report.Created))
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	status := http.StatusOK
+	if report.Created == 0 {
+		status = http.StatusUnprocessableEntity
+	}
+
+	res.Bulk = report
+	res.Data = bulkLines(r.PostForm, report)
+	wc.render(w, r, status, "bulk.html", res)
+}
+
+// bulkLines rebuilds the lines of the submitted grid with their results.
+// The values of the saved lines are cleared, so submitting the grid again
+// only retries the lines that failed
+func bulkLines(form url.Values, report *services.BulkReport) []BulkLine {
+	results := make(map[int]*services.BulkResult)
+	if report != nil {
+		for i := range report.Results {
+			results[report.Results[i].Line] = &report.Results[i]
+		}
+	}
+
+	value := func(field string, i int) string {
+		if i < len(form[field]) {
+			return form[field][i]
+		}
+
+		return ""
+	}
+
+	lines := make([]BulkLine, len(form["date"]))
+	for i := range lines {
+		lines[i] = BulkLine{
+			Line:   i + 1,
+			Date:   value("date", i),
+			Max:    value("max", i),
+			Min:    value("min", i),
+			Notes:  value("notes", i),
+			Tags:   value("tags", i),
+			Result: results[i+1],
+		}
+
+		if lines[i].Result != nil && lines[i].Result.Status == services.BulkCreated {
+			lines[i].Max, lines[i].Min, lines[i].Notes, lines[i].Tags = "", "", "", ""
+		}
+	}
+
+	return lines
+}
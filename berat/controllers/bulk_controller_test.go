@@ -0,0 +1,163 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/controllers"
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+func TestBindWeights_Form_Skip_Empty_Lines(t *testing.T) {
+	v := url.Values{
+		"mode":  {"best_effort"},
+		"date":  {"2020-11-09", "2020-11-10", "2020-11-11"},
+		"max":   {"50", "", "abc"},
+		"min":   {"48", "", "49"},
+		"notes": {"", "", ""},
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "/", strings.NewReader(v.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	mode, rows, err := controllers.BindWeights(req)
+	require.NoError(t, err)
+	require.Equal(t, services.BulkBestEffort, mode)
+	require.Equal(t, []services.BulkRow{
+		{Line: 1, Weight: &models.Weight{Date: "2020-11-09", Max: 50, Min: 48}},
+		{
+			Line:   3,
+			Weight: &models.Weight{Date: "2020-11-11", Min: 49},
+			Errors: models.ValidationErrors{"max": "Please fill the max value correctly"},
+		},
+	}, rows)
+}
+
+func (s *Suite) Test_NewBulk_One_Line_Per_Day() {
+	req, err := http.NewRequest(http.MethodGet, "/weight/bulk?from=2020-11-01&days=30", nil)
+	require.NoError(s.T(), err)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusOK, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 30, strings.Count(string(body), `name="max"`))
+	require.Contains(s.T(), string(body), `value="2020-11-30"`)
+}
+
+func (s *Suite) Test_InsertBulk_When_All_Lines_Saved() {
+	s.repo.On("FindByDate", "2020-11-09").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("SaveAll", mock.Anything).Return(nil).Once()
+
+	req := s.newFormRequest("/weight/bulk/insert", url.Values{
+		"date": {"2020-11-09", "2020-11-10"},
+		"max":  {"50", ""},
+		"min":  {"48", ""},
+	})
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+
+	require.Equal(s.T(), http.StatusSeeOther, res.StatusCode)
+	require.Equal(s.T(), "/", res.Header.Get("Location"))
+}
+
+func (s *Suite) Test_InsertBulk_Save_Tags_Of_The_Lines() {
+	s.repo.On("FindByDate", "2020-11-09").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("SaveAll", mock.Anything).Return(nil).Once()
+	s.repo.On("SetWeightTags", uint64(0), []string{"ate out", "sick"}).Return(nil).Once()
+
+	req := s.newFormRequest("/weight/bulk/insert", url.Values{
+		"date": {"2020-11-09", "2020-11-10"},
+		"max":  {"50", ""},
+		"min":  {"48", ""},
+		"tags": {"ate out, sick", ""},
+	})
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+
+	require.Equal(s.T(), http.StatusSeeOther, rec.Code)
+}
+
+func (s *Suite) Test_InsertBulk_When_A_Line_Fails_Save_Nothing() {
+	s.repo.On("FindByDate", "2020-11-09").Return(&models.Weight{ID: 3, Date: "2020-11-09"}, nil).Once()
+	s.repo.On("FindByDate", "2020-11-10").Return(nil, gorm.ErrRecordNotFound).Once()
+
+	req := s.newFormRequest("/weight/bulk/insert", url.Values{
+		"mode": {services.BulkAllOrNothing},
+		"date": {"2020-11-09", "2020-11-10"},
+		"max":  {"50", "51"},
+		"min":  {"48", "49"},
+	})
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusUnprocessableEntity, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), string(body), "0 saved, 1 duplicate, 0 invalid, 1 not saved")
+	require.Contains(s.T(), string(body), "Weight already in the database")
+	require.Contains(s.T(), string(body), `value="51"`)
+}
+
+func (s *APISuite) Test_CreateBulk_Best_Effort_Report_Every_Row() {
+	s.repo.On("FindByDate", "2020-11-09").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("SaveAll", mock.Anything).Return(nil).Once()
+
+	res := s.serveJSON(http.MethodPost, "/api/weights/bulk", `{"mode":"best_effort","weights":[
+		{"date":"2020-11-09","max":50,"min":48},
+		{"date":"2020-11-10","max":40,"min":48}
+	]}`)
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusOK, res.StatusCode)
+
+	var report services.BulkReport
+	require.NoError(s.T(), json.NewDecoder(res.Body).Decode(&report))
+	require.Equal(s.T(), 1, report.Created)
+	require.Equal(s.T(), 1, report.Invalid)
+	require.Equal(s.T(), services.BulkInvalid, report.Results[1].Status)
+}
+
+func (s *APISuite) Test_CreateBulk_Save_Tags_Of_The_Rows() {
+	s.repo.On("FindByDate", "2020-11-09").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("SaveAll", mock.Anything).Return(nil).Once()
+	s.repo.On("SetWeightTags", uint64(0), []string{"ate out"}).Return(nil).Once()
+
+	res := s.serveJSON(http.MethodPost, "/api/weights/bulk", `{"weights":[
+		{"date":"2020-11-09","max":50,"min":48,"tags":["ate out"]}
+	]}`)
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusCreated, res.StatusCode)
+}
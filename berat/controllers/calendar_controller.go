@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/erizkiatama/berat/services"
+)
+
+// CalendarPage is the data shown by the calendar.html template,
+// with the addresses of the previous and next month or year
+type CalendarPage struct {
+	*services.Calendar
+	Title    string
+	Previous string
+	Next     string
+}
+
+// Calendar is the function for the calendar view, coloring every day by
+// its change against the trend. It shows the month of the month query in
+// the year query or this year, the twelve months of the year query alone,
+// or the current month
+func (wc *WeightController) Calendar(w http.ResponseWriter, r *http.Request) {
+	res := new(Response)
+	now := time.Now()
+
+	query := r.URL.Query()
+	year, err := strconv.Atoi(query.Get("year"))
+	if err != nil || year < 1 || year > 9999 {
+		year = 0
+	}
+
+	month, err := strconv.Atoi(query.Get("month"))
+	if err != nil || month < 1 || month > 12 {
+		month = 0
+	}
+
+	page := new(CalendarPage)
+	from, months := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC), 1
+	switch {
+	case year > 0 && month == 0:
+		from, months = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC), 12
+		page.Title = strconv.Itoa(year)
+		page.Previous = fmt.Sprintf("/calendar?year=%d", year-1)
+		page.Next = fmt.Sprintf("/calendar?year=%d", year+1)
+	default:
+		if month > 0 {
+			if year == 0 {
+				year = now.Year()
+			}
+			from = time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+		}
+
+		previous, next := from.AddDate(0, -1, 0), from.AddDate(0, 1, 0)
+		page.Title = fmt.Sprintf("%s %d", from.Month(), from.Year())
+		page.Previous = fmt.Sprintf("/calendar?year=%d&month=%d", previous.Year(), previous.Month())
+		page.Next = fmt.Sprintf("/calendar?year=%d&month=%d", next.Year(), next.Month())
+	}
+
+	page.Calendar, err = wc.Service.Calendar(from, months, now)
+	if err != nil {
+		wc.renderServiceError(w, r, err)
+		return
+	}
+
+	res.Data = page
+	wc.render(w, r, http.StatusOK, "calendar.html", res)
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+const (
+	chartWidth   = 720
+	chartHeight  = 320
+	chartPadding = 48
+
+	// ChartHistoryDays is how many days before the last logged
+	// day are drawn before the forecast on the chart
+	ChartHistoryDays = 180
+)
+
+// Chart is an SVG line chart of the logged weight and its forecast, with
+// the prediction interval as a band. The lines and the band are lists of
+// "x,y" points for the points attribute of polyline and polygon
+type Chart struct {
+	Width    int
+	Height   int
+	History  string
+	Forecast string
+	Band     string
+	XLabels  []ChartLabel
+	YLabels  []ChartLabel
+}
+
+// ChartLabel is a text on an axis of the chart
+type ChartLabel struct {
+	X    float64
+	Y    float64
+	Text string
+}
+
+// chartScale turns dates and weights into coordinates of the chart
+type chartScale struct {
+	start   time.Time
+	days    float64
+	low     float64
+	high    float64
+	width   float64
+	height  float64
+	padding float64
+}
+
+// NewForecastChart draws the last ChartHistoryDays days of the
+// history and the whole forecast with its prediction interval
+func NewForecastChart(forecast *services.Forecast) *Chart {
+	history := forecast.History
+	last, _ := time.Parse(models.DateLayout, history[len(history)-1].Date)
+	from := last.AddDate(0, 0, -ChartHistoryDays).Format(models.DateLayout)
+	for len(history) > 1 && history[0].Date < from {
+		history = history[1:]
+	}
+
+	scale := chartScale{
+		low:     math.Inf(1),
+		high:    math.Inf(-1),
+		width:   chartWidth - 2*chartPadding,
+		height:  chartHeight - 2*chartPadding,
+		padding: chartPadding,
+	}
+	scale.start, _ = time.Parse(models.DateLayout, history[0].Date)
+
+	end := last
+	if n := len(forecast.Points); n > 0 {
+		end, _ = time.Parse(models.DateLayout, forecast.Points[n-1].Date)
+	}
+	scale.days = math.Max(end.Sub(scale.start).Hours()/24, 1)
+
+	for _, observation := range history {
+		scale.low = math.Min(scale.low, observation.Value)
+		scale.high = math.Max(scale.high, observation.Value)
+	}
+	for _, point := range forecast.Points {
+		scale.low = math.Min(scale.low, point.Lower)
+		scale.high = math.Max(scale.high, point.Upper)
+	}
+	if scale.high-scale.low < 1 {
+		scale.low, scale.high = scale.low-0.5, scale.high+0.5
+	}
+
+	chart := &Chart{Width: chartWidth, Height: chartHeight}
+
+	var line []string
+	for _, observation := range history {
+		line = append(line, scale.point(observation.Date, observation.Value))
+	}
+	chart.History = strings.Join(line, " ")
+
+	// the forecast and its band start from the last logged day
+	start := history[len(history)-1]
+	forecastLine := []string{scale.point(start.Date, start.Value)}
+	upper := []string{scale.point(start.Date, start.Value)}
+	lower := []string{scale.point(start.Date, start.Value)}
+	for _, point := range forecast.Points {
+		forecastLine = append(forecastLine, scale.point(point.Date, point.Value))
+		upper = append(upper, scale.point(point.Date, point.Upper))
+		lower = append(lower, scale.point(point.Date, point.Lower))
+	}
+	for i, j := 0, len(lower)-1; i < j; i, j = i+1, j-1 {
+		lower[i], lower[j] = lower[j], lower[i]
+	}
+	chart.Forecast = strings.Join(forecastLine, " ")
+	chart.Band = strings.Join(append(upper, lower...), " ")
+
+	chart.XLabels = append(chart.XLabels, scale.xLabel(history[0].Date), scale.xLabel(start.Date))
+	for _, point := range forecast.Horizons {
+		chart.XLabels = append(chart.XLabels, scale.xLabel(point.Date))
+	}
+
+	for i := 0; i <= 4; i++ {
+		value := scale.low + (scale.high-scale.low)*float64(i)/4
+		_, y := scale.xy(history[0].Date, value)
+		chart.YLabels = append(chart.YLabels, ChartLabel{X: chartPadding - 6, Y: y, Text: fmt.Sprintf("%.1f", value)})
+	}
+
+	return chart
+}
+
+func (s chartScale) xy(date string, value float64) (float64, float64) {
+	day, _ := time.Parse(models.DateLayout, date)
+	x := s.padding + s.width*(day.Sub(s.start).Hours()/24)/s.days
+	y := s.padding + s.height*(s.high-value)/(s.high-s.low)
+
+	return x, y
+}
+
+func (s chartScale) point(date string, value float64) string {
+	x, y := s.xy(date, value)
+	return fmt.Sprintf("%.1f,%.1f", x, y)
+}
+
+func (s chartScale) xLabel(date string) ChartLabel {
+	x, _ := s.xy(date, s.low)
+	return ChartLabel{X: x, Y: chartHeight - chartPadding + 18, Text: date}
+}
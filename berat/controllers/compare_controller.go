@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+// Compare is the function for the comparison view, comparing the
+// averages of two periods, by default the previous and current month
+func (wc *WeightController) Compare(w http.ResponseWriter, r *http.Request) {
+	res := new(Response)
+
+	first, second := comparePeriods(r.URL.Query())
+	res.Form = url.Values{
+		"first_from":  {first.From},
+		"first_to":    {first.To},
+		"second_from": {second.From},
+		"second_to":   {second.To},
+	}
+
+	comparison, err := wc.Service.Compare(first, second)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		res.Errors = errs
+		wc.render(w, r, http.StatusBadRequest, "compare.html", res)
+		return
+	}
+
+	if err != nil {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusInternalServerError, "compare.html", res)
+		return
+	}
+
+	res.Comparison = comparison
+	wc.render(w, r, http.StatusOK, "compare.html", res)
+}
+
+// comparePeriods reads the periods from the first_from, first_to,
+// second_from and second_to queries, the empty ones are taken
+// from services.DefaultPeriods
+func comparePeriods(query url.Values) (services.Period, services.Period) {
+	first, second := services.DefaultPeriods(time.Now())
+
+	for value, target := range map[string]*string{
+		"first_from":  &first.From,
+		"first_to":    &first.To,
+		"second_from": &second.From,
+		"second_to":   &second.To,
+	} {
+		if v := query.Get(value); v != "" {
+			*target = v
+		}
+	}
+
+	return first, second
+}
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	csrfCookieName = "csrf_token"
+	csrfFieldName  = "csrf_token"
+	csrfHeaderName = "X-CSRF-Token"
+	csrfTokenBytes = 32
+)
+
+type csrfContextKey struct{}
+
+// CSRF is a middleware that protects every state-changing request
+// with a per-session token. The token is kept in a cookie and must be
+// sent back in the csrf_token form field or the X-CSRF-Token header.
+// JSON requests are exempt since browsers could not send them
+// cross-site without a CORS preflight, which this server never allows.
+// The API under /api/ is exempt too, it is guarded by SameOrigin
+func (wc *WeightController) CSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := ""
+		if cookie, err := r.Cookie(csrfCookieName); err == nil {
+			token = cookie.Value
+		}
+
+		if token == "" {
+			var err error
+			token, err = newCSRFToken()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			http.SetCookie(w, &http.Cookie{
+				Name:     csrfCookieName,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+
+		r = r.WithContext(context.WithValue(r.Context(), csrfContextKey{}, token))
+
+		if isStateChanging(r.Method) && !isJSON(r) && !isAPI(r) {
+			sent := r.Header.Get(csrfHeaderName)
+			if sent == "" {
+				sent = r.PostFormValue(csrfFieldName)
+			}
+
+			if subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+				wc.renderError(w, r, http.StatusForbidden, "")
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// SameOrigin is the API middleware taking the place of CSRF. The API is
+// used by scripts and the CLI, which have no CSRF cookie, so instead a
+// state-changing request sent by a browser from another origin, which
+// always has the Origin header, is refused
+func (ac *APIController) SameOrigin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); isStateChanging(r.Method) && origin != "" {
+			if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+				writeJSON(w, http.StatusForbidden, ErrorResponse{Error: "Cross-origin requests are not allowed"})
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CSRFToken returns the token issued to the session of given request,
+// or an empty string when the request did not pass the CSRF middleware
+func CSRFToken(r *http.Request) string {
+	token, _ := r.Context().Value(csrfContextKey{}).(string)
+
+	return token
+}
+
+func newCSRFToken() (string, error) {
+	b := make([]byte, csrfTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func isStateChanging(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return false
+	}
+
+	return true
+}
+
+func isAPI(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/api/")
+}
+
+func isJSON(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	return err == nil && mediaType == "application/json"
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/erizkiatama/berat/services"
+)
+
+// ErrorPage is the data shown by the error.html template
+type ErrorPage struct {
+	Status  int
+	Title   string
+	Message string
+}
+
+var errorMessages = map[int]string{
+	http.StatusBadRequest:          "The request could not be understood. Please check the address and try again.",
+	http.StatusForbidden:           "Your form session has expired or the request did not come from this site. Please reload the page and submit the form again.",
+	http.StatusNotFound:            "The page or data you are looking for does not exist.",
+	http.StatusMethodNotAllowed:    "This page could not be accessed that way.",
+	http.StatusConflict:            "The data conflicts with another weight data in the database.",
+	http.StatusInternalServerError: "Something went wrong on our side. Please try again later.",
+}
+
+// renderError renders the error page for the status code. When message
+// is empty the default message of the status code is shown instead
+func (wc *WeightController) renderError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	if message == "" {
+		message = errorMessages[status]
+	}
+
+	res := &Response{
+		Data: &ErrorPage{
+			Status:  status,
+			Title:   http.StatusText(status),
+			Message: message,
+		},
+	}
+
+	wc.render(w, r, status, "error.html", res)
+}
+
+// renderServiceError renders the 404 page when the data could not be found
+// and the 500 page for any other error coming from the service
+func (wc *WeightController) renderServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case services.ErrNotFound, services.ErrReadingNotFound,
+		services.ErrMeasurementNotFound, services.ErrMeasurementTypeNotFound:
+		wc.renderError(w, r, http.StatusNotFound, "")
+		return
+	}
+
+	log.Printf("service error on %s %s: %s", r.Method, r.URL.Path, err)
+	wc.renderError(w, r, http.StatusInternalServerError, "")
+}
+
+// Recover is a middleware that turns a panic in any handler into
+// the 500 error page and logs its stack trace
+func (wc *WeightController) Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if err := recover(); err != nil {
+				log.Printf("panic on %s %s: %v\n%s", r.Method, r.URL.Path, err, debug.Stack())
+				wc.renderError(w, r, http.StatusInternalServerError, "")
+			}
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NotFound renders the 404 page for every unknown route
+func (wc *WeightController) NotFound(w http.ResponseWriter, r *http.Request) {
+	wc.renderError(w, r, http.StatusNotFound, "")
+}
+
+// MethodNotAllowed renders the 405 page when a route is requested
+// with a method it does not support
+func (wc *WeightController) MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	wc.renderError(w, r, http.StatusMethodNotAllowed, "")
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const flashCookieName = "flash"
+
+// Kinds of flash message, also used as the css class in the templates
+const (
+	FlashSuccess = "success"
+	FlashWarning = "warning"
+)
+
+// Flash is a one-time message shown on the next page rendered
+// after a redirect, e.g. "Entry saved" after the form is submitted
+type Flash struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// setFlash stores the message in a cookie so it survives the redirect
+func setFlash(w http.ResponseWriter, kind, message string) {
+	value, err := json.Marshal(&Flash{Kind: kind, Message: message})
+	if err != nil {
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     flashCookieName,
+		Value:    base64.RawURLEncoding.EncodeToString(value),
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// popFlash returns the flash message of the request, if any,
+// and expires its cookie so the message is only shown once
+func popFlash(w http.ResponseWriter, r *http.Request) *Flash {
+	cookie, err := r.Cookie(flashCookieName)
+	if err != nil {
+		return nil
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     flashCookieName,
+		Path:     "/",
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	value, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil {
+		return nil
+	}
+
+	flash := new(Flash)
+	if err := json.Unmarshal(value, flash); err != nil {
+		return nil
+	}
+
+	return flash
+}
@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+// Forecast is the function for the forecast view, showing the likely
+// weight of the next days on a chart and at every forecast horizon
+func (wc *WeightController) Forecast(w http.ResponseWriter, r *http.Request) {
+	model := r.URL.Query().Get("model")
+	res := &Response{Form: url.Values{"model": {model}}}
+
+	forecast, err := wc.Service.Forecast(model, time.Now())
+	if errs, ok := err.(models.ValidationErrors); ok {
+		res.Errors = errs
+		wc.render(w, r, http.StatusBadRequest, "forecast.html", res)
+		return
+	}
+
+	if err == services.ErrNotEnoughHistory {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusOK, "forecast.html", res)
+		return
+	}
+
+	if err != nil {
+		wc.renderServiceError(w, r, err)
+		return
+	}
+
+	res.Forecast = forecast
+	res.Chart = NewForecastChart(forecast)
+	wc.render(w, r, http.StatusOK, "forecast.html", res)
+}
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	idempotencyHeader         = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+)
+
+// Idempotent wraps a create handler of the API so a request sent with an
+// Idempotency-Key header is only handled once. A retry with the same key
+// and the same request gets the stored response again, a retry with the
+// same key and another request is rejected. Server errors are not stored,
+// so the request could be retried with the same key
+func (ac *APIController) Idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimSpace(r.Header.Get(idempotencyHeader))
+		if key == "" {
+			next(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Idempotency key could not be longer than 255 characters"})
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeServiceError(w, &badRequestError{err})
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		stored, err := ac.Service.ReserveIdempotencyKey(key, requestHash(r, body), time.Now())
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+
+		if stored != nil {
+			if stored.Location != "" {
+				w.Header().Set("Location", stored.Location)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set(idempotencyReplayedHeader, "true")
+			w.WriteHeader(stored.Status)
+			w.Write([]byte(stored.Body))
+			return
+		}
+
+		// a panicking handler is answered with 500 by the recovery
+		// middleware, so the key is released for the retry like any
+		// other server error before panicking again
+		defer func() {
+			if p := recover(); p != nil {
+				if err := ac.Service.ReleaseIdempotencyKey(key); err != nil {
+					log.Printf("idempotency key error on %s %s: %s", r.Method, r.URL.Path, err)
+				}
+				panic(p)
+			}
+		}()
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+
+		if rec.status >= http.StatusInternalServerError {
+			err = ac.Service.ReleaseIdempotencyKey(key)
+		} else {
+			err = ac.Service.CompleteIdempotencyKey(key, rec.status, w.Header().Get("Location"), rec.body.String())
+		}
+
+		if err != nil {
+			log.Printf("idempotency key error on %s %s: %s", r.Method, r.URL.Path, err)
+		}
+	}
+}
+
+// requestHash identifies the request by its method, path and body
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder writes the response through
+// while keeping its status and body
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	rr.status = status
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.body.Write(b)
+
+	return rr.ResponseWriter.Write(b)
+}
@@ -0,0 +1,117 @@
+package controllers_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+)
+
+func (s *APISuite) serveIdempotentJSON(target, key, body string) *http.Response {
+	req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	require.NoError(s.T(), err)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", key)
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+
+	return rec.Result()
+}
+
+func (s *APISuite) Test_Create_With_Idempotency_Key_Store_Response() {
+	s.weight.ID = 0
+
+	s.repo.On("FindIdempotencyKey", "retry-1").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("SaveIdempotencyKey", mock.AnythingOfType("*models.IdempotencyKey")).Return(nil).Once()
+	s.repo.On("FindByDate", s.weight.Date).Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("Save", s.weight).Return(&models.Weight{ID: 7}, nil).Once()
+	s.repo.On("UpdateIdempotencyKey", mock.MatchedBy(func(ik *models.IdempotencyKey) bool {
+		return ik.Key == "retry-1" && ik.Status == http.StatusCreated && ik.Location == "/api/weights/7" &&
+			strings.Contains(ik.Body, `"id":7`)
+	})).Return(nil).Once()
+
+	res := s.serveIdempotentJSON("/api/weights", "retry-1", `{"date":"2020-11-09","max":50,"min":48}`)
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusCreated, res.StatusCode)
+}
+
+func (s *APISuite) Test_Create_With_Idempotency_Key_Replay_Response() {
+	body := `{"date":"2020-11-09","max":50,"min":48}`
+
+	s.repo.On("FindIdempotencyKey", "retry-1").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("SaveIdempotencyKey", mock.AnythingOfType("*models.IdempotencyKey")).
+		Run(func(args mock.Arguments) {
+			ik := args.Get(0).(*models.IdempotencyKey)
+			ik.Status = http.StatusCreated
+			ik.Location = "/api/weights/7"
+			ik.Body = `{"id":7}`
+			s.repo.On("FindIdempotencyKey", "retry-1").Return(ik, nil).Once()
+		}).
+		Return(nil).Once()
+	s.repo.On("FindByDate", "2020-11-09").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("Save", mock.Anything).Return(&models.Weight{ID: 7}, nil).Once()
+	s.repo.On("UpdateIdempotencyKey", mock.Anything).Return(nil).Once()
+
+	first := s.serveIdempotentJSON("/api/weights", "retry-1", body)
+	first.Body.Close()
+
+	res := s.serveIdempotentJSON("/api/weights", "retry-1", body)
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusCreated, res.StatusCode)
+	require.Equal(s.T(), "/api/weights/7", res.Header.Get("Location"))
+	require.Equal(s.T(), "true", res.Header.Get("Idempotent-Replayed"))
+
+	replayed, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), `{"id":7}`, string(replayed))
+}
+
+func (s *APISuite) Test_Create_With_Idempotency_Key_Reused_For_Another_Payload() {
+	s.repo.On("FindIdempotencyKey", "retry-1").Return(&models.IdempotencyKey{
+		Key:         "retry-1",
+		RequestHash: "another request",
+		Status:      http.StatusCreated,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}, nil).Once()
+
+	res := s.serveIdempotentJSON("/api/weights", "retry-1", `{"date":"2020-11-10","max":50,"min":48}`)
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusUnprocessableEntity, res.StatusCode)
+}
+
+func (s *APISuite) Test_Create_With_Idempotency_Key_Release_On_Server_Error() {
+	s.repo.On("FindIdempotencyKey", "retry-1").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("SaveIdempotencyKey", mock.AnythingOfType("*models.IdempotencyKey")).Return(nil).Once()
+	s.repo.On("FindByDate", "2020-11-09").Return(nil, gorm.ErrInvalidSQL).Once()
+	s.repo.On("DeleteIdempotencyKey", "retry-1").Return(nil).Once()
+
+	res := s.serveIdempotentJSON("/api/weights", "retry-1", `{"date":"2020-11-09","max":50,"min":48}`)
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusInternalServerError, res.StatusCode)
+}
+
+func (s *APISuite) Test_Create_With_Idempotency_Key_Release_On_Panic() {
+	s.repo.On("FindIdempotencyKey", "retry-1").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("SaveIdempotencyKey", mock.AnythingOfType("*models.IdempotencyKey")).Return(nil).Once()
+	s.repo.On("FindByDate", "2020-11-09").Run(func(mock.Arguments) { panic("boom") }).Return(nil, nil).Once()
+	s.repo.On("DeleteIdempotencyKey", "retry-1").Return(nil).Once()
+
+	require.PanicsWithValue(s.T(), "boom", func() {
+		s.serveIdempotentJSON("/api/weights", "retry-1", `{"date":"2020-11-09","max":50,"min":48}`)
+	})
+}
@@ -0,0 +1,364 @@
+package controllers
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+	"github.com/gorilla/mux"
+)
+
+// Measurements is the function for the measurements view, showing all
+// the measurements by date and all the measurement types
+func (wc *WeightController) Measurements(w http.ResponseWriter, r *http.Request) {
+	res := new(Response)
+
+	if err := wc.loadMeasurements(res); err != nil {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusInternalServerError, "measurements.html", res)
+		return
+	}
+
+	dates := make([]string, 0, len(res.Measurements))
+	for date := range res.Measurements {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	res.Data = dates
+	wc.render(w, r, http.StatusOK, "measurements.html", res)
+}
+
+// NewMeasurement is the function for showing new measurement form.
+// The date is pre-filled with the date query, or today when empty
+func (wc *WeightController) NewMeasurement(w http.ResponseWriter, r *http.Request) {
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		date = time.Now().Format(models.DateLayout)
+	}
+
+	res := &Response{Form: url.Values{"date": {date}, "type_id": {r.URL.Query().Get("type_id")}}}
+	wc.renderMeasurementForm(w, r, http.StatusOK, "measurement_new.html", res)
+}
+
+// InsertMeasurement is the function to actually insert the measurement
+// after the new measurement form is submitted
+func (wc *WeightController) InsertMeasurement(w http.ResponseWriter, r *http.Request) {
+	res := new(Response)
+
+	measurement, errs, err := bindMeasurement(r)
+	res.Form = r.PostForm
+	if err != nil {
+		res.Error = err.Error()
+		wc.renderMeasurementForm(w, r, http.StatusBadRequest, "measurement_new.html", res)
+		return
+	}
+
+	if len(errs) > 0 {
+		res.Errors = errs
+		wc.renderMeasurementForm(w, r, http.StatusUnprocessableEntity, "measurement_new.html", res)
+		return
+	}
+
+	_, err = wc.Service.CreateMeasurement(measurement)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		res.Errors = errs
+		wc.renderMeasurementForm(w, r, http.StatusBadRequest, "measurement_new.html", res)
+		return
+	}
+
+	if err == services.ErrDuplicateMeasurement {
+		res.Error = err.Error()
+		wc.renderMeasurementForm(w, r, http.StatusConflict, "measurement_new.html", res)
+		return
+	}
+
+	if err != nil {
+		res.Error = err.Error()
+		wc.renderMeasurementForm(w, r, http.StatusInternalServerError, "measurement_new.html", res)
+		return
+	}
+
+	setFlash(w, FlashSuccess, "Measurement saved")
+	http.Redirect(w, r, "/measurements", http.StatusSeeOther)
+}
+
+// EditMeasurement is function to show the edit measurement form
+// with the existing measurement data from database
+func (wc *WeightController) EditMeasurement(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		wc.renderError(w, r, http.StatusBadRequest, "Invalid measurement id")
+		return
+	}
+
+	measurement, err := wc.Service.GetMeasurement(id)
+	if err != nil {
+		wc.renderServiceError(w, r, err)
+		return
+	}
+
+	res := &Response{
+		Data: measurement,
+		Form: url.Values{
+			"type_id": {strconv.FormatUint(measurement.TypeID, 10)},
+			"date":    {measurement.Date},
+			"value":   {strconv.FormatFloat(measurement.Value, 'f', -1, 64)},
+		},
+	}
+	wc.renderMeasurementForm(w, r, http.StatusOK, "measurement_edit.html", res)
+}
+
+// UpdateMeasurement is the function to actually update the measurement
+// when edit measurement form is submitted
+func (wc *WeightController) UpdateMeasurement(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		wc.renderError(w, r, http.StatusBadRequest, "Invalid measurement id")
+		return
+	}
+
+	res := &Response{Data: &models.Measurement{ID: id}}
+
+	measurement, errs, err := bindMeasurement(r)
+	res.Form = r.PostForm
+	if err != nil {
+		res.Error = err.Error()
+		wc.renderMeasurementForm(w, r, http.StatusBadRequest, "measurement_edit.html", res)
+		return
+	}
+
+	if len(errs) > 0 {
+		res.Errors = errs
+		wc.renderMeasurementForm(w, r, http.StatusUnprocessableEntity, "measurement_edit.html", res)
+		return
+	}
+
+	_, err = wc.Service.UpdateMeasurement(id, measurement)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		res.Errors = errs
+		wc.renderMeasurementForm(w, r, http.StatusBadRequest, "measurement_edit.html", res)
+		return
+	}
+
+	if err == services.ErrMeasurementNotFound {
+		wc.renderServiceError(w, r, err)
+		return
+	}
+
+	if err == services.ErrDuplicateMeasurement {
+		res.Error = err.Error()
+		wc.renderMeasurementForm(w, r, http.StatusConflict, "measurement_edit.html", res)
+		return
+	}
+
+	if err != nil {
+		res.Error = err.Error()
+		wc.renderMeasurementForm(w, r, http.StatusInternalServerError, "measurement_edit.html", res)
+		return
+	}
+
+	setFlash(w, FlashSuccess, "Measurement saved")
+	http.Redirect(w, r, "/measurements", http.StatusSeeOther)
+}
+
+// DeleteMeasurement is the function to delete the measurement
+// when the delete button in edit measurement page is submitted
+func (wc *WeightController) DeleteMeasurement(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		wc.renderError(w, r, http.StatusBadRequest, "Invalid measurement id")
+		return
+	}
+
+	err = wc.Service.DeleteMeasurement(id)
+	if err == services.ErrMeasurementNotFound {
+		setFlash(w, FlashWarning, "Measurement was already deleted")
+		http.Redirect(w, r, "/measurements", http.StatusSeeOther)
+		return
+	}
+
+	if err != nil {
+		wc.renderServiceError(w, r, err)
+		return
+	}
+
+	setFlash(w, FlashSuccess, "Measurement deleted")
+	http.Redirect(w, r, "/measurements", http.StatusSeeOther)
+}
+
+// NewMeasurementType is the function for showing new measurement type form
+func (wc *WeightController) NewMeasurementType(w http.ResponseWriter, r *http.Request) {
+	wc.render(w, r, http.StatusOK, "measurement_type_new.html", nil)
+}
+
+// InsertMeasurementType is the function to actually insert the
+// measurement type after the new measurement type form is submitted
+func (wc *WeightController) InsertMeasurementType(w http.ResponseWriter, r *http.Request) {
+	res := new(Response)
+
+	mt, errs, err := bindMeasurementType(r)
+	res.Form = r.PostForm
+	if err != nil {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusBadRequest, "measurement_type_new.html", res)
+		return
+	}
+
+	if len(errs) > 0 {
+		res.Errors = errs
+		wc.render(w, r, http.StatusUnprocessableEntity, "measurement_type_new.html", res)
+		return
+	}
+
+	_, err = wc.Service.CreateMeasurementType(mt)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		res.Errors = errs
+		wc.render(w, r, http.StatusBadRequest, "measurement_type_new.html", res)
+		return
+	}
+
+	if err != nil {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusInternalServerError, "measurement_type_new.html", res)
+		return
+	}
+
+	setFlash(w, FlashSuccess, "Measurement type saved")
+	http.Redirect(w, r, "/measurements", http.StatusSeeOther)
+}
+
+// EditMeasurementType is function to show the edit measurement type form
+func (wc *WeightController) EditMeasurementType(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		wc.renderError(w, r, http.StatusBadRequest, "Invalid measurement type id")
+		return
+	}
+
+	mt, err := wc.Service.GetMeasurementType(id)
+	if err != nil {
+		wc.renderServiceError(w, r, err)
+		return
+	}
+
+	res := &Response{
+		Data: mt,
+		Form: url.Values{
+			"name":      {mt.Name},
+			"unit":      {mt.Unit},
+			"min_value": {strconv.FormatFloat(mt.MinValue, 'f', -1, 64)},
+			"max_value": {strconv.FormatFloat(mt.MaxValue, 'f', -1, 64)},
+		},
+	}
+	wc.render(w, r, http.StatusOK, "measurement_type_edit.html", res)
+}
+
+// UpdateMeasurementType is the function to actually update the
+// measurement type when edit measurement type form is submitted
+func (wc *WeightController) UpdateMeasurementType(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		wc.renderError(w, r, http.StatusBadRequest, "Invalid measurement type id")
+		return
+	}
+
+	res := &Response{Data: &models.MeasurementType{ID: id}}
+
+	mt, errs, err := bindMeasurementType(r)
+	res.Form = r.PostForm
+	if err != nil {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusBadRequest, "measurement_type_edit.html", res)
+		return
+	}
+
+	if len(errs) > 0 {
+		res.Errors = errs
+		wc.render(w, r, http.StatusUnprocessableEntity, "measurement_type_edit.html", res)
+		return
+	}
+
+	_, err = wc.Service.UpdateMeasurementType(id, mt)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		res.Errors = errs
+		wc.render(w, r, http.StatusBadRequest, "measurement_type_edit.html", res)
+		return
+	}
+
+	if err == services.ErrMeasurementTypeNotFound {
+		wc.renderServiceError(w, r, err)
+		return
+	}
+
+	if err != nil {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusInternalServerError, "measurement_type_edit.html", res)
+		return
+	}
+
+	setFlash(w, FlashSuccess, "Measurement type saved")
+	http.Redirect(w, r, "/measurements", http.StatusSeeOther)
+}
+
+// DeleteMeasurementType is the function to delete the measurement type
+// and all of its measurements
+func (wc *WeightController) DeleteMeasurementType(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		wc.renderError(w, r, http.StatusBadRequest, "Invalid measurement type id")
+		return
+	}
+
+	err = wc.Service.DeleteMeasurementType(id)
+	if err == services.ErrMeasurementTypeNotFound {
+		setFlash(w, FlashWarning, "Measurement type was already deleted")
+		http.Redirect(w, r, "/measurements", http.StatusSeeOther)
+		return
+	}
+
+	if err != nil {
+		wc.renderServiceError(w, r, err)
+		return
+	}
+
+	setFlash(w, FlashSuccess, "Measurement type deleted")
+	http.Redirect(w, r, "/measurements", http.StatusSeeOther)
+}
+
+// loadMeasurements fills the measurement types, the measurements grouped
+// by date and their summary into the response
+func (wc *WeightController) loadMeasurements(res *Response) error {
+	types, err := wc.Service.MeasurementTypes()
+	if err != nil {
+		return err
+	}
+
+	measurements, err := wc.Service.Measurements()
+	if err != nil {
+		return err
+	}
+
+	res.Types = types
+	res.Measurements = services.GroupMeasurements(*measurements)
+	res.MeasurementStats = services.SummarizeMeasurements(*types, *measurements)
+
+	return nil
+}
+
+// renderMeasurementForm renders a measurement form with the measurement
+// types to choose from
+func (wc *WeightController) renderMeasurementForm(w http.ResponseWriter, r *http.Request, status int, name string, res *Response) {
+	types, err := wc.Service.MeasurementTypes()
+	if err != nil {
+		wc.renderServiceError(w, r, err)
+		return
+	}
+
+	res.Types = types
+	wc.render(w, r, status, name, res)
+}
@@ -0,0 +1,158 @@
+package controllers_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+)
+
+func (s *Suite) bodyFat() *models.MeasurementType {
+	return &models.MeasurementType{ID: 1, Name: "Body Fat", Unit: "%", MinValue: 2, MaxValue: 75}
+}
+
+func (s *Suite) Test_Index_Show_Measurement_Columns() {
+	types := []models.MeasurementType{*s.bodyFat()}
+	measurements := []models.Measurement{{ID: 3, TypeID: 1, Date: s.weight.Date, Value: 21.4}}
+
+	s.repo.On("FindAll").Return(&[]models.Weight{*s.weight}, nil).Once()
+	s.repo.On("FindWeightTagNames").Return(map[uint64][]string{}, nil).Once()
+	s.repo.On("FindAllTags").Return(&[]models.Tag{}, nil).Once()
+	s.repo.On("FindAllMeasurementTypes").Return(&types, nil).Once()
+	s.repo.On("FindAllMeasurements").Return(&measurements, nil).Once()
+	s.repo.On("FindProfile").Return(nil, gorm.ErrRecordNotFound).Once()
+
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(s.T(), err)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusOK, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), string(body), "Body Fat (%)")
+	require.Contains(s.T(), string(body), "<td>21.4</td>")
+	require.Contains(s.T(), string(body), "21.40")
+}
+
+func (s *Suite) Test_Measurements_Link_Missing_Values_To_New_Form() {
+	types := []models.MeasurementType{*s.bodyFat(), {ID: 2, Name: "Waist", Unit: "cm", MinValue: 40, MaxValue: 200}}
+	measurements := []models.Measurement{{ID: 3, TypeID: 1, Date: "2020-11-09", Value: 21.4}}
+
+	s.repo.On("FindAllMeasurementTypes").Return(&types, nil).Once()
+	s.repo.On("FindAllMeasurements").Return(&measurements, nil).Once()
+
+	req, err := http.NewRequest(http.MethodGet, "/measurements", nil)
+	require.NoError(s.T(), err)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusOK, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), string(body), "/measurement/3/edit")
+	require.Contains(s.T(), string(body), "/measurement/new?date=2020-11-09&type_id=2")
+}
+
+func (s *Suite) Test_InsertMeasurement_Success() {
+	measurement := &models.Measurement{TypeID: 1, Date: "2020-11-09", Value: 21.4}
+
+	s.repo.On("FindMeasurementTypeByID", uint64(1)).Return(s.bodyFat(), nil).Once()
+	s.repo.On("FindMeasurementByTypeAndDate", uint64(1), "2020-11-09").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("SaveMeasurement", measurement).Return(&models.Measurement{ID: 3}, nil).Once()
+
+	v := url.Values{}
+	v.Set("type_id", "1")
+	v.Set("date", "2020-11-09")
+	v.Set("value", "21.4")
+
+	req := s.newFormRequest("/measurement/insert", v)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+
+	require.Equal(s.T(), http.StatusSeeOther, res.StatusCode)
+	require.Equal(s.T(), "/measurements", res.Header.Get("Location"))
+}
+
+func (s *Suite) Test_InsertMeasurement_When_Value_Out_Of_Range() {
+	types := []models.MeasurementType{*s.bodyFat()}
+
+	s.repo.On("FindMeasurementTypeByID", uint64(1)).Return(s.bodyFat(), nil).Once()
+	s.repo.On("FindAllMeasurementTypes").Return(&types, nil).Once()
+
+	v := url.Values{}
+	v.Set("type_id", "1")
+	v.Set("date", "2020-11-09")
+	v.Set("value", "90")
+
+	req := s.newFormRequest("/measurement/insert", v)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusBadRequest, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), string(body), "Body Fat must be between 2 and 75 %")
+	require.Contains(s.T(), string(body), "<option value=\"1\" selected>")
+}
+
+func (s *Suite) Test_InsertMeasurementType_When_Range_Is_Invalid() {
+	v := url.Values{}
+	v.Set("name", "Visceral Fat")
+	v.Set("unit", "level")
+	v.Set("min_value", "30")
+	v.Set("max_value", "1")
+
+	req := s.newFormRequest("/measurement-type/insert", v)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusBadRequest, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), string(body), "Max value must be greater than min value")
+	require.Contains(s.T(), string(body), "value=\"Visceral Fat\"")
+}
+
+func (s *Suite) Test_EditMeasurement_When_Measurement_Not_Exist() {
+	s.repo.On("FindMeasurementByID", uint64(3)).Return(&models.Measurement{}, gorm.ErrRecordNotFound).Once()
+
+	req, err := http.NewRequest(http.MethodGet, "/measurement/3/edit", nil)
+	require.NoError(s.T(), err)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusNotFound, res.StatusCode)
+}
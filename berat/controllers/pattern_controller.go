@@ -0,0 +1,21 @@
+package controllers
+
+import (
+	"net/http"
+)
+
+// Patterns is the function for the pattern view, showing the averages of
+// every day of the week and month of the year against the overall trend
+func (wc *WeightController) Patterns(w http.ResponseWriter, r *http.Request) {
+	res := new(Response)
+
+	patterns, err := wc.Service.Patterns()
+	if err != nil {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusInternalServerError, "patterns.html", res)
+		return
+	}
+
+	res.Patterns = patterns
+	wc.render(w, r, http.StatusOK, "patterns.html", res)
+}
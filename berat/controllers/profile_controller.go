@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+// EditProfile is the function to show the profile form,
+// filled with the existing profile when there is one
+func (wc *WeightController) EditProfile(w http.ResponseWriter, r *http.Request) {
+	res := new(Response)
+
+	profile, err := wc.Service.Profile()
+	if err != nil && err != services.ErrProfileNotFound {
+		wc.renderServiceError(w, r, err)
+		return
+	}
+
+	if profile != nil {
+		res.Form = url.Values{
+			"height":     {strconv.FormatFloat(profile.Height, 'f', -1, 64)},
+			"birth_date": {profile.BirthDate},
+			"sex":        {profile.Sex},
+		}
+	}
+
+	wc.render(w, r, http.StatusOK, "profile.html", res)
+}
+
+// UpdateProfile is the function to save the profile
+// when the profile form is submitted
+func (wc *WeightController) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	res := new(Response)
+
+	profile, errs, err := bindProfile(r)
+	res.Form = r.PostForm
+	if err != nil {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusBadRequest, "profile.html", res)
+		return
+	}
+
+	if len(errs) > 0 {
+		res.Errors = errs
+		wc.render(w, r, http.StatusUnprocessableEntity, "profile.html", res)
+		return
+	}
+
+	_, err = wc.Service.SaveProfile(profile)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		res.Errors = errs
+		wc.render(w, r, http.StatusBadRequest, "profile.html", res)
+		return
+	}
+
+	if err != nil {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusInternalServerError, "profile.html", res)
+		return
+	}
+
+	setFlash(w, FlashSuccess, "Profile saved")
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// loadBMI fills the profile and the BMI of the weights into the response.
+// Nothing is filled when the profile is not filled yet
+func (wc *WeightController) loadBMI(res *Response, weights []models.Weight) error {
+	profile, err := wc.Service.Profile()
+	if err == services.ErrProfileNotFound {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	res.Profile = profile
+	res.BMI = services.CalculateBMIs(profile, weights)
+	res.AverageBMI = services.SummarizeBMI(profile, weights)
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package controllers_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+)
+
+func (s *Suite) Test_Index_Show_BMI_When_Profile_Filled() {
+	profile := &models.Profile{ID: 1, Height: 160, BirthDate: "1990-03-15", Sex: models.SexMale}
+	weight := models.Weight{ID: 1, Date: "2020-11-09", Max: 66, Min: 64, Difference: 2}
+
+	s.repo.On("FindAll").Return(&[]models.Weight{weight}, nil).Once()
+	s.repo.On("FindWeightTagNames").Return(map[uint64][]string{}, nil).Once()
+	s.repo.On("FindAllTags").Return(&[]models.Tag{}, nil).Once()
+	s.repo.On("FindAllMeasurementTypes").Return(&[]models.MeasurementType{}, nil).Once()
+	s.repo.On("FindAllMeasurements").Return(&[]models.Measurement{}, nil).Once()
+	s.repo.On("FindProfile").Return(profile, nil).Once()
+
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(s.T(), err)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusOK, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), string(body), "<td>25.4</td>")
+	require.Contains(s.T(), string(body), "<td>Pre-obesity</td>")
+}
+
+func (s *Suite) Test_EditProfile_When_Not_Filled() {
+	s.repo.On("FindProfile").Return(nil, gorm.ErrRecordNotFound).Once()
+
+	req, err := http.NewRequest(http.MethodGet, "/profile", nil)
+	require.NoError(s.T(), err)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+
+	require.Equal(s.T(), http.StatusOK, res.StatusCode)
+}
+
+func (s *Suite) Test_UpdateProfile_Success() {
+	s.repo.On("FindProfile").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("SaveProfile", &models.Profile{Height: 170.5, BirthDate: "1990-03-15", Sex: models.SexFemale}).
+		Return(&models.Profile{ID: 1}, nil).Once()
+
+	v := url.Values{}
+	v.Set("height", "170.5")
+	v.Set("birth_date", "1990-03-15")
+	v.Set("sex", "female")
+
+	req := s.newFormRequest("/profile/update", v)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+
+	require.Equal(s.T(), http.StatusSeeOther, res.StatusCode)
+	require.Equal(s.T(), "/", res.Header.Get("Location"))
+}
+
+func (s *Suite) Test_UpdateProfile_When_Fail_To_Parse_Form_Data() {
+	v := url.Values{}
+	v.Set("height", "tall")
+	v.Set("birth_date", "1990-03-15")
+	v.Set("sex", "")
+
+	req := s.newFormRequest("/profile/update", v)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusUnprocessableEntity, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), string(body), "Please fill the height correctly")
+	require.Contains(s.T(), string(body), "Please choose male or female")
+}
@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+	"github.com/gorilla/mux"
+)
+
+// NewReading is the function for showing new reading form in html template.
+// The reading time is pre-filled with the date query, or now when empty
+func (wc *WeightController) NewReading(w http.ResponseWriter, r *http.Request) {
+	takenAt := time.Now().Format(ReadingTimeLayout)
+	if date, err := time.Parse(models.DateLayout, r.URL.Query().Get("date")); err == nil {
+		takenAt = date.Format(models.DateLayout) + "T07:00"
+	}
+
+	res := &Response{Form: url.Values{"taken_at": {takenAt}}}
+	wc.render(w, r, http.StatusOK, "reading_new.html", res)
+}
+
+// InsertReading is the function to actually insert the reading
+// after the new reading form is submitted
+func (wc *WeightController) InsertReading(w http.ResponseWriter, r *http.Request) {
+	res := new(Response)
+
+	reading, errs, err := bindReading(r)
+	res.Form = r.PostForm
+	if err != nil {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusBadRequest, "reading_new.html", res)
+		return
+	}
+
+	if len(errs) > 0 {
+		res.Errors = errs
+		wc.render(w, r, http.StatusUnprocessableEntity, "reading_new.html", res)
+		return
+	}
+
+	newReading, err := wc.Service.AddReading(reading)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		res.Errors = errs
+		wc.render(w, r, http.StatusBadRequest, "reading_new.html", res)
+		return
+	}
+
+	if rules, ok := err.(services.RuleErrors); ok {
+		res.Rules = rules
+		wc.render(w, r, http.StatusUnprocessableEntity, "reading_new.html", res)
+		return
+	}
+
+	if err == services.ErrDayEnteredByHand {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusConflict, "reading_new.html", res)
+		return
+	}
+
+	if err != nil {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusInternalServerError, "reading_new.html", res)
+		return
+	}
+
+	url := fmt.Sprintf("/weight/%d", newReading.WeightID)
+
+	setFlash(w, FlashSuccess, "Reading saved")
+	http.Redirect(w, r, url, http.StatusSeeOther)
+}
+
+// EditReading is function to show the edit reading form
+// with the existing reading data from database
+func (wc *WeightController) EditReading(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		wc.renderError(w, r, http.StatusBadRequest, "Invalid reading id")
+		return
+	}
+
+	reading, err := wc.Service.GetReading(id)
+	if err != nil {
+		wc.renderServiceError(w, r, err)
+		return
+	}
+
+	res := &Response{
+		Data: reading,
+		Form: url.Values{
+			"taken_at": {reading.TakenAt.Local().Format(ReadingTimeLayout)},
+			"value":    {strconv.Itoa(reading.Value)},
+		},
+	}
+	wc.render(w, r, http.StatusOK, "reading_edit.html", res)
+}
+
+// UpdateReading is the function to actually update the reading
+// when edit reading form is submitted
+func (wc *WeightController) UpdateReading(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		wc.renderError(w, r, http.StatusBadRequest, "Invalid reading id")
+		return
+	}
+
+	res := &Response{Data: &models.Reading{ID: id}}
+
+	reading, errs, err := bindReading(r)
+	res.Form = r.PostForm
+	if err != nil {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusBadRequest, "reading_edit.html", res)
+		return
+	}
+
+	if len(errs) > 0 {
+		res.Errors = errs
+		wc.render(w, r, http.StatusUnprocessableEntity, "reading_edit.html", res)
+		return
+	}
+
+	newReading, err := wc.Service.UpdateReading(id, reading)
+	if errs, ok := err.(models.ValidationErrors); ok {
+		res.Errors = errs
+		wc.render(w, r, http.StatusBadRequest, "reading_edit.html", res)
+		return
+	}
+
+	if rules, ok := err.(services.RuleErrors); ok {
+		res.Rules = rules
+		wc.render(w, r, http.StatusUnprocessableEntity, "reading_edit.html", res)
+		return
+	}
+
+	if err == services.ErrDayEnteredByHand {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusConflict, "reading_edit.html", res)
+		return
+	}
+
+	if err == services.ErrReadingNotFound {
+		wc.renderServiceError(w, r, err)
+		return
+	}
+
+	if err != nil {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusInternalServerError, "reading_edit.html", res)
+		return
+	}
+
+	url := fmt.Sprintf("/weight/%d", newReading.WeightID)
+
+	setFlash(w, FlashSuccess, "Reading saved")
+	http.Redirect(w, r, url, http.StatusSeeOther)
+}
+
+// DeleteReading is the function to delete the reading when the delete
+// button in detail page is submitted. It goes back to the detail page,
+// or to the index when the deleted reading was the last of its day
+func (wc *WeightController) DeleteReading(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		wc.renderError(w, r, http.StatusBadRequest, "Invalid reading id")
+		return
+	}
+
+	weightID, err := wc.Service.DeleteReading(id)
+	if err == services.ErrReadingNotFound {
+		setFlash(w, FlashWarning, "Reading was already deleted")
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	if err != nil {
+		wc.renderServiceError(w, r, err)
+		return
+	}
+
+	url := fmt.Sprintf("/weight/%d", weightID)
+	if _, err := wc.Service.Get(weightID); err == services.ErrNotFound {
+		url = "/"
+	}
+
+	setFlash(w, FlashSuccess, "Reading deleted")
+	http.Redirect(w, r, url, http.StatusSeeOther)
+}
@@ -0,0 +1,153 @@
+package controllers_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+func (s *Suite) Test_NewReading_Prefill_Date() {
+	req, err := http.NewRequest(http.MethodGet, "/reading/new?date=2020-11-09", nil)
+	require.NoError(s.T(), err)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusOK, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), string(body), "value=\"2020-11-09T07:00\"")
+}
+
+func (s *Suite) Test_InsertReading_Create_Day_And_Derive_Weight() {
+	takenAt := time.Date(2020, 11, 9, 7, 30, 0, 0, time.Local)
+	reading := &models.Reading{WeightID: 1, TakenAt: takenAt, Value: 49}
+	readings := []models.Reading{{ID: 3, WeightID: 1, TakenAt: takenAt, Value: 49}}
+
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("FindByDate", "2020-11-09").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("Save", &models.Weight{Date: "2020-11-09", Max: 49, Min: 49}).
+		Return(&models.Weight{ID: 1, Date: "2020-11-09", Max: 49, Min: 49}, nil).Once()
+	s.repo.On("SaveReading", reading).Return(&models.Reading{ID: 3, WeightID: 1, TakenAt: takenAt, Value: 49}, nil).Once()
+	s.repo.On("FindReadingsByWeightID", uint64(1)).Return(&readings, nil).Once()
+	s.repo.On("FindByID", uint64(1)).Return(&models.Weight{ID: 1, Date: "2020-11-09", Max: 49, Min: 49}, nil).Once()
+	s.repo.On("Update", uint64(1), &models.Weight{ID: 1, Date: "2020-11-09", Max: 49, Min: 49}).
+		Return(&models.Weight{ID: 1}, nil).Once()
+
+	v := url.Values{}
+	v.Set("taken_at", "2020-11-09T07:30")
+	v.Set("value", "49")
+
+	req := s.newFormRequest("/reading/insert", v)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+
+	require.Equal(s.T(), http.StatusSeeOther, res.StatusCode)
+	require.Equal(s.T(), "/weight/1", res.Header.Get("Location"))
+}
+
+func (s *Suite) Test_InsertReading_When_Day_Was_Entered_By_Hand() {
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("FindByDate", "2020-11-09").Return(&models.Weight{ID: 1, Date: "2020-11-09", Max: 80, Min: 78}, nil).Once()
+	s.repo.On("FindReadingsByWeightID", uint64(1)).Return(&[]models.Reading{}, nil).Once()
+
+	v := url.Values{}
+	v.Set("taken_at", "2020-11-09T07:30")
+	v.Set("value", "49")
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, s.newFormRequest("/reading/insert", v))
+
+	require.Equal(s.T(), http.StatusConflict, rec.Code)
+	require.Contains(s.T(), rec.Body.String(), services.ErrDayEnteredByHand.Error())
+}
+
+func (s *Suite) Test_InsertReading_When_Day_Fails_Rules() {
+	s.service.Rules = services.Rules{MaxWeight: 300}
+	defer func() { s.service.Rules = services.Rules{} }()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("FindByDate", "2020-11-09").Return(nil, gorm.ErrRecordNotFound).Once()
+
+	v := url.Values{}
+	v.Set("taken_at", "2020-11-09T07:30")
+	v.Set("value", "310")
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, s.newFormRequest("/reading/insert", v))
+
+	require.Equal(s.T(), http.StatusUnprocessableEntity, rec.Code)
+	require.Contains(s.T(), rec.Body.String(), "Max weight could not be more than 300")
+}
+
+func (s *Suite) Test_InsertReading_When_Fail_To_Parse_Form_Data() {
+	v := url.Values{}
+	v.Set("taken_at", "yesterday")
+	v.Set("value", "49")
+
+	req := s.newFormRequest("/reading/insert", v)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusUnprocessableEntity, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), string(body), "Please fill the reading time correctly")
+	require.Contains(s.T(), string(body), "value=\"yesterday\"")
+}
+
+func (s *Suite) Test_EditReading_When_Reading_Not_Exist() {
+	s.repo.On("FindReadingByID", uint64(3)).Return(&models.Reading{}, gorm.ErrRecordNotFound).Once()
+
+	req, err := http.NewRequest(http.MethodGet, "/reading/3/edit", nil)
+	require.NoError(s.T(), err)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusNotFound, res.StatusCode)
+}
+
+func (s *Suite) Test_DeleteReading_When_Last_Reading_Of_The_Day() {
+	s.repo.On("FindReadingByID", uint64(3)).Return(&models.Reading{ID: 3, WeightID: 1}, nil).Once()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("DeleteReading", uint64(3)).Return(nil).Once()
+	s.repo.On("FindReadingsByWeightID", uint64(1)).Return(&[]models.Reading{}, nil).Once()
+	s.repo.On("Delete", uint64(1)).Return(nil).Once()
+	s.repo.On("FindByID", uint64(1)).Return(&models.Weight{}, gorm.ErrRecordNotFound).Once()
+
+	req := s.newFormRequest("/reading/3/delete", url.Values{})
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+
+	require.Equal(s.T(), http.StatusSeeOther, res.StatusCode)
+	require.Equal(s.T(), "/", res.Header.Get("Location"))
+	s.repo.AssertCalled(s.T(), "Delete", uint64(1))
+	s.repo.AssertNotCalled(s.T(), "Purge", mock.Anything)
+}
@@ -0,0 +1,18 @@
+package controllers
+
+import (
+	"html/template"
+
+	"github.com/erizkiatama/berat/services"
+	"github.com/gorilla/mux"
+)
+
+// NewRouter creates the router serving both the HTML pages
+// and the JSON API of the weight service
+func NewRouter(ws *services.WeightService, tmpl *template.Template) *mux.Router {
+	router := mux.NewRouter()
+	NewWeightController(ws, tmpl, router)
+	NewAPIController(ws, router)
+
+	return router
+}
@@ -0,0 +1,69 @@
+package controllers_test
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/models/mocks"
+	"github.com/erizkiatama/berat/services"
+
+	"github.com/erizkiatama/berat/controllers"
+)
+
+func newProductionRouter(t *testing.T) (*mocks.WeightRepository, http.Handler) {
+	repo := new(mocks.WeightRepository)
+	t.Cleanup(func() { repo.AssertExpectations(t) })
+
+	template := template.Must(template.ParseGlob("../views/*.html"))
+
+	return repo, controllers.NewRouter(services.NewWeightService(repo), template)
+}
+
+func serve(router http.Handler, req *http.Request) *http.Response {
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	return rec.Result()
+}
+
+func TestRouter_API_Without_Body_Is_Not_Checked_For_CSRF(t *testing.T) {
+	repo, router := newProductionRouter(t)
+	repo.On("FindByID", uint64(1)).Return(&models.Weight{ID: 1, Date: "2020-11-09", Max: 50, Min: 48}, nil).Once()
+	repo.On("Delete", uint64(1)).Return(nil).Once()
+	repo.On("FindDeletedByID", uint64(2)).Return(&models.Weight{}, gorm.ErrRecordNotFound).Once()
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/weights/1", nil)
+	res := serve(router, req)
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/trash/2/restore", nil)
+	res = serve(router, req)
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+	require.Equal(t, "application/json", res.Header.Get("Content-Type"))
+}
+
+func TestRouter_API_Refuses_Cross_Origin_Browser_Requests(t *testing.T) {
+	_, router := newProductionRouter(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/trash/2/restore", nil)
+	req.Header.Set("Origin", "http://evil.example")
+	res := serve(router, req)
+
+	require.Equal(t, http.StatusForbidden, res.StatusCode)
+	require.Equal(t, "application/json", res.Header.Get("Content-Type"))
+}
+
+func TestRouter_Pages_Still_Need_The_CSRF_Token(t *testing.T) {
+	_, router := newProductionRouter(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/weight/1/delete", nil)
+	res := serve(router, req)
+
+	require.Equal(t, http.StatusForbidden, res.StatusCode)
+}
@@ -0,0 +1,21 @@
+package controllers
+
+import (
+	"net/http"
+)
+
+// TagReport is the function for the tag report view, comparing the
+// average Difference of the days with and without each tag
+func (wc *WeightController) TagReport(w http.ResponseWriter, r *http.Request) {
+	res := new(Response)
+
+	report, err := wc.Service.TagReport()
+	if err != nil {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusInternalServerError, "tags.html", res)
+		return
+	}
+
+	res.TagReport = report
+	wc.render(w, r, http.StatusOK, "tags.html", res)
+}
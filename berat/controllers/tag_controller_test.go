@@ -0,0 +1,70 @@
+package controllers_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+)
+
+func (s *Suite) Test_Index_Filter_By_Tag() {
+	weights := []models.Weight{
+		{ID: 1, Date: "2020-11-09", Max: 52, Min: 48, Difference: 4},
+		{ID: 2, Date: "2020-11-10", Max: 50, Min: 49, Difference: 1},
+	}
+
+	s.repo.On("FindAll").Return(&weights, nil).Once()
+	s.repo.On("FindWeightTagNames").Return(map[uint64][]string{1: {"ate out"}}, nil).Once()
+	s.repo.On("FindAllTags").Return(&[]models.Tag{{ID: 1, Name: "ate out"}}, nil).Once()
+	s.repo.On("FindAllMeasurementTypes").Return(&[]models.MeasurementType{}, nil).Once()
+	s.repo.On("FindAllMeasurements").Return(&[]models.Measurement{}, nil).Once()
+	s.repo.On("FindProfile").Return(nil, gorm.ErrRecordNotFound).Once()
+
+	req, err := http.NewRequest(http.MethodGet, "/?tag=ate+out", nil)
+	require.NoError(s.T(), err)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusOK, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), string(body), "2020-11-09")
+	require.NotContains(s.T(), string(body), "2020-11-10")
+	require.Contains(s.T(), string(body), "<b>ate out</b>")
+	require.Contains(s.T(), string(body), "<th>4.00</th>")
+}
+
+func (s *Suite) Test_TagReport_Show_Difference_Delta() {
+	weights := []models.Weight{
+		{ID: 1, Date: "2020-11-09", Max: 52, Min: 48, Difference: 4},
+		{ID: 2, Date: "2020-11-10", Max: 50, Min: 49, Difference: 1},
+	}
+
+	s.repo.On("FindAll").Return(&weights, nil).Once()
+	s.repo.On("FindWeightTagNames").Return(map[uint64][]string{1: {"ate out"}}, nil).Once()
+	s.repo.On("FindAllTags").Return(&[]models.Tag{{ID: 1, Name: "ate out"}}, nil).Once()
+
+	req, err := http.NewRequest(http.MethodGet, "/tags", nil)
+	require.NoError(s.T(), err)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusOK, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), string(body), "<td>&#43;3.00</td>")
+}
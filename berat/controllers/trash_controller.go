@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/erizkiatama/berat/services"
+	"github.com/gorilla/mux"
+)
+
+// Trash is the function for the trash view, showing all the deleted
+// weight data that could still be restored
+func (wc *WeightController) Trash(w http.ResponseWriter, r *http.Request) {
+	res := &Response{PurgeAfterDays: wc.Service.PurgeAfterDays}
+
+	weights, err := wc.Service.Trash()
+	if err != nil {
+		res.Error = err.Error()
+		wc.render(w, r, http.StatusInternalServerError, "trash.html", res)
+		return
+	}
+
+	res.Data = weights
+	wc.render(w, r, http.StatusOK, "trash.html", res)
+}
+
+// RestoreTrash is the function to take the weight data out of the trash
+// when the restore button in trash page is submitted
+func (wc *WeightController) RestoreTrash(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		wc.renderError(w, r, http.StatusBadRequest, "Invalid weight id")
+		return
+	}
+
+	weight, err := wc.Service.Restore(id)
+	if err == services.ErrNotFound {
+		setFlash(w, FlashWarning, "Entry is no longer in the trash")
+		http.Redirect(w, r, "/trash", http.StatusSeeOther)
+		return
+	}
+
+	if err == services.ErrDuplicateDate {
+		setFlash(w, FlashWarning, "Another entry already exists on that date, delete it first to restore this one")
+		http.Redirect(w, r, "/trash", http.StatusSeeOther)
+		return
+	}
+
+	if err != nil {
+		wc.renderServiceError(w, r, err)
+		return
+	}
+
+	setFlash(w, FlashSuccess, "Entry restored")
+	http.Redirect(w, r, fmt.Sprintf("/weight/%d", weight.ID), http.StatusSeeOther)
+}
+
+// PurgeTrash is the function to permanently delete the weight data
+// when the delete forever button in trash page is submitted
+func (wc *WeightController) PurgeTrash(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		wc.renderError(w, r, http.StatusBadRequest, "Invalid weight id")
+		return
+	}
+
+	err = wc.Service.Purge(id)
+	if err == services.ErrNotFound {
+		setFlash(w, FlashWarning, "Entry is no longer in the trash")
+		http.Redirect(w, r, "/trash", http.StatusSeeOther)
+		return
+	}
+
+	if err != nil {
+		wc.renderServiceError(w, r, err)
+		return
+	}
+
+	setFlash(w, FlashSuccess, "Entry deleted forever")
+	http.Redirect(w, r, "/trash", http.StatusSeeOther)
+}
@@ -0,0 +1,84 @@
+package controllers_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+)
+
+func (s *Suite) Test_Trash_Show_Deleted_Weights() {
+	deletedAt := time.Date(2020, 11, 12, 8, 30, 0, 0, time.UTC)
+	weights := []models.Weight{{ID: 1, Date: "2020-11-09", Max: 50, Min: 48, Difference: 2, DeletedAt: &deletedAt}}
+
+	s.repo.On("FindDeleted").Return(&weights, nil).Once()
+
+	req, err := http.NewRequest(http.MethodGet, "/trash", nil)
+	require.NoError(s.T(), err)
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(s.T(), http.StatusOK, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(s.T(), err)
+	require.Contains(s.T(), string(body), "2020-11-12 08:30")
+	require.Contains(s.T(), string(body), `action="/trash/1/restore"`)
+}
+
+func (s *Suite) Test_RestoreTrash_Redirect_To_Detail() {
+	s.repo.On("FindDeletedByID", uint64(1)).Return(&models.Weight{ID: 1, Date: "2020-11-09"}, nil).Once()
+	s.repo.On("FindByDate", "2020-11-09").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("Restore", uint64(1)).Return(nil).Once()
+
+	req := s.newFormRequest("/trash/1/restore", url.Values{})
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+
+	require.Equal(s.T(), http.StatusSeeOther, res.StatusCode)
+	require.Equal(s.T(), "/weight/1", res.Header.Get("Location"))
+}
+
+func (s *Suite) Test_RestoreTrash_When_Date_Taken_By_Another_Weight() {
+	s.repo.On("FindDeletedByID", uint64(1)).Return(&models.Weight{ID: 1, Date: "2020-11-09"}, nil).Once()
+	s.repo.On("FindByDate", "2020-11-09").Return(&models.Weight{ID: 2, Date: "2020-11-09"}, nil).Once()
+
+	req := s.newFormRequest("/trash/1/restore", url.Values{})
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+
+	require.Equal(s.T(), http.StatusSeeOther, res.StatusCode)
+	require.Equal(s.T(), "/trash", res.Header.Get("Location"))
+	require.Equal(s.T(), "flash", res.Cookies()[0].Name)
+}
+
+func (s *Suite) Test_PurgeTrash_Delete_Forever() {
+	s.repo.On("FindDeletedByID", uint64(1)).Return(&models.Weight{ID: 1, Date: "2020-11-09"}, nil).Once()
+	s.repo.On("Purge", uint64(1)).Return(nil).Once()
+
+	req := s.newFormRequest("/trash/1/purge", url.Values{})
+
+	rec := httptest.NewRecorder()
+
+	s.router.ServeHTTP(rec, req)
+	res := rec.Result()
+
+	require.Equal(s.T(), http.StatusSeeOther, res.StatusCode)
+	require.Equal(s.T(), "/trash", res.Header.Get("Location"))
+}
@@ -0,0 +1,89 @@
+// Package database connects to the postgres database of berat and migrates
+// its tables, so the web server and the command line tool share the same
+// configuration
+package database
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/erizkiatama/berat/models"
+
+	"github.com/jinzhu/gorm"
+	// postgres is the only database berat runs on
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+)
+
+// Config is the connection configuration of the database
+type Config struct {
+	Host     string
+	Port     string
+	User     string
+	Name     string
+	Password string
+}
+
+// ConfigFromEnv reads the configuration from the DB_HOST, DB_PORT,
+// DB_USER, DB_NAME and DB_PASSWORD environment variables
+func ConfigFromEnv() Config {
+	return Config{
+		Host:     os.Getenv("DB_HOST"),
+		Port:     os.Getenv("DB_PORT"),
+		User:     os.Getenv("DB_USER"),
+		Name:     os.Getenv("DB_NAME"),
+		Password: os.Getenv("DB_PASSWORD"),
+	}
+}
+
+// Open connects to the database and migrates its tables
+func Open(cfg Config) (*gorm.DB, error) {
+	DBURL := fmt.Sprintf("host=%s port=%s user=%s dbname=%s sslmode=disable password=%s", cfg.Host, cfg.Port, cfg.User, cfg.Name, cfg.Password)
+	db, err := gorm.Open("postgres", DBURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := Migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Migrate creates or updates the tables, indexes and foreign keys, and
+// stops at the first statement the database refuses
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(&models.Weight{}, &models.Reading{}, &models.MeasurementType{}, &models.Measurement{}, &models.Profile{}, &models.Tag{}, &models.WeightTag{}, &models.IdempotencyKey{}).Error; err != nil {
+		return fmt.Errorf("migrate: %v", err)
+	}
+
+	statements := []string{
+		"ALTER TABLE weights DROP COLUMN IF EXISTS difference",
+		"ALTER TABLE weights DROP CONSTRAINT IF EXISTS weights_date_key",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_weights_date_active ON weights (date) WHERE deleted_at IS NULL",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("migrate: %s: %v", statement, err)
+		}
+	}
+
+	foreignKeys := []struct {
+		model      interface{}
+		field      string
+		references string
+	}{
+		{&models.Reading{}, "weight_id", "weights(id)"},
+		{&models.Measurement{}, "type_id", "measurement_types(id)"},
+		{&models.WeightTag{}, "weight_id", "weights(id)"},
+		{&models.WeightTag{}, "tag_id", "tags(id)"},
+	}
+	for _, fk := range foreignKeys {
+		if err := db.Model(fk.model).AddForeignKey(fk.field, fk.references, "CASCADE", "CASCADE").Error; err != nil {
+			return fmt.Errorf("migrate: foreign key %s: %v", fk.field, err)
+		}
+	}
+
+	return nil
+}
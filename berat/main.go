@@ -2,25 +2,25 @@ package main
 
 import (
 	"fmt"
+	"html/template"
 	"log"
 	"net/http"
 	"os"
-	"text/template"
-
-	"github.com/gorilla/mux"
+	"time"
 
 	"github.com/erizkiatama/berat/controllers"
+	"github.com/erizkiatama/berat/database"
 	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/notify"
+	"github.com/erizkiatama/berat/services"
 
 	"github.com/joho/godotenv"
 
 	"github.com/jinzhu/gorm"
-	_ "github.com/jinzhu/gorm/dialects/postgres"
 )
 
-func initDB(dbHost, dbPort, dbUser, dbName, dbPassword string) *gorm.DB {
-	DBURL := fmt.Sprintf("host=%s port=%s user=%s dbname=%s sslmode=disable password=%s", dbHost, dbPort, dbUser, dbName, dbPassword)
-	db, err := gorm.Open("postgres", DBURL)
+func initDB() *gorm.DB {
+	db, err := database.Open(database.ConfigFromEnv())
 	if err != nil {
 		fmt.Println("Cannot connect to the database")
 		log.Fatal(err)
@@ -28,11 +28,60 @@ func initDB(dbHost, dbPort, dbUser, dbName, dbPassword string) *gorm.DB {
 		fmt.Println("Connected to the database")
 	}
 
-	db.AutoMigrate(&models.Weight{})
-
 	return db
 }
 
+// purgeExpired permanently deletes the expired weight data in the trash
+// and the expired idempotency keys now and then once a day
+func purgeExpired(ws *services.WeightService) {
+	for {
+		purged, err := ws.PurgeExpired(time.Now())
+		if err != nil {
+			log.Printf("Error purging the trash: %s", err.Error())
+		} else if purged > 0 {
+			log.Printf("Purged %d weight data from the trash", purged)
+		}
+
+		if _, err := ws.PurgeExpiredIdempotencyKeys(time.Now()); err != nil {
+			log.Printf("Error purging the idempotency keys: %s", err.Error())
+		}
+
+		time.Sleep(24 * time.Hour)
+	}
+}
+
+// remind checks the reminder at its time every day
+func remind(reminder *services.Reminder) {
+	for {
+		time.Sleep(time.Until(reminder.Next(time.Now())))
+
+		if _, err := reminder.Check(time.Now()); err != nil {
+			log.Printf("Error sending the reminder: %s", err.Error())
+		}
+	}
+}
+
+// newReminder creates the reminder configured by REMINDER_TIME, nil when
+// it is empty. It notifies to the file REMINDER_FILE or else to the log
+func newReminder(ws *services.WeightService) *services.Reminder {
+	value := os.Getenv("REMINDER_TIME")
+	if value == "" {
+		return nil
+	}
+
+	at, err := services.ParseReminderTime(value)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var notifier notify.Notifier = &notify.LogNotifier{}
+	if path := os.Getenv("REMINDER_FILE"); path != "" {
+		notifier = &notify.FileNotifier{Path: path}
+	}
+
+	return &services.Reminder{Service: ws, Notifier: notifier, At: at}
+}
+
 func init() {
 	err := godotenv.Load()
 	if err != nil {
@@ -41,13 +90,26 @@ func init() {
 }
 
 func main() {
-	db := initDB(os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_USER"), os.Getenv("DB_NAME"), os.Getenv("DB_PASSWORD"))
+	db := initDB()
 
 	template := template.Must(template.ParseGlob("views/*.html"))
 	weightRepo := &models.WeightRepository{DB: db}
-	router := mux.NewRouter()
+	weightService, err := services.NewConfiguredWeightService(weightRepo, services.ConfigFromEnv())
+	if err != nil {
+		log.Fatalf("Error loading the rules: %s", err.Error())
+	}
+
+	if err := weightService.SeedMeasurementTypes(); err != nil {
+		log.Fatalf("Error creating measurement types: %s", err.Error())
+	}
+
+	go purgeExpired(weightService)
+
+	if reminder := newReminder(weightService); reminder != nil {
+		go remind(reminder)
+	}
 
-	controllers.NewWeightController(weightRepo, template, router)
+	router := controllers.NewRouter(weightService, template)
 
 	fmt.Println("Listening to port 8080")
 	log.Fatal(http.ListenAndServe(":8080", router))
@@ -1,51 +1,222 @@
 package models
 
 import (
-	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/jinzhu/gorm"
 )
 
 // Weight is the model entity for this application
 type Weight struct {
-	ID         uint64 `gorm:"primary_key;auto_increment"`
-	Date       string `gorm:"not null;unique;default:null"`
-	Max        int    `gorm:"not null;default:null"`
-	Min        int    `gorm:"not null;default:null"`
-	Difference int    `gorm:"not null;default:null"`
+	ID         uint64     `gorm:"primary_key;auto_increment" json:"id"`
+	Date       string     `gorm:"not null;default:null" json:"date"`
+	Max        int        `gorm:"not null;default:null" json:"max"`
+	Min        int        `gorm:"not null;default:null" json:"min"`
+	Difference int        `gorm:"-" json:"difference"`
+	Notes      string     `gorm:"type:text;not null;default:''" json:"notes"`
+	Tags       []string   `gorm:"-" json:"tags"`
+	DeletedAt  *time.Time `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// CalculateDifference sets the Difference of the Weight from its Max and
+// Min. Difference is not stored, so it could never disagree with them
+func (w *Weight) CalculateDifference() {
+	w.Difference = w.Max - w.Min
+}
+
+// AfterFind is called by gorm for every Weight data read from database
+func (w *Weight) AfterFind() error {
+	w.CalculateDifference()
+
+	return nil
+}
+
+// ValidationErrors holds every validation failure keyed by the field name,
+// so it could be shown next to each form input or sent as JSON
+type ValidationErrors map[string]string
+
+// Error joins all the failures, ordered by field name, into one message
+func (ve ValidationErrors) Error() string {
+	fields := make([]string, 0, len(ve))
+	for field := range ve {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	messages := make([]string, 0, len(fields))
+	for _, field := range fields {
+		messages = append(messages, ve[field])
+	}
+
+	return strings.Join(messages, ", ")
+}
+
+// Add records the message for the field unless the field already failed,
+// so the first failure of a field is the one being reported
+func (ve ValidationErrors) Add(field, message string) {
+	if _, ok := ve[field]; !ok {
+		ve[field] = message
+	}
 }
 
 // Repository is an interace of repository for easy mocking
 type Repository interface {
+	Transaction(func(Repository) error) error
+
 	Save(*Weight) (*Weight, error)
+	SaveAll([]Weight) error
+	UpsertByDate(*Weight) (created bool, err error)
 	FindAll() (*[]Weight, error)
 	FindByID(uint64) (*Weight, error)
 	FindByDate(date string) (*Weight, error)
+	FindPrevious(date string) (*Weight, error)
 	Update(uint64, *Weight) (*Weight, error)
 	Delete(uint64) error
+
+	SaveReading(*Reading) (*Reading, error)
+	FindReadingByID(uint64) (*Reading, error)
+	FindReadingsByWeightID(uint64) (*[]Reading, error)
+	UpdateReading(uint64, *Reading) (*Reading, error)
+	DeleteReading(uint64) error
+
+	FindAllMeasurementTypes() (*[]MeasurementType, error)
+	FindMeasurementTypeByID(uint64) (*MeasurementType, error)
+	SaveMeasurementType(*MeasurementType) (*MeasurementType, error)
+	UpdateMeasurementType(uint64, *MeasurementType) (*MeasurementType, error)
+	DeleteMeasurementType(uint64) error
+
+	FindAllMeasurements() (*[]Measurement, error)
+	FindMeasurementByID(uint64) (*Measurement, error)
+	FindMeasurementByTypeAndDate(typeID uint64, date string) (*Measurement, error)
+	SaveMeasurement(*Measurement) (*Measurement, error)
+	UpdateMeasurement(uint64, *Measurement) (*Measurement, error)
+	DeleteMeasurement(uint64) error
+
+	FindDeleted() (*[]Weight, error)
+	FindDeletedByID(uint64) (*Weight, error)
+	Restore(uint64) error
+	Purge(uint64) error
+	PurgeDeletedBefore(time.Time) (int64, error)
+
+	FindAllWithDeleted() (*[]Weight, error)
+	RepairWeights(repaired []Weight, trash []uint64) error
+
+	FindAllTags() (*[]Tag, error)
+	FindWeightTagNames() (map[uint64][]string, error)
+	SetWeightTags(weightID uint64, names []string) error
+
+	FindProfile() (*Profile, error)
+	SaveProfile(*Profile) (*Profile, error)
+
+	FindIdempotencyKey(key string) (*IdempotencyKey, error)
+	SaveIdempotencyKey(*IdempotencyKey) error
+	UpdateIdempotencyKey(*IdempotencyKey) error
+	DeleteIdempotencyKey(key string) error
+	DeleteExpiredIdempotencyKeys(now time.Time) (int64, error)
 }
 
+// MaxNotesLength is the longest notes allowed on a weight data
+const MaxNotesLength = 500
+
 // WeightRepository is the our wrapper for doing transaction to database
 type WeightRepository struct {
 	DB *gorm.DB
+
+	// inTx is true on the repository given by Transaction
+	inTx bool
+}
+
+// Transaction runs fn with a repository whose writes all belong to one
+// transaction. It is committed when fn returns nil and rolled back when
+// fn returns an error or panics
+func (wr *WeightRepository) Transaction(fn func(Repository) error) error {
+	if wr.inTx {
+		return fn(wr)
+	}
+
+	tx := wr.begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			tx.Rollback()
+		}
+	}()
+
+	if err := fn(&WeightRepository{DB: tx, inTx: true}); err != nil {
+		return err
+	}
+
+	committed = true
+	return tx.Commit().Error
+}
+
+// begin starts a transaction, or carries on with the one of Transaction
+// which is then committed or rolled back by Transaction alone
+func (wr *WeightRepository) begin() *gorm.DB {
+	if wr.inTx {
+		return wr.DB
+	}
+
+	return wr.DB.Begin()
+}
+
+// commit commits the transaction started by begin
+func (wr *WeightRepository) commit(tx *gorm.DB) error {
+	if wr.inTx {
+		return nil
+	}
+
+	return tx.Commit().Error
+}
+
+// rollback rolls back the transaction started by begin
+func (wr *WeightRepository) rollback(tx *gorm.DB) {
+	if !wr.inTx {
+		tx.Rollback()
+	}
 }
 
 // Validate will check all validation needed for Weight model.
+// It returns ValidationErrors containing every failed field, or nil
 func (w *Weight) Validate() error {
+	errs := ValidationErrors{}
+
 	if w.Date == "" {
-		return errors.New("Required date")
+		errs.Add("date", "Required date")
 	}
 
 	if w.Max < 1 {
-		return errors.New("Required max weight")
+		errs.Add("max", "Required max weight")
 	}
 
 	if w.Min < 1 {
-		return errors.New("Required min weight")
+		errs.Add("min", "Required min weight")
+	}
+
+	if w.Max > 0 && w.Min > 0 && w.Max < w.Min {
+		errs.Add("max", "Max weight could not be smaller than min weight")
+	}
+
+	if utf8.RuneCountInString(w.Notes) > MaxNotesLength {
+		errs.Add("notes", fmt.Sprintf("Notes could not be longer than %d characters", MaxNotesLength))
 	}
 
-	if w.Max < w.Min {
-		return errors.New("Max weight could not be smaller than min weight")
+	for _, tag := range w.Tags {
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			errs.Add("tags", fmt.Sprintf("Tag %q is longer than %d characters", tag, MaxTagLength))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
 	}
 
 	return nil
@@ -62,7 +233,48 @@ func (wr *WeightRepository) Save(weight *Weight) (*Weight, error) {
 	return weight, nil
 }
 
-// FindAll will get all Weight data from database
+// SaveAll accept many Weight data as parameter and save them to database
+// in one transaction, so either all of them are saved or none of them.
+// The ids of the saved data are set in the given slice
+func (wr *WeightRepository) SaveAll(weights []Weight) error {
+	tx := wr.begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	for i := range weights {
+		if err := tx.Create(&weights[i]).Error; err != nil {
+			wr.rollback(tx)
+			return err
+		}
+	}
+
+	return wr.commit(tx)
+}
+
+// upsertByDateQuery inserts the weight data or updates the one of the same
+// date in one statement. xmax is zero only for a freshly inserted row
+const upsertByDateQuery = `INSERT INTO weights (date, max, min, notes) VALUES (?, ?, ?, ?)
+	ON CONFLICT (date) WHERE deleted_at IS NULL DO UPDATE SET
+	max = EXCLUDED.max, min = EXCLUDED.min, notes = EXCLUDED.notes
+	RETURNING id, (xmax = 0) AS created`
+
+// UpsertByDate accept Weight as parameter and atomically creates it, or
+// updates all the fields of the weight data having the same date when
+// there is one. The id of the saved data is set in the given weight and
+// created tells whether it was created or updated
+func (wr *WeightRepository) UpsertByDate(weight *Weight) (created bool, err error) {
+	err = wr.DB.Raw(upsertByDateQuery, weight.Date, weight.Max, weight.Min, weight.Notes).
+		Row().
+		Scan(&weight.ID, &created)
+	if err != nil {
+		return false, err
+	}
+
+	return created, nil
+}
+
+// FindAll will get all Weight data from database, except the deleted ones
 func (wr *WeightRepository) FindAll() (*[]Weight, error) {
 	var weights []Weight
 
@@ -100,10 +312,29 @@ func (wr *WeightRepository) FindByDate(date string) (*Weight, error) {
 	return &weight, nil
 }
 
+// FindPrevious accept date as parameter and it will get
+// the latest Weight data before the date
+func (wr *WeightRepository) FindPrevious(date string) (*Weight, error) {
+	var weight Weight
+
+	err := wr.DB.Where("date < ?", date).Order("date DESC").Take(&weight).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &weight, nil
+}
+
 // Update accept id type uint64 and Weight data as parameter and
-// it will update the weight data in database based on the id
+// it will update all the fields of the weight data, zero values included,
+// in database based on the id
 func (wr *WeightRepository) Update(id uint64, newWeight *Weight) (*Weight, error) {
-	err := wr.DB.Model(&Weight{}).Where("id = ?", id).Updates(&newWeight).Error
+	err := wr.DB.Model(&Weight{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"date":  newWeight.Date,
+		"max":   newWeight.Max,
+		"min":   newWeight.Min,
+		"notes": newWeight.Notes,
+	}).Error
 	if err != nil {
 		return nil, err
 	}
@@ -111,8 +342,9 @@ func (wr *WeightRepository) Update(id uint64, newWeight *Weight) (*Weight, error
 	return newWeight, nil
 }
 
-// Delete accept id type uint64 as parameter and
-// it will delete the Weight data in database based on the id
+// Delete accept id type uint64 as parameter and it will move the Weight
+// data to the trash by setting its deleted_at, it is kept in database
+// until it is purged
 func (wr *WeightRepository) Delete(id uint64) error {
 	err := wr.DB.Where("id = ?", id).Delete(&Weight{}).Error
 	if err != nil {
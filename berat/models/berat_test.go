@@ -2,6 +2,7 @@ package models_test
 
 import (
 	"database/sql"
+	"errors"
 	"regexp"
 	"testing"
 
@@ -79,6 +80,25 @@ func (s *Suite) Test_Weight_Model_Validate_When_Max_Less_Than_Min() {
 
 	err := s.weight.Validate()
 	require.Error(s.T(), err)
+	require.Equal(s.T(), "Max weight could not be smaller than min weight", err.(models.ValidationErrors)["max"])
+}
+
+func (s *Suite) Test_Weight_Model_Validate_Reports_All_Fields() {
+	s.weight.Date = ""
+	s.weight.Max = 0
+	s.weight.Min = 0
+
+	err := s.weight.Validate()
+	require.Error(s.T(), err)
+
+	errs, ok := err.(models.ValidationErrors)
+	require.True(s.T(), ok)
+	require.Equal(s.T(), models.ValidationErrors{
+		"date": "Required date",
+		"max":  "Required max weight",
+		"min":  "Required min weight",
+	}, errs)
+	require.Equal(s.T(), "Required date, Required max weight, Required min weight", err.Error())
 }
 
 func (s *Suite) Test_Weight_Model_Validate_Success() {
@@ -88,13 +108,13 @@ func (s *Suite) Test_Weight_Model_Validate_Success() {
 
 func (s *Suite) Test_Repository_Save_Given_Valid_Weight_Data() {
 	weightID := uint64(10)
-	sqlQuery := `INSERT INTO "weights" ("date","max","min","difference") 
+	sqlQuery := `INSERT INTO "weights" ("date","max","min","deleted_at") 
 		VALUES ($1,$2,$3,$4) RETURNING "weights"."id"`
 	rows := sqlmock.NewRows([]string{"id"}).AddRow(weightID)
 
 	s.mock.ExpectBegin()
 	s.mock.ExpectQuery(regexp.QuoteMeta(sqlQuery)).
-		WithArgs(s.weight.Date, s.weight.Max, s.weight.Min, s.weight.Difference).
+		WithArgs(s.weight.Date, s.weight.Max, s.weight.Min, nil).
 		WillReturnRows(rows)
 	s.mock.ExpectCommit()
 
@@ -106,15 +126,94 @@ func (s *Suite) Test_Repository_Save_Given_Valid_Weight_Data() {
 	require.Equal(s.T(), res.ID, s.weight.ID)
 }
 
+func (s *Suite) Test_Repository_SaveAll_Rollback_When_A_Row_Fails() {
+	sqlQuery := `INSERT INTO "weights" ("date","max","min","deleted_at") 
+		VALUES ($1,$2,$3,$4) RETURNING "weights"."id"`
+	weights := []models.Weight{
+		{Date: "2020-11-09", Max: 50, Min: 48, Difference: 2},
+		{Date: "2020-11-10", Max: 51, Min: 49, Difference: 2},
+	}
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(sqlQuery)).
+		WithArgs("2020-11-09", 50, 48, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+	s.mock.ExpectQuery(regexp.QuoteMeta(sqlQuery)).
+		WithArgs("2020-11-10", 51, 49, nil).
+		WillReturnError(errors.New("duplicate key value violates unique constraint"))
+	s.mock.ExpectRollback()
+
+	err := s.repo.SaveAll(weights)
+	require.Error(s.T(), err)
+}
+
+func (s *Suite) Test_Repository_Transaction_Rollback_When_A_Write_Fails() {
+	sqlQuery := `INSERT INTO "weights" ("date","max","min","deleted_at") 
+		VALUES ($1,$2,$3,$4) RETURNING "weights"."id"`
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(sqlQuery)).
+		WithArgs(s.weight.Date, s.weight.Max, s.weight.Min, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "weight_tags" WHERE (weight_id = $1)`)).
+		WithArgs(10).
+		WillReturnError(errors.New("connection reset"))
+	s.mock.ExpectRollback()
+
+	err := s.repo.Transaction(func(repo models.Repository) error {
+		weight, err := repo.Save(s.weight)
+		if err != nil {
+			return err
+		}
+
+		return repo.SetWeightTags(weight.ID, []string{"sick"})
+	})
+	require.EqualError(s.T(), err, "connection reset")
+}
+
+func (s *Suite) Test_Repository_Transaction_Commit_Once() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "weight_tags" WHERE (weight_id = $1)`)).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "weight_tags" WHERE (weight_id = $1)`)).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mock.ExpectCommit()
+
+	err := s.repo.Transaction(func(repo models.Repository) error {
+		if err := repo.SetWeightTags(1, nil); err != nil {
+			return err
+		}
+
+		return repo.SetWeightTags(2, nil)
+	})
+	require.NoError(s.T(), err)
+}
+
+func (s *Suite) Test_Repository_UpsertByDate_Return_Whether_Created() {
+	sqlQuery := `INSERT INTO weights (date, max, min, notes) VALUES ($1, $2, $3, $4)
+	ON CONFLICT (date) WHERE deleted_at IS NULL DO UPDATE SET`
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(sqlQuery)).
+		WithArgs(s.weight.Date, s.weight.Max, s.weight.Min, "").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created"}).AddRow(10, false))
+
+	created, err := s.repo.UpsertByDate(s.weight)
+	require.NoError(s.T(), err)
+	require.False(s.T(), created)
+	require.Equal(s.T(), uint64(10), s.weight.ID)
+}
+
 func (s *Suite) Test_Repository_Save_Given_Invalid_Weight_Data() {
 	s.weight.Date = ""
 
-	sqlQuery := `INSERT INTO "weights" ("max","min","difference") 
+	sqlQuery := `INSERT INTO "weights" ("max","min","deleted_at") 
 		VALUES ($1,$2,$3) RETURNING "weights"."id"`
 
 	s.mock.ExpectBegin()
 	s.mock.ExpectQuery(regexp.QuoteMeta(sqlQuery)).
-		WithArgs(s.weight.Max, s.weight.Min, s.weight.Difference).
+		WithArgs(s.weight.Max, s.weight.Min, nil).
 		WillReturnError(gorm.ErrInvalidTransaction)
 
 	res, err := s.repo.Save(s.weight)
@@ -127,16 +226,17 @@ func (s *Suite) Test_Repository_Save_Given_Invalid_Weight_Data() {
 func (s *Suite) Test_Repository_FindAll() {
 	sqlQuery := `SELECT * FROM "weights"`
 	rows := sqlmock.
-		NewRows([]string{"id", "date", "max", "min", "difference"}).
-		AddRow(1, "2020-11-01", 50, 48, 2).
-		AddRow(2, "2020-11-02", 52, 50, 2).
-		AddRow(3, "2020-11-03", 54, 52, 2)
+		NewRows([]string{"id", "date", "max", "min"}).
+		AddRow(1, "2020-11-01", 50, 48).
+		AddRow(2, "2020-11-02", 52, 50).
+		AddRow(3, "2020-11-03", 54, 51)
 
 	s.mock.ExpectQuery(regexp.QuoteMeta(sqlQuery)).WillReturnRows(rows)
 
 	res, err := s.repo.FindAll()
 	require.NoError(s.T(), err)
 	require.Len(s.T(), *res, 3)
+	require.Equal(s.T(), 3, (*res)[2].Difference)
 }
 
 func (s *Suite) Test_Repository_FindAll_When_Database_Is_Empty() {
@@ -163,10 +263,10 @@ func (s *Suite) Test_Repository_FindAll_Transaction_Error() {
 func (s *Suite) Test_Repository_FindByID_Given_Valid_ID() {
 	s.weight.ID = 1
 
-	sqlQuery := `SELECT * FROM "weights" WHERE (id = $1) LIMIT 1`
+	sqlQuery := `SELECT * FROM "weights" WHERE "weights"."deleted_at" IS NULL AND ((id = $1)) LIMIT 1`
 	rows := sqlmock.
-		NewRows([]string{"id", "date", "max", "min", "difference"}).
-		AddRow(s.weight.ID, s.weight.Date, s.weight.Max, s.weight.Min, s.weight.Difference)
+		NewRows([]string{"id", "date", "max", "min"}).
+		AddRow(s.weight.ID, s.weight.Date, s.weight.Max, s.weight.Min)
 
 	s.mock.ExpectQuery(regexp.QuoteMeta(sqlQuery)).WithArgs(s.weight.ID).WillReturnRows(rows)
 
@@ -178,7 +278,7 @@ func (s *Suite) Test_Repository_FindByID_Given_Valid_ID() {
 func (s *Suite) Test_Repository_FindByID_Given_Invalid_ID() {
 	weightID := uint64(1)
 
-	sqlQuery := `SELECT * FROM "weights" WHERE (id = $1) LIMIT 1`
+	sqlQuery := `SELECT * FROM "weights" WHERE "weights"."deleted_at" IS NULL AND ((id = $1)) LIMIT 1`
 	rows := sqlmock.NewRows(nil)
 
 	s.mock.ExpectQuery(regexp.QuoteMeta(sqlQuery)).WithArgs(weightID).WillReturnRows(rows)
@@ -191,10 +291,10 @@ func (s *Suite) Test_Repository_FindByID_Given_Invalid_ID() {
 func (s *Suite) Test_Repository_FindByDate_Given_Valid_Date() {
 	s.weight.ID = 1
 
-	sqlQuery := `SELECT * FROM "weights" WHERE (date = $1) LIMIT 1`
+	sqlQuery := `SELECT * FROM "weights" WHERE "weights"."deleted_at" IS NULL AND ((date = $1)) LIMIT 1`
 	rows := sqlmock.
-		NewRows([]string{"id", "date", "max", "min", "difference"}).
-		AddRow(s.weight.ID, s.weight.Date, s.weight.Max, s.weight.Min, s.weight.Difference)
+		NewRows([]string{"id", "date", "max", "min"}).
+		AddRow(s.weight.ID, s.weight.Date, s.weight.Max, s.weight.Min)
 
 	s.mock.ExpectQuery(regexp.QuoteMeta(sqlQuery)).WithArgs(s.weight.Date).WillReturnRows(rows)
 
@@ -206,7 +306,7 @@ func (s *Suite) Test_Repository_FindByDate_Given_Valid_Date() {
 func (s *Suite) Test_Repository_FindByDate_Given_Invalid_Date() {
 	date := ""
 
-	sqlQuery := `SELECT * FROM "weights" WHERE (date = $1) LIMIT 1`
+	sqlQuery := `SELECT * FROM "weights" WHERE "weights"."deleted_at" IS NULL AND ((date = $1)) LIMIT 1`
 	rows := sqlmock.NewRows(nil)
 
 	s.mock.ExpectQuery(regexp.QuoteMeta(sqlQuery)).WithArgs(date).WillReturnRows(rows)
@@ -216,13 +316,44 @@ func (s *Suite) Test_Repository_FindByDate_Given_Invalid_Date() {
 	require.Nil(s.T(), res)
 }
 
+func (s *Suite) Test_Repository_FindPrevious() {
+	s.weight.ID = 1
+
+	sqlQuery := `SELECT * FROM "weights" WHERE "weights"."deleted_at" IS NULL AND ((date < $1)) ORDER BY date DESC LIMIT 1`
+	rows := sqlmock.
+		NewRows([]string{"id", "date", "max", "min"}).
+		AddRow(s.weight.ID, s.weight.Date, s.weight.Max, s.weight.Min)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(sqlQuery)).WithArgs("2020-11-12").WillReturnRows(rows)
+
+	res, err := s.repo.FindPrevious("2020-11-12")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), res, s.weight)
+}
+
 func (s *Suite) Test_Repository_Update_Given_Valid_ID() {
-	sqlQuery := `UPDATE "weights" SET "date" = $1, "difference" = $2, "max" = $3, "min" = $4 WHERE (id = $5)`
+	sqlQuery := `UPDATE "weights" SET "date" = $1, "max" = $2, "min" = $3, "notes" = $4 WHERE "weights"."deleted_at" IS NULL AND ((id = $5))`
+	weightID := uint64(10)
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(sqlQuery)).
+		WithArgs(s.weight.Date, s.weight.Max, s.weight.Min, s.weight.Notes, weightID).
+		WillReturnResult(sqlmock.NewResult(10, 1))
+	s.mock.ExpectCommit()
+
+	res, err := s.repo.Update(weightID, s.weight)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), res, s.weight)
+}
+
+func (s *Suite) Test_Repository_Update_Write_Empty_Notes() {
+	sqlQuery := `UPDATE "weights" SET "date" = $1, "max" = $2, "min" = $3, "notes" = $4 WHERE "weights"."deleted_at" IS NULL AND ((id = $5))`
 	weightID := uint64(10)
+	s.weight.Notes = ""
 
 	s.mock.ExpectBegin()
 	s.mock.ExpectExec(regexp.QuoteMeta(sqlQuery)).
-		WithArgs(s.weight.Date, s.weight.Difference, s.weight.Max, s.weight.Min, weightID).
+		WithArgs(s.weight.Date, s.weight.Max, s.weight.Min, "", weightID).
 		WillReturnResult(sqlmock.NewResult(10, 1))
 	s.mock.ExpectCommit()
 
@@ -232,12 +363,12 @@ func (s *Suite) Test_Repository_Update_Given_Valid_ID() {
 }
 
 func (s *Suite) Test_Repository_Update_Given_Invalid_ID() {
-	sqlQuery := `UPDATE "weights" SET "date" = $1, "difference" = $2, "max" = $3, "min" = $4 WHERE (id = $5)`
+	sqlQuery := `UPDATE "weights" SET "date" = $1, "max" = $2, "min" = $3, "notes" = $4 WHERE "weights"."deleted_at" IS NULL AND ((id = $5))`
 	weightID := uint64(10)
 
 	s.mock.ExpectBegin()
 	s.mock.ExpectExec(regexp.QuoteMeta(sqlQuery)).
-		WithArgs(s.weight.Date, s.weight.Difference, s.weight.Max, s.weight.Min, weightID).
+		WithArgs(s.weight.Date, s.weight.Max, s.weight.Min, s.weight.Notes, weightID).
 		WillReturnResult(sqlmock.NewErrorResult(gorm.ErrRecordNotFound))
 
 	res, err := s.repo.Update(weightID, s.weight)
@@ -247,9 +378,9 @@ func (s *Suite) Test_Repository_Update_Given_Invalid_ID() {
 
 func (s *Suite) Test_Repository_Delete_Given_Valid_ID() {
 	weightID := uint64(1)
-	sqlQuery := `DELETE FROM "weights" WHERE (id = $1)`
+	sqlQuery := `UPDATE "weights" SET "deleted_at"=$1  WHERE "weights"."deleted_at" IS NULL AND ((id = $2))`
 
-	s.mock.ExpectExec(regexp.QuoteMeta(sqlQuery)).WithArgs(weightID).WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(sqlQuery)).WithArgs(sqlmock.AnyArg(), weightID).WillReturnResult(sqlmock.NewResult(1, 1))
 
 	err := s.repo.Delete(weightID)
 	require.NoError(s.T(), err)
@@ -257,9 +388,9 @@ func (s *Suite) Test_Repository_Delete_Given_Valid_ID() {
 
 func (s *Suite) Test_Repository_Delete_Given_Invalid_ID() {
 	weightID := uint64(1)
-	sqlQuery := `DELETE FROM "weights" WHERE (id = $1)`
+	sqlQuery := `UPDATE "weights" SET "deleted_at"=$1  WHERE "weights"."deleted_at" IS NULL AND ((id = $2))`
 
-	s.mock.ExpectExec(regexp.QuoteMeta(sqlQuery)).WithArgs(weightID).WillReturnResult(sqlmock.NewErrorResult(gorm.ErrRecordNotFound))
+	s.mock.ExpectExec(regexp.QuoteMeta(sqlQuery)).WithArgs(sqlmock.AnyArg(), weightID).WillReturnResult(sqlmock.NewErrorResult(gorm.ErrRecordNotFound))
 
 	err := s.repo.Delete(weightID)
 	require.Error(s.T(), err)
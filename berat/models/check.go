@@ -0,0 +1,47 @@
+package models
+
+// FindAllWithDeleted will get all Weight data from database,
+// the ones in the trash included, ordered by id
+func (wr *WeightRepository) FindAllWithDeleted() (*[]Weight, error) {
+	var weights []Weight
+
+	err := wr.DB.Unscoped().Order("id ASC").Find(&weights).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &weights, nil
+}
+
+// RepairWeights accept the repaired Weight data and the ids of the Weight
+// data to move to the trash as parameter. All the fields of the repaired
+// data are written, zero values included, and the others are moved to the
+// trash in one transaction, so either all of them are repaired or none
+func (wr *WeightRepository) RepairWeights(repaired []Weight, trash []uint64) error {
+	tx := wr.begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	for _, weight := range repaired {
+		err := tx.Unscoped().Model(&Weight{}).Where("id = ?", weight.ID).Updates(map[string]interface{}{
+			"date":  weight.Date,
+			"max":   weight.Max,
+			"min":   weight.Min,
+			"notes": weight.Notes,
+		}).Error
+		if err != nil {
+			wr.rollback(tx)
+			return err
+		}
+	}
+
+	if len(trash) > 0 {
+		if err := tx.Where("id IN (?)", trash).Delete(&Weight{}).Error; err != nil {
+			wr.rollback(tx)
+			return err
+		}
+	}
+
+	return wr.commit(tx)
+}
@@ -0,0 +1,39 @@
+package models_test
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/stretchr/testify/require"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/erizkiatama/berat/models"
+)
+
+func (s *Suite) Test_Repository_FindAllWithDeleted() {
+	sqlQuery := `SELECT * FROM "weights" ORDER BY id ASC`
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(sqlQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "date"}).AddRow(1, "2020-11-09").AddRow(2, "2020/11/10"))
+
+	weights, err := s.repo.FindAllWithDeleted()
+	require.NoError(s.T(), err)
+	require.Len(s.T(), *weights, 2)
+}
+
+func (s *Suite) Test_Repository_RepairWeights_Rollback_When_Trash_Fails() {
+	updateQuery := `UPDATE "weights" SET "date" = $1, "max" = $2, "min" = $3, "notes" = $4 WHERE (id = $5)`
+	trashQuery := `UPDATE "weights" SET "deleted_at"=$1 WHERE "weights"."deleted_at" IS NULL AND ((id IN ($2)))`
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(updateQuery)).
+		WithArgs("2020-11-09", 50, 48, "", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mock.ExpectExec(regexp.QuoteMeta(trashQuery)).
+		WithArgs(sqlmock.AnyArg(), 2).
+		WillReturnError(errors.New("connection reset"))
+	s.mock.ExpectRollback()
+
+	err := s.repo.RepairWeights([]models.Weight{{ID: 1, Date: "2020-11-09", Max: 50, Min: 48}}, []uint64{2})
+	require.EqualError(s.T(), err, "connection reset")
+}
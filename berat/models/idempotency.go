@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// IdempotencyKey is the key sent by a client in the Idempotency-Key header
+// of a create request, with the response given to that request. Status is
+// zero while the request is still being handled. RequestHash identifies the
+// method, path and body, so a key could not be reused for another request
+type IdempotencyKey struct {
+	Key         string    `gorm:"primary_key" json:"key"`
+	RequestHash string    `gorm:"not null" json:"request_hash"`
+	Status      int       `gorm:"not null;default:0" json:"status"`
+	Location    string    `gorm:"not null;default:''" json:"location"`
+	Body        string    `gorm:"type:text;not null;default:''" json:"body"`
+	ExpiresAt   time.Time `gorm:"not null;index" json:"expires_at"`
+}
+
+// FindIdempotencyKey accept the key as parameter and
+// it will get the IdempotencyKey data based on the key
+func (wr *WeightRepository) FindIdempotencyKey(key string) (*IdempotencyKey, error) {
+	var ik IdempotencyKey
+
+	err := wr.DB.Where("key = ?", key).Take(&ik).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &ik, nil
+}
+
+// SaveIdempotencyKey accept IdempotencyKey as parameter and save it to
+// database. It fails when the key is already saved
+func (wr *WeightRepository) SaveIdempotencyKey(ik *IdempotencyKey) error {
+	return wr.DB.Create(ik).Error
+}
+
+// UpdateIdempotencyKey accept IdempotencyKey as parameter and
+// it will save the response of the request in database based on the key
+func (wr *WeightRepository) UpdateIdempotencyKey(ik *IdempotencyKey) error {
+	return wr.DB.Model(&IdempotencyKey{}).Where("key = ?", ik.Key).Updates(map[string]interface{}{
+		"status":   ik.Status,
+		"location": ik.Location,
+		"body":     ik.Body,
+	}).Error
+}
+
+// DeleteIdempotencyKey accept the key as parameter and
+// it will delete the IdempotencyKey data based on the key
+func (wr *WeightRepository) DeleteIdempotencyKey(key string) error {
+	return wr.DB.Where("key = ?", key).Delete(&IdempotencyKey{}).Error
+}
+
+// DeleteExpiredIdempotencyKeys deletes the IdempotencyKey data expired
+// at the given time and returns how many were deleted
+func (wr *WeightRepository) DeleteExpiredIdempotencyKeys(now time.Time) (int64, error) {
+	res := wr.DB.Where("expires_at <= ?", now).Delete(&IdempotencyKey{})
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
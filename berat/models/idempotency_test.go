@@ -0,0 +1,22 @@
+package models_test
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func (s *Suite) Test_Repository_DeleteExpiredIdempotencyKeys() {
+	now := time.Date(2020, 11, 9, 7, 0, 0, 0, time.UTC)
+	sqlQuery := `DELETE FROM "idempotency_keys" WHERE (expires_at <= $1)`
+
+	s.mock.ExpectExec(regexp.QuoteMeta(sqlQuery)).
+		WithArgs(now).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+
+	deleted, err := s.repo.DeleteExpiredIdempotencyKeys(now)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), int64(4), deleted)
+}
@@ -0,0 +1,224 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// MeasurementType is a kind of body measurement taken besides the weight,
+// such as body fat percentage, with its unit and the range of valid values
+type MeasurementType struct {
+	ID       uint64  `gorm:"primary_key;auto_increment" json:"id"`
+	Name     string  `gorm:"not null;unique;default:null" json:"name"`
+	Unit     string  `gorm:"not null;default:null" json:"unit"`
+	MinValue float64 `gorm:"not null;default:0" json:"min_value"`
+	MaxValue float64 `gorm:"not null;default:0" json:"max_value"`
+}
+
+// Measurement is the value of a MeasurementType taken on a date.
+// There could only be one measurement of a type on each date
+type Measurement struct {
+	ID     uint64  `gorm:"primary_key;auto_increment" json:"id"`
+	TypeID uint64  `gorm:"not null;unique_index:idx_measurements_type_date" json:"type_id"`
+	Date   string  `gorm:"not null;unique_index:idx_measurements_type_date;default:null" json:"date"`
+	Value  float64 `gorm:"not null;default:0" json:"value"`
+}
+
+// DefaultMeasurementTypes are the measurements of common smart scales,
+// created when the application starts if they do not exist yet
+var DefaultMeasurementTypes = []MeasurementType{
+	{Name: "Body Fat", Unit: "%", MinValue: 2, MaxValue: 75},
+	{Name: "Muscle Mass", Unit: "kg", MinValue: 5, MaxValue: 150},
+	{Name: "Waist", Unit: "cm", MinValue: 40, MaxValue: 200},
+	{Name: "Water", Unit: "%", MinValue: 20, MaxValue: 80},
+}
+
+// Validate will check all validation needed for MeasurementType model.
+// It returns ValidationErrors containing every failed field, or nil
+func (mt *MeasurementType) Validate() error {
+	errs := ValidationErrors{}
+
+	if mt.Name == "" {
+		errs.Add("name", "Required name")
+	}
+
+	if mt.Unit == "" {
+		errs.Add("unit", "Required unit")
+	}
+
+	if mt.MaxValue <= mt.MinValue {
+		errs.Add("max_value", "Max value must be greater than min value")
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// Validate will check all validation needed for Measurement model against
+// the range of its type. It returns ValidationErrors or nil
+func (m *Measurement) Validate(mt *MeasurementType) error {
+	errs := ValidationErrors{}
+
+	if m.Date == "" {
+		errs.Add("date", "Required date")
+	} else if _, err := time.Parse(DateLayout, m.Date); err != nil {
+		errs.Add("date", "Please fill the date correctly (YYYY-MM-DD)")
+	}
+
+	if m.Value < mt.MinValue || m.Value > mt.MaxValue {
+		errs.Add("value", fmt.Sprintf("%s must be between %s and %s %s",
+			mt.Name, formatValue(mt.MinValue), formatValue(mt.MaxValue), mt.Unit))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// FindAllMeasurementTypes will get all MeasurementType data ordered by name
+func (wr *WeightRepository) FindAllMeasurementTypes() (*[]MeasurementType, error) {
+	var types []MeasurementType
+
+	err := wr.DB.Order("name ASC").Find(&types).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &types, nil
+}
+
+// FindMeasurementTypeByID accept id type uint64 as parameter and
+// it will get MeasurementType data based on the id
+func (wr *WeightRepository) FindMeasurementTypeByID(id uint64) (*MeasurementType, error) {
+	var mt MeasurementType
+
+	err := wr.DB.Where("id = ?", id).Take(&mt).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &mt, nil
+}
+
+// SaveMeasurementType accept MeasurementType as parameter and save it to
+// database and it will return saved data if success and error if failed
+func (wr *WeightRepository) SaveMeasurementType(mt *MeasurementType) (*MeasurementType, error) {
+	err := wr.DB.Create(&mt).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return mt, nil
+}
+
+// UpdateMeasurementType accept id type uint64 and MeasurementType data as
+// parameter and it will update all of its fields based on the id
+func (wr *WeightRepository) UpdateMeasurementType(id uint64, mt *MeasurementType) (*MeasurementType, error) {
+	err := wr.DB.Model(&MeasurementType{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"name":      mt.Name,
+		"unit":      mt.Unit,
+		"min_value": mt.MinValue,
+		"max_value": mt.MaxValue,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return mt, nil
+}
+
+// DeleteMeasurementType accept id type uint64 as parameter and it will
+// delete the MeasurementType, its measurements are deleted by the database
+func (wr *WeightRepository) DeleteMeasurementType(id uint64) error {
+	err := wr.DB.Where("id = ?", id).Delete(&MeasurementType{}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindAllMeasurements will get all Measurement data ordered by date
+func (wr *WeightRepository) FindAllMeasurements() (*[]Measurement, error) {
+	var measurements []Measurement
+
+	err := wr.DB.Order("date ASC").Find(&measurements).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &measurements, nil
+}
+
+// FindMeasurementByID accept id type uint64 as parameter and
+// it will get Measurement data based on the id
+func (wr *WeightRepository) FindMeasurementByID(id uint64) (*Measurement, error) {
+	var measurement Measurement
+
+	err := wr.DB.Where("id = ?", id).Take(&measurement).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &measurement, nil
+}
+
+// FindMeasurementByTypeAndDate will get the Measurement data
+// of the MeasurementType taken on the date
+func (wr *WeightRepository) FindMeasurementByTypeAndDate(typeID uint64, date string) (*Measurement, error) {
+	var measurement Measurement
+
+	err := wr.DB.Where("type_id = ? AND date = ?", typeID, date).Take(&measurement).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &measurement, nil
+}
+
+// SaveMeasurement accept Measurement as parameter and save it to database
+// and it will return saved data if success and error if failed
+func (wr *WeightRepository) SaveMeasurement(measurement *Measurement) (*Measurement, error) {
+	err := wr.DB.Create(&measurement).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return measurement, nil
+}
+
+// UpdateMeasurement accept id type uint64 and Measurement data as
+// parameter and it will update all of its fields based on the id
+func (wr *WeightRepository) UpdateMeasurement(id uint64, measurement *Measurement) (*Measurement, error) {
+	err := wr.DB.Model(&Measurement{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"type_id": measurement.TypeID,
+		"date":    measurement.Date,
+		"value":   measurement.Value,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return measurement, nil
+}
+
+// DeleteMeasurement accept id type uint64 as parameter and
+// it will delete the Measurement data in database based on the id
+func (wr *WeightRepository) DeleteMeasurement(id uint64) error {
+	err := wr.DB.Where("id = ?", id).Delete(&Measurement{}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
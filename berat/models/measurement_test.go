@@ -0,0 +1,103 @@
+package models_test
+
+import (
+	"regexp"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func (s *Suite) newMeasurementType() *models.MeasurementType {
+	return &models.MeasurementType{ID: 1, Name: "Body Fat", Unit: "%", MinValue: 2, MaxValue: 75}
+}
+
+func (s *Suite) Test_MeasurementType_Model_Validate_When_Empty() {
+	err := new(models.MeasurementType).Validate()
+	require.Equal(s.T(), models.ValidationErrors{
+		"name":      "Required name",
+		"unit":      "Required unit",
+		"max_value": "Max value must be greater than min value",
+	}, err)
+}
+
+func (s *Suite) Test_Measurement_Model_Validate_Out_Of_Range() {
+	measurement := &models.Measurement{TypeID: 1, Date: "2020-11-09", Value: 80.5}
+
+	err := measurement.Validate(s.newMeasurementType())
+	require.Equal(s.T(), models.ValidationErrors{"value": "Body Fat must be between 2 and 75 %"}, err)
+}
+
+func (s *Suite) Test_Measurement_Model_Validate_Success() {
+	measurement := &models.Measurement{TypeID: 1, Date: "2020-11-09", Value: 21.4}
+
+	err := measurement.Validate(s.newMeasurementType())
+	require.NoError(s.T(), err)
+}
+
+func (s *Suite) Test_Repository_SaveMeasurementType_Given_Valid_Type() {
+	mt := s.newMeasurementType()
+	mt.ID = 0
+	sqlQuery := `INSERT INTO "measurement_types" ("name","unit","min_value","max_value")
+		VALUES ($1,$2,$3,$4) RETURNING "measurement_types"."id"`
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(sqlQuery)).
+		WithArgs(mt.Name, mt.Unit, mt.MinValue, mt.MaxValue).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	s.mock.ExpectCommit()
+
+	res, err := s.repo.SaveMeasurementType(mt)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), uint64(5), res.ID)
+}
+
+func (s *Suite) Test_Repository_FindMeasurementByTypeAndDate_Given_Missing_Date() {
+	sqlQuery := `SELECT * FROM "measurements" WHERE (type_id = $1 AND date = $2) LIMIT 1`
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(sqlQuery)).WithArgs(1, "2020-11-09").WillReturnRows(sqlmock.NewRows(nil))
+
+	res, err := s.repo.FindMeasurementByTypeAndDate(1, "2020-11-09")
+	require.Equal(s.T(), gorm.ErrRecordNotFound, err)
+	require.Nil(s.T(), res)
+}
+
+func (s *Suite) Test_Repository_FindAllMeasurements() {
+	sqlQuery := `SELECT * FROM "measurements" ORDER BY date ASC`
+	rows := sqlmock.
+		NewRows([]string{"id", "type_id", "date", "value"}).
+		AddRow(1, 1, "2020-11-09", 21.4).
+		AddRow(2, 2, "2020-11-09", 80)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(sqlQuery)).WillReturnRows(rows)
+
+	res, err := s.repo.FindAllMeasurements()
+	require.NoError(s.T(), err)
+	require.Len(s.T(), *res, 2)
+	require.Equal(s.T(), 21.4, (*res)[0].Value)
+}
+
+func (s *Suite) Test_Repository_UpdateMeasurement_Given_Valid_ID() {
+	measurement := &models.Measurement{TypeID: 1, Date: "2020-11-09", Value: 0}
+	sqlQuery := `UPDATE "measurements" SET "date" = $1, "type_id" = $2, "value" = $3 WHERE (id = $4)`
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(sqlQuery)).
+		WithArgs(measurement.Date, measurement.TypeID, measurement.Value, 3).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	s.mock.ExpectCommit()
+
+	res, err := s.repo.UpdateMeasurement(3, measurement)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), measurement, res)
+}
+
+func (s *Suite) Test_Repository_DeleteMeasurementType_Given_Valid_ID() {
+	sqlQuery := `DELETE FROM "measurement_types" WHERE (id = $1)`
+
+	s.mock.ExpectExec(regexp.QuoteMeta(sqlQuery)).WithArgs(3).WillReturnResult(sqlmock.NewResult(3, 1))
+
+	err := s.repo.DeleteMeasurementType(3)
+	require.NoError(s.T(), err)
+}
@@ -1,6 +1,8 @@
 package mocks
 
 import (
+	"time"
+
 	"github.com/erizkiatama/berat/models"
 	"github.com/stretchr/testify/mock"
 )
@@ -11,6 +13,14 @@ type WeightRepository struct {
 	mock.Mock
 }
 
+// Transaction provides mock for running fn in one transaction. fn is run
+// with the mock itself, so the writes within are expected on it as usual
+func (_m *WeightRepository) Transaction(fn func(models.Repository) error) error {
+	_m.Called()
+
+	return fn(_m)
+}
+
 // Save provides mock for saving Weight data to database
 func (_m *WeightRepository) Save(w *models.Weight) (*models.Weight, error) {
 	args := _m.Called(w)
@@ -18,6 +28,20 @@ func (_m *WeightRepository) Save(w *models.Weight) (*models.Weight, error) {
 	return args.Get(0).(*models.Weight), args.Error(1)
 }
 
+// SaveAll provides mock for saving many Weight data in one transaction
+func (_m *WeightRepository) SaveAll(weights []models.Weight) error {
+	args := _m.Called(weights)
+
+	return args.Error(0)
+}
+
+// UpsertByDate provides mock for creating or updating Weight data by its date
+func (_m *WeightRepository) UpsertByDate(w *models.Weight) (bool, error) {
+	args := _m.Called(w)
+
+	return args.Bool(0), args.Error(1)
+}
+
 // FindAll provides mock for getting all Weight data from database
 func (_m *WeightRepository) FindAll() (*[]models.Weight, error) {
 	args := _m.Called()
@@ -43,6 +67,17 @@ func (_m *WeightRepository) FindByDate(date string) (*models.Weight, error) {
 	return args.Get(0).(*models.Weight), args.Error(1)
 }
 
+// FindPrevious provides mock for getting the latest Weight data before given date
+func (_m *WeightRepository) FindPrevious(date string) (*models.Weight, error) {
+	args := _m.Called(date)
+
+	if _, ok := args.Get(0).(*models.Weight); !ok {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*models.Weight), args.Error(1)
+}
+
 // Update provides mock for update existing Weight data based on given id
 func (_m *WeightRepository) Update(id uint64, newWeight *models.Weight) (*models.Weight, error) {
 	args := _m.Called(id, newWeight)
@@ -54,5 +89,250 @@ func (_m *WeightRepository) Update(id uint64, newWeight *models.Weight) (*models
 func (_m *WeightRepository) Delete(id uint64) error {
 	args := _m.Called(id)
 
-	return args.Error(1)
+	return args.Error(0)
+}
+
+// SaveReading provides mock for saving Reading data to database
+func (_m *WeightRepository) SaveReading(r *models.Reading) (*models.Reading, error) {
+	args := _m.Called(r)
+
+	return args.Get(0).(*models.Reading), args.Error(1)
+}
+
+// FindReadingByID provides mock for getting Reading data based on given id
+func (_m *WeightRepository) FindReadingByID(id uint64) (*models.Reading, error) {
+	args := _m.Called(id)
+
+	return args.Get(0).(*models.Reading), args.Error(1)
+}
+
+// FindReadingsByWeightID provides mock for getting all Reading data of a Weight
+func (_m *WeightRepository) FindReadingsByWeightID(weightID uint64) (*[]models.Reading, error) {
+	args := _m.Called(weightID)
+
+	return args.Get(0).(*[]models.Reading), args.Error(1)
+}
+
+// UpdateReading provides mock for update existing Reading data based on given id
+func (_m *WeightRepository) UpdateReading(id uint64, newReading *models.Reading) (*models.Reading, error) {
+	args := _m.Called(id, newReading)
+
+	return args.Get(0).(*models.Reading), args.Error(1)
+}
+
+// DeleteReading provides mock for delete existing Reading data based on given id
+func (_m *WeightRepository) DeleteReading(id uint64) error {
+	args := _m.Called(id)
+
+	return args.Error(0)
+}
+
+// FindAllMeasurementTypes provides mock for getting all MeasurementType data
+func (_m *WeightRepository) FindAllMeasurementTypes() (*[]models.MeasurementType, error) {
+	args := _m.Called()
+
+	return args.Get(0).(*[]models.MeasurementType), args.Error(1)
+}
+
+// FindMeasurementTypeByID provides mock for getting MeasurementType data based on given id
+func (_m *WeightRepository) FindMeasurementTypeByID(id uint64) (*models.MeasurementType, error) {
+	args := _m.Called(id)
+
+	return args.Get(0).(*models.MeasurementType), args.Error(1)
+}
+
+// SaveMeasurementType provides mock for saving MeasurementType data to database
+func (_m *WeightRepository) SaveMeasurementType(mt *models.MeasurementType) (*models.MeasurementType, error) {
+	args := _m.Called(mt)
+
+	return args.Get(0).(*models.MeasurementType), args.Error(1)
+}
+
+// UpdateMeasurementType provides mock for update existing MeasurementType data based on given id
+func (_m *WeightRepository) UpdateMeasurementType(id uint64, mt *models.MeasurementType) (*models.MeasurementType, error) {
+	args := _m.Called(id, mt)
+
+	return args.Get(0).(*models.MeasurementType), args.Error(1)
+}
+
+// DeleteMeasurementType provides mock for delete existing MeasurementType data based on given id
+func (_m *WeightRepository) DeleteMeasurementType(id uint64) error {
+	args := _m.Called(id)
+
+	return args.Error(0)
+}
+
+// FindAllMeasurements provides mock for getting all Measurement data
+func (_m *WeightRepository) FindAllMeasurements() (*[]models.Measurement, error) {
+	args := _m.Called()
+
+	return args.Get(0).(*[]models.Measurement), args.Error(1)
+}
+
+// FindMeasurementByID provides mock for getting Measurement data based on given id
+func (_m *WeightRepository) FindMeasurementByID(id uint64) (*models.Measurement, error) {
+	args := _m.Called(id)
+
+	return args.Get(0).(*models.Measurement), args.Error(1)
+}
+
+// FindMeasurementByTypeAndDate provides mock for getting Measurement data of a type on given date
+func (_m *WeightRepository) FindMeasurementByTypeAndDate(typeID uint64, date string) (*models.Measurement, error) {
+	args := _m.Called(typeID, date)
+
+	if _, ok := args.Get(0).(*models.Measurement); !ok {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*models.Measurement), args.Error(1)
+}
+
+// SaveMeasurement provides mock for saving Measurement data to database
+func (_m *WeightRepository) SaveMeasurement(measurement *models.Measurement) (*models.Measurement, error) {
+	args := _m.Called(measurement)
+
+	return args.Get(0).(*models.Measurement), args.Error(1)
+}
+
+// UpdateMeasurement provides mock for update existing Measurement data based on given id
+func (_m *WeightRepository) UpdateMeasurement(id uint64, measurement *models.Measurement) (*models.Measurement, error) {
+	args := _m.Called(id, measurement)
+
+	return args.Get(0).(*models.Measurement), args.Error(1)
+}
+
+// DeleteMeasurement provides mock for delete existing Measurement data based on given id
+func (_m *WeightRepository) DeleteMeasurement(id uint64) error {
+	args := _m.Called(id)
+
+	return args.Error(0)
+}
+
+// FindProfile provides mock for getting the Profile data
+func (_m *WeightRepository) FindProfile() (*models.Profile, error) {
+	args := _m.Called()
+
+	if _, ok := args.Get(0).(*models.Profile); !ok {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*models.Profile), args.Error(1)
+}
+
+// SaveProfile provides mock for creating or updating the Profile data
+func (_m *WeightRepository) SaveProfile(p *models.Profile) (*models.Profile, error) {
+	args := _m.Called(p)
+
+	return args.Get(0).(*models.Profile), args.Error(1)
+}
+
+// FindAllTags provides mock for getting all Tag data from database
+func (_m *WeightRepository) FindAllTags() (*[]models.Tag, error) {
+	args := _m.Called()
+
+	return args.Get(0).(*[]models.Tag), args.Error(1)
+}
+
+// FindWeightTagNames provides mock for getting the tag names of every Weight
+func (_m *WeightRepository) FindWeightTagNames() (map[uint64][]string, error) {
+	args := _m.Called()
+
+	return args.Get(0).(map[uint64][]string), args.Error(1)
+}
+
+// SetWeightTags provides mock for replacing the tags of a Weight
+func (_m *WeightRepository) SetWeightTags(weightID uint64, names []string) error {
+	args := _m.Called(weightID, names)
+
+	return args.Error(0)
+}
+
+// FindDeleted provides mock for getting all Weight data in the trash
+func (_m *WeightRepository) FindDeleted() (*[]models.Weight, error) {
+	args := _m.Called()
+
+	return args.Get(0).(*[]models.Weight), args.Error(1)
+}
+
+// FindDeletedByID provides mock for getting Weight data in the trash based on given id
+func (_m *WeightRepository) FindDeletedByID(id uint64) (*models.Weight, error) {
+	args := _m.Called(id)
+
+	return args.Get(0).(*models.Weight), args.Error(1)
+}
+
+// Restore provides mock for taking Weight data out of the trash based on given id
+func (_m *WeightRepository) Restore(id uint64) error {
+	args := _m.Called(id)
+
+	return args.Error(0)
+}
+
+// Purge provides mock for permanently deleting Weight data based on given id
+func (_m *WeightRepository) Purge(id uint64) error {
+	args := _m.Called(id)
+
+	return args.Error(0)
+}
+
+// PurgeDeletedBefore provides mock for permanently deleting the Weight data
+// moved to the trash before given time
+func (_m *WeightRepository) PurgeDeletedBefore(before time.Time) (int64, error) {
+	args := _m.Called(before)
+
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// FindAllWithDeleted provides mock for getting all Weight data from
+// database, the ones in the trash included
+func (_m *WeightRepository) FindAllWithDeleted() (*[]models.Weight, error) {
+	args := _m.Called()
+
+	return args.Get(0).(*[]models.Weight), args.Error(1)
+}
+
+// RepairWeights provides mock for repairing Weight data in one transaction
+func (_m *WeightRepository) RepairWeights(repaired []models.Weight, trash []uint64) error {
+	args := _m.Called(repaired, trash)
+
+	return args.Error(0)
+}
+
+// FindIdempotencyKey provides mock for getting IdempotencyKey data based on given key
+func (_m *WeightRepository) FindIdempotencyKey(key string) (*models.IdempotencyKey, error) {
+	args := _m.Called(key)
+
+	if _, ok := args.Get(0).(*models.IdempotencyKey); !ok {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*models.IdempotencyKey), args.Error(1)
+}
+
+// SaveIdempotencyKey provides mock for saving IdempotencyKey data to database
+func (_m *WeightRepository) SaveIdempotencyKey(ik *models.IdempotencyKey) error {
+	args := _m.Called(ik)
+
+	return args.Error(0)
+}
+
+// UpdateIdempotencyKey provides mock for saving the response of IdempotencyKey data
+func (_m *WeightRepository) UpdateIdempotencyKey(ik *models.IdempotencyKey) error {
+	args := _m.Called(ik)
+
+	return args.Error(0)
+}
+
+// DeleteIdempotencyKey provides mock for deleting IdempotencyKey data based on given key
+func (_m *WeightRepository) DeleteIdempotencyKey(key string) error {
+	args := _m.Called(key)
+
+	return args.Error(0)
+}
+
+// DeleteExpiredIdempotencyKeys provides mock for deleting the expired IdempotencyKey data
+func (_m *WeightRepository) DeleteExpiredIdempotencyKeys(now time.Time) (int64, error) {
+	args := _m.Called(now)
+
+	return args.Get(0).(int64), args.Error(1)
 }
@@ -0,0 +1,103 @@
+package models
+
+import "time"
+
+// Sexes of the profile
+const (
+	SexMale   = "male"
+	SexFemale = "female"
+)
+
+// Profile is the person whose weight is tracked. Height is in centimeters.
+// There is only one profile, it is needed to calculate the BMI
+type Profile struct {
+	ID        uint64  `gorm:"primary_key;auto_increment" json:"id"`
+	Height    float64 `gorm:"not null;default:0" json:"height"`
+	BirthDate string  `gorm:"not null;default:null" json:"birth_date"`
+	Sex       string  `gorm:"not null;default:null" json:"sex"`
+}
+
+// Validate will check all validation needed for Profile model.
+// It returns ValidationErrors containing every failed field, or nil
+func (p *Profile) Validate() error {
+	errs := ValidationErrors{}
+
+	if p.Height < 50 || p.Height > 250 {
+		errs.Add("height", "Height must be between 50 and 250 cm")
+	}
+
+	if p.BirthDate == "" {
+		errs.Add("birth_date", "Required birth date")
+	} else if birthDate, err := time.Parse(DateLayout, p.BirthDate); err != nil {
+		errs.Add("birth_date", "Please fill the birth date correctly (YYYY-MM-DD)")
+	} else if birthDate.After(time.Now()) {
+		errs.Add("birth_date", "Birth date could not be in the future")
+	}
+
+	if p.Sex != SexMale && p.Sex != SexFemale {
+		errs.Add("sex", "Please choose male or female")
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// AgeOn returns the age in full years on the given date,
+// or -1 when the birth date or the date could not be parsed
+func (p *Profile) AgeOn(date string) int {
+	birthDate, err := time.Parse(DateLayout, p.BirthDate)
+	if err != nil {
+		return -1
+	}
+
+	on, err := time.Parse(DateLayout, date)
+	if err != nil {
+		return -1
+	}
+
+	age := on.Year() - birthDate.Year()
+	if on.Month() < birthDate.Month() || (on.Month() == birthDate.Month() && on.Day() < birthDate.Day()) {
+		age--
+	}
+
+	return age
+}
+
+// FindProfile will get the Profile data
+func (wr *WeightRepository) FindProfile() (*Profile, error) {
+	var profile Profile
+
+	err := wr.DB.Order("id ASC").Take(&profile).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &profile, nil
+}
+
+// SaveProfile accept Profile as parameter and save it to database. A new
+// profile is created when it has no id, otherwise all fields are updated
+func (wr *WeightRepository) SaveProfile(profile *Profile) (*Profile, error) {
+	if profile.ID == 0 {
+		err := wr.DB.Create(&profile).Error
+		if err != nil {
+			return nil, err
+		}
+
+		return profile, nil
+	}
+
+	err := wr.DB.Model(&Profile{}).Where("id = ?", profile.ID).Updates(map[string]interface{}{
+		"height":     profile.Height,
+		"birth_date": profile.BirthDate,
+		"sex":        profile.Sex,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
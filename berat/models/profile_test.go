@@ -0,0 +1,70 @@
+package models_test
+
+import (
+	"regexp"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func (s *Suite) newProfile() *models.Profile {
+	return &models.Profile{Height: 170, BirthDate: "1990-03-15", Sex: models.SexFemale}
+}
+
+func (s *Suite) Test_Profile_Model_Validate_When_Empty() {
+	err := new(models.Profile).Validate()
+	require.Equal(s.T(), models.ValidationErrors{
+		"height":     "Height must be between 50 and 250 cm",
+		"birth_date": "Required birth date",
+		"sex":        "Please choose male or female",
+	}, err)
+}
+
+func (s *Suite) Test_Profile_Model_Validate_Future_Birth_Date() {
+	profile := s.newProfile()
+	profile.BirthDate = "2999-01-01"
+
+	err := profile.Validate()
+	require.Equal(s.T(), models.ValidationErrors{"birth_date": "Birth date could not be in the future"}, err)
+}
+
+func (s *Suite) Test_Profile_Model_AgeOn() {
+	profile := s.newProfile()
+
+	require.Equal(s.T(), 29, profile.AgeOn("2020-03-14"))
+	require.Equal(s.T(), 30, profile.AgeOn("2020-03-15"))
+	require.Equal(s.T(), -1, profile.AgeOn("someday"))
+}
+
+func (s *Suite) Test_Repository_SaveProfile_Create_When_No_ID() {
+	profile := s.newProfile()
+	sqlQuery := `INSERT INTO "profiles" ("height","birth_date","sex")
+		VALUES ($1,$2,$3) RETURNING "profiles"."id"`
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(sqlQuery)).
+		WithArgs(profile.Height, profile.BirthDate, profile.Sex).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	s.mock.ExpectCommit()
+
+	res, err := s.repo.SaveProfile(profile)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), uint64(1), res.ID)
+}
+
+func (s *Suite) Test_Repository_SaveProfile_Update_When_Has_ID() {
+	profile := s.newProfile()
+	profile.ID = 1
+	sqlQuery := `UPDATE "profiles" SET "birth_date" = $1, "height" = $2, "sex" = $3 WHERE (id = $4)`
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(sqlQuery)).
+		WithArgs(profile.BirthDate, profile.Height, profile.Sex, 1).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mock.ExpectCommit()
+
+	res, err := s.repo.SaveProfile(profile)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), profile, res)
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"time"
+)
+
+// DateLayout is the format of Weight.Date
+const DateLayout = "2006-01-02"
+
+// Reading is a single timestamped scale reading. The Max, Min and
+// Difference of the Weight on the same day are derived from its readings
+type Reading struct {
+	ID       uint64    `gorm:"primary_key;auto_increment" json:"id"`
+	WeightID uint64    `gorm:"not null;index" json:"weight_id"`
+	TakenAt  time.Time `gorm:"not null" json:"taken_at"`
+	Value    int       `gorm:"not null;default:null" json:"value"`
+}
+
+// Date returns the day the reading was taken in the format of Weight.Date
+func (r *Reading) Date() string {
+	return r.TakenAt.Format(DateLayout)
+}
+
+// Validate will check all validation needed for Reading model.
+// It returns ValidationErrors containing every failed field, or nil
+func (r *Reading) Validate() error {
+	errs := ValidationErrors{}
+
+	if r.TakenAt.IsZero() {
+		errs.Add("taken_at", "Required reading time")
+	}
+
+	if r.Value < 1 {
+		errs.Add("value", "Required weight value")
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// SaveReading accept Reading as parameter and save it to database and
+// it will return saved data if success and error if failed
+func (wr *WeightRepository) SaveReading(reading *Reading) (*Reading, error) {
+	err := wr.DB.Create(&reading).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return reading, nil
+}
+
+// FindReadingByID accept id type uint64 as parameter and
+// it will get Reading data based on the id
+func (wr *WeightRepository) FindReadingByID(id uint64) (*Reading, error) {
+	var reading Reading
+
+	err := wr.DB.Where("id = ?", id).Take(&reading).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &reading, nil
+}
+
+// FindReadingsByWeightID accept id of a Weight as parameter and it will
+// get all the Reading data of that Weight ordered by the reading time
+func (wr *WeightRepository) FindReadingsByWeightID(weightID uint64) (*[]Reading, error) {
+	var readings []Reading
+
+	err := wr.DB.Where("weight_id = ?", weightID).Order("taken_at ASC").Find(&readings).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &readings, nil
+}
+
+// UpdateReading accept id type uint64 and Reading data as parameter and
+// it will update all the fields of the Reading data based on the id
+func (wr *WeightRepository) UpdateReading(id uint64, newReading *Reading) (*Reading, error) {
+	err := wr.DB.Model(&Reading{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"weight_id": newReading.WeightID,
+		"taken_at":  newReading.TakenAt,
+		"value":     newReading.Value,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return newReading, nil
+}
+
+// DeleteReading accept id type uint64 as parameter and
+// it will delete the Reading data in database based on the id
+func (wr *WeightRepository) DeleteReading(id uint64) error {
+	err := wr.DB.Where("id = ?", id).Delete(&Reading{}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package models_test
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func (s *Suite) newReading() *models.Reading {
+	return &models.Reading{
+		WeightID: 1,
+		TakenAt:  time.Date(2020, 11, 9, 7, 30, 0, 0, time.UTC),
+		Value:    50,
+	}
+}
+
+func (s *Suite) Test_Reading_Model_Date() {
+	require.Equal(s.T(), "2020-11-09", s.newReading().Date())
+}
+
+func (s *Suite) Test_Reading_Model_Validate_When_Empty() {
+	err := new(models.Reading).Validate()
+	require.Equal(s.T(), models.ValidationErrors{
+		"taken_at": "Required reading time",
+		"value":    "Required weight value",
+	}, err)
+}
+
+func (s *Suite) Test_Reading_Model_Validate_Success() {
+	err := s.newReading().Validate()
+	require.NoError(s.T(), err)
+}
+
+func (s *Suite) Test_Repository_SaveReading_Given_Valid_Reading() {
+	reading := s.newReading()
+	sqlQuery := `INSERT INTO "readings" ("weight_id","taken_at","value")
+		VALUES ($1,$2,$3) RETURNING "readings"."id"`
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectQuery(regexp.QuoteMeta(sqlQuery)).
+		WithArgs(reading.WeightID, reading.TakenAt, reading.Value).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	s.mock.ExpectCommit()
+
+	res, err := s.repo.SaveReading(reading)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), uint64(3), res.ID)
+}
+
+func (s *Suite) Test_Repository_FindReadingByID_Given_Invalid_ID() {
+	sqlQuery := `SELECT * FROM "readings" WHERE (id = $1) LIMIT 1`
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(sqlQuery)).WithArgs(3).WillReturnRows(sqlmock.NewRows(nil))
+
+	res, err := s.repo.FindReadingByID(3)
+	require.Equal(s.T(), gorm.ErrRecordNotFound, err)
+	require.Nil(s.T(), res)
+}
+
+func (s *Suite) Test_Repository_FindReadingsByWeightID() {
+	reading := s.newReading()
+	sqlQuery := `SELECT * FROM "readings" WHERE (weight_id = $1) ORDER BY taken_at ASC`
+	rows := sqlmock.
+		NewRows([]string{"id", "weight_id", "taken_at", "value"}).
+		AddRow(1, reading.WeightID, reading.TakenAt, 50).
+		AddRow(2, reading.WeightID, reading.TakenAt.Add(time.Hour), 49)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(sqlQuery)).WithArgs(reading.WeightID).WillReturnRows(rows)
+
+	res, err := s.repo.FindReadingsByWeightID(reading.WeightID)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), *res, 2)
+	require.Equal(s.T(), 49, (*res)[1].Value)
+}
+
+func (s *Suite) Test_Repository_UpdateReading_Given_Valid_ID() {
+	reading := s.newReading()
+	sqlQuery := `UPDATE "readings" SET "taken_at" = $1, "value" = $2, "weight_id" = $3 WHERE (id = $4)`
+
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(sqlQuery)).
+		WithArgs(reading.TakenAt, reading.Value, reading.WeightID, 3).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	s.mock.ExpectCommit()
+
+	res, err := s.repo.UpdateReading(3, reading)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), reading, res)
+}
+
+func (s *Suite) Test_Repository_DeleteReading_Given_Valid_ID() {
+	sqlQuery := `DELETE FROM "readings" WHERE (id = $1)`
+
+	s.mock.ExpectExec(regexp.QuoteMeta(sqlQuery)).WithArgs(3).WillReturnResult(sqlmock.NewResult(3, 1))
+
+	err := s.repo.DeleteReading(3)
+	require.NoError(s.T(), err)
+}
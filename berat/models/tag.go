@@ -0,0 +1,108 @@
+package models
+
+import (
+	"strings"
+)
+
+// Tag is a label put on weight data to give the day a context,
+// such as "ate out" or "sick". A weight data could have many tags
+type Tag struct {
+	ID   uint64 `gorm:"primary_key;auto_increment" json:"id"`
+	Name string `gorm:"not null;unique;default:null" json:"name"`
+}
+
+// WeightTag links a Weight with one of its Tags
+type WeightTag struct {
+	WeightID uint64 `gorm:"primary_key;auto_increment:false"`
+	TagID    uint64 `gorm:"primary_key;auto_increment:false"`
+}
+
+// MaxTagLength is the longest tag name allowed
+const MaxTagLength = 30
+
+// ParseTags splits the comma separated tag names. The names are trimmed
+// and lower cased, and the empty and repeated names are left out.
+// It returns nil when there is no name
+func ParseTags(text string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	for _, name := range strings.Split(text, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+
+		seen[name] = true
+		tags = append(tags, name)
+	}
+
+	return tags
+}
+
+// FindAllTags will get all Tag data ordered by name
+func (wr *WeightRepository) FindAllTags() (*[]Tag, error) {
+	var tags []Tag
+
+	err := wr.DB.Order("name ASC").Find(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &tags, nil
+}
+
+// FindWeightTagNames will get the tag names of every tagged Weight,
+// indexed by the weight id and ordered by name
+func (wr *WeightRepository) FindWeightTagNames() (map[uint64][]string, error) {
+	rows, err := wr.DB.Table("weight_tags").
+		Select("weight_tags.weight_id, tags.name").
+		Joins("JOIN tags ON tags.id = weight_tags.tag_id").
+		Order("tags.name ASC").
+		Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[uint64][]string)
+	for rows.Next() {
+		var weightID uint64
+		var name string
+		if err := rows.Scan(&weightID, &name); err != nil {
+			return nil, err
+		}
+
+		names[weightID] = append(names[weightID], name)
+	}
+
+	return names, rows.Err()
+}
+
+// SetWeightTags replaces the tags of the Weight with the given names in one
+// transaction. The tags that do not exist yet are created
+func (wr *WeightRepository) SetWeightTags(weightID uint64, names []string) error {
+	tx := wr.begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if err := tx.Where("weight_id = ?", weightID).Delete(&WeightTag{}).Error; err != nil {
+		wr.rollback(tx)
+		return err
+	}
+
+	for _, name := range names {
+		var tag Tag
+		if err := tx.Where(Tag{Name: name}).FirstOrCreate(&tag).Error; err != nil {
+			wr.rollback(tx)
+			return err
+		}
+
+		if err := tx.Create(&WeightTag{WeightID: weightID, TagID: tag.ID}).Error; err != nil {
+			wr.rollback(tx)
+			return err
+		}
+	}
+
+	return wr.commit(tx)
+}
@@ -0,0 +1,59 @@
+package models_test
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func (s *Suite) Test_ParseTags_Trim_Lower_And_Dedupe() {
+	require.Equal(s.T(), []string{"ate out", "sick"}, models.ParseTags(" Ate Out,sick,, ate out "))
+	require.Nil(s.T(), models.ParseTags(" , "))
+}
+
+func (s *Suite) Test_Weight_Model_Validate_Notes_And_Tags_Length() {
+	s.weight.Notes = strings.Repeat("a", models.MaxNotesLength+1)
+	s.weight.Tags = []string{strings.Repeat("b", models.MaxTagLength+1)}
+
+	err := s.weight.Validate()
+	require.Equal(s.T(), models.ValidationErrors{
+		"notes": "Notes could not be longer than 500 characters",
+		"tags":  `Tag "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb" is longer than 30 characters`,
+	}, err)
+}
+
+func (s *Suite) Test_Repository_FindWeightTagNames() {
+	sqlQuery := `SELECT weight_tags.weight_id, tags.name FROM "weight_tags"
+		JOIN tags ON tags.id = weight_tags.tag_id ORDER BY tags.name ASC`
+	rows := sqlmock.
+		NewRows([]string{"weight_id", "name"}).
+		AddRow(1, "ate out").
+		AddRow(2, "ate out").
+		AddRow(1, "sick")
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(sqlQuery)).WillReturnRows(rows)
+
+	res, err := s.repo.FindWeightTagNames()
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), map[uint64][]string{1: {"ate out", "sick"}, 2: {"ate out"}}, res)
+}
+
+func (s *Suite) Test_Repository_SetWeightTags_Replace_In_Transaction() {
+	s.mock.ExpectBegin()
+	s.mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "weight_tags" WHERE (weight_id = $1)`)).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "tags" WHERE ("tags"."name" = $1)`)).
+		WithArgs("sick").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(4, "sick"))
+	s.mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "weight_tags" ("weight_id","tag_id") VALUES ($1,$2)`)).
+		WithArgs(1, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"weight_id"}).AddRow(1))
+	s.mock.ExpectCommit()
+
+	err := s.repo.SetWeightTags(1, []string{"sick"})
+	require.NoError(s.T(), err)
+}
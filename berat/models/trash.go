@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// FindDeleted will get all Weight data in the trash,
+// the most recently deleted first
+func (wr *WeightRepository) FindDeleted() (*[]Weight, error) {
+	var weights []Weight
+
+	err := wr.DB.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&weights).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &weights, nil
+}
+
+// FindDeletedByID accept id type uint64 as parameter and
+// it will get the Weight data in the trash based on the id
+func (wr *WeightRepository) FindDeletedByID(id uint64) (*Weight, error) {
+	var weight Weight
+
+	err := wr.DB.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).Take(&weight).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &weight, nil
+}
+
+// Restore accept id type uint64 as parameter and
+// it will take the Weight data out of the trash
+func (wr *WeightRepository) Restore(id uint64) error {
+	err := wr.DB.Unscoped().Model(&Weight{}).Where("id = ?", id).Update("deleted_at", nil).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Purge accept id type uint64 as parameter and it will permanently
+// delete the Weight data, its readings are deleted by the database
+func (wr *WeightRepository) Purge(id uint64) error {
+	err := wr.DB.Unscoped().Where("id = ?", id).Delete(&Weight{}).Error
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// PurgeDeletedBefore permanently deletes the Weight data that were moved
+// to the trash before the given time and returns how many were deleted
+func (wr *WeightRepository) PurgeDeletedBefore(before time.Time) (int64, error) {
+	res := wr.DB.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Delete(&Weight{})
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
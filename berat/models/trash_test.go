@@ -0,0 +1,48 @@
+package models_test
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func (s *Suite) Test_Repository_FindDeleted() {
+	deletedAt := time.Date(2020, 11, 12, 8, 0, 0, 0, time.UTC)
+	sqlQuery := `SELECT * FROM "weights" WHERE (deleted_at IS NOT NULL) ORDER BY deleted_at DESC`
+	rows := sqlmock.
+		NewRows([]string{"id", "date", "max", "min", "deleted_at"}).
+		AddRow(s.weight.ID, s.weight.Date, s.weight.Max, s.weight.Min, deletedAt)
+
+	s.mock.ExpectQuery(regexp.QuoteMeta(sqlQuery)).WillReturnRows(rows)
+
+	res, err := s.repo.FindDeleted()
+	require.NoError(s.T(), err)
+	require.Len(s.T(), *res, 1)
+	require.Equal(s.T(), deletedAt, *(*res)[0].DeletedAt)
+}
+
+func (s *Suite) Test_Repository_Restore() {
+	sqlQuery := `UPDATE "weights" SET "deleted_at" = $1 WHERE (id = $2)`
+
+	s.mock.ExpectExec(regexp.QuoteMeta(sqlQuery)).
+		WithArgs(nil, s.weight.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := s.repo.Restore(s.weight.ID)
+	require.NoError(s.T(), err)
+}
+
+func (s *Suite) Test_Repository_PurgeDeletedBefore() {
+	before := time.Date(2020, 10, 13, 0, 0, 0, 0, time.UTC)
+	sqlQuery := `DELETE FROM "weights" WHERE (deleted_at IS NOT NULL AND deleted_at < $1)`
+
+	s.mock.ExpectExec(regexp.QuoteMeta(sqlQuery)).
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	purged, err := s.repo.PurgeDeletedBefore(before)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), int64(3), purged)
+}
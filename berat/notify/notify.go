@@ -0,0 +1,61 @@
+// Package notify sends the reminders of berat. Notifier is the extension
+// point, LogNotifier and FileNotifier are the implementations shipped with
+// it, other channels only have to implement Notify
+package notify
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Notifier sends a message to the user
+type Notifier interface {
+	Notify(message string) error
+}
+
+// LogNotifier writes the messages to a logger,
+// the standard logger when Logger is nil
+type LogNotifier struct {
+	Logger *log.Logger
+}
+
+// Notify writes the message to the logger
+func (ln *LogNotifier) Notify(message string) error {
+	if ln.Logger == nil {
+		log.Println(message)
+		return nil
+	}
+
+	ln.Logger.Println(message)
+
+	return nil
+}
+
+// FileNotifier appends the messages to the file at Path, one line
+// each prefixed by the time it was sent
+type FileNotifier struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// Notify appends the message to the file, creating it when needed
+func (fn *FileNotifier) Notify(message string) error {
+	fn.mu.Lock()
+	defer fn.mu.Unlock()
+
+	f, err := os.OpenFile(fn.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(f, "%s %s\n", time.Now().Format(time.RFC3339), message); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,36 @@
+package notify_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/notify"
+)
+
+func TestFileNotifier_Append_Messages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "berat")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	notifier := &notify.FileNotifier{Path: filepath.Join(dir, "reminders.log")}
+	require.NoError(t, notifier.Notify("first"))
+	require.NoError(t, notifier.Notify("second"))
+
+	content, err := ioutil.ReadFile(notifier.Path)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+	require.True(t, strings.HasSuffix(lines[0], " first"))
+	require.True(t, strings.HasSuffix(lines[1], " second"))
+}
+
+func TestFileNotifier_When_Directory_Does_Not_Exist(t *testing.T) {
+	notifier := &notify.FileNotifier{Path: filepath.Join(os.TempDir(), "berat-missing", "reminders.log")}
+	require.Error(t, notifier.Notify("first"))
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/erizkiatama/berat/models"
+)
+
+// Settings of the anomaly detection. The daily change of a weight data is
+// compared against the daily changes of the AnomalyWindow weight data
+// before it, and it is an anomaly when its modified z-score is above
+// AnomalyThreshold. There is no detection until MinAnomalyHistory changes
+// are known. MinAnomalyMAD keeps a very steady history from turning every
+// small change into an anomaly
+const (
+	AnomalyWindow     = 30
+	AnomalyThreshold  = 3.5
+	MinAnomalyHistory = 5
+	MinAnomalyMAD     = 0.5
+)
+
+// Anomaly is a weight data far from the recent trend. Change is the change
+// per day of the middle of its Max and Min from the weight data before it,
+// Expected is the median of the recent daily changes
+type Anomaly struct {
+	Score    float64 `json:"score"`
+	Change   float64 `json:"change"`
+	Expected float64 `json:"expected"`
+	Message  string  `json:"message"`
+}
+
+// datedValue is the middle of the Max and Min of a weight data at its date
+type datedValue struct {
+	date  time.Time
+	value float64
+}
+
+// DetectAnomaly returns the Anomaly when the weight is far from the trend of
+// the history, or nil. Only the weight data before the date of the weight
+// are used, the ones whose date could not be read are ignored
+func DetectAnomaly(history []models.Weight, weight models.Weight) *Anomaly {
+	current, ok := valueOf(weight)
+	if !ok {
+		return nil
+	}
+
+	var before []datedValue
+	for _, w := range history {
+		if v, ok := valueOf(w); ok && v.date.Before(current.date) {
+			before = append(before, v)
+		}
+	}
+
+	sort.Slice(before, func(i, j int) bool { return before[i].date.Before(before[j].date) })
+	if len(before) > AnomalyWindow+1 {
+		before = before[len(before)-AnomalyWindow-1:]
+	}
+
+	return detect(before, current)
+}
+
+// FindOutliers returns the anomalies of the weight data by their id. Every
+// weight data is compared to the ones before it, leaving out the outliers
+// already found, so the day after a typo is not flagged as well
+func FindOutliers(weights []models.Weight) map[uint64]*Anomaly {
+	outliers := make(map[uint64]*Anomaly)
+
+	sorted := make([]models.Weight, len(weights))
+	copy(sorted, weights)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date < sorted[j].Date })
+
+	var clean []datedValue
+	for _, weight := range sorted {
+		current, ok := valueOf(weight)
+		if !ok {
+			continue
+		}
+
+		window := clean
+		if len(window) > AnomalyWindow+1 {
+			window = window[len(window)-AnomalyWindow-1:]
+		}
+
+		if anomaly := detect(window, current); anomaly != nil {
+			outliers[weight.ID] = anomaly
+			continue
+		}
+
+		clean = append(clean, current)
+	}
+
+	return outliers
+}
+
+// ExcludeOutliers returns the weight data without the outliers
+func ExcludeOutliers(weights []models.Weight, outliers map[uint64]*Anomaly) []models.Weight {
+	kept := make([]models.Weight, 0, len(weights))
+	for _, weight := range weights {
+		if _, ok := outliers[weight.ID]; !ok {
+			kept = append(kept, weight)
+		}
+	}
+
+	return kept
+}
+
+// CheckAnomaly compares the weight about to be saved with the trend of the
+// other weight data, leaving out their outliers. id is the weight data
+// being edited or zero
+func (ws *WeightService) CheckAnomaly(id uint64, weight *models.Weight) (*Anomaly, error) {
+	weights, err := ws.List()
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]models.Weight, 0, len(*weights))
+	for _, w := range *weights {
+		if w.ID != id {
+			history = append(history, w)
+		}
+	}
+
+	return DetectAnomaly(ExcludeOutliers(history, FindOutliers(history)), *weight), nil
+}
+
+// detect compares the change from the last value of the window to the
+// current value against the changes within the window
+func detect(window []datedValue, current datedValue) *Anomaly {
+	if len(window) < MinAnomalyHistory+1 {
+		return nil
+	}
+
+	changes := make([]float64, 0, len(window)-1)
+	for i := 1; i < len(window); i++ {
+		changes = append(changes, dailyChange(window[i-1], window[i]))
+	}
+
+	last := window[len(window)-1]
+	change := dailyChange(last, current)
+	expected := median(changes)
+
+	deviations := make([]float64, len(changes))
+	for i, c := range changes {
+		deviations[i] = math.Abs(c - expected)
+	}
+	mad := math.Max(median(deviations), MinAnomalyMAD)
+
+	score := 0.6745 * (change - expected) / mad
+	if math.Abs(score) <= AnomalyThreshold {
+		return nil
+	}
+
+	return &Anomaly{
+		Score:    score,
+		Change:   change,
+		Expected: expected,
+		Message: fmt.Sprintf("Changed %+.1f per day since %s while it usually changes %+.1f per day, is it a typo?",
+			change, last.date.Format(models.DateLayout), expected),
+	}
+}
+
+func valueOf(weight models.Weight) (datedValue, bool) {
+	date, err := time.Parse(models.DateLayout, weight.Date)
+	if err != nil {
+		return datedValue{}, false
+	}
+
+	return datedValue{date: date, value: float64(weight.Max+weight.Min) / 2}, true
+}
+
+func dailyChange(from, to datedValue) float64 {
+	days := to.date.Sub(from.date).Hours() / 24
+	if days < 1 {
+		days = 1
+	}
+
+	return (to.value - from.value) / days
+}
+
+func median(values []float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+
+	return sorted[middle]
+}
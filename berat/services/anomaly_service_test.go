@@ -0,0 +1,76 @@
+package services_test
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+// steadyWeights returns days of weight data from 2020-11-01 slowly going
+// down, the ids are the days of the month
+func steadyWeights(days int) []models.Weight {
+	weights := make([]models.Weight, days)
+	for i := range weights {
+		max := 76 - i/3
+		weights[i] = models.Weight{ID: uint64(i + 1), Date: fmt.Sprintf("2020-11-%02d", i+1), Max: max, Min: max - 2}
+	}
+
+	return weights
+}
+
+func (s *Suite) Test_DetectAnomaly_When_Typo() {
+	anomaly := services.DetectAnomaly(steadyWeights(10), models.Weight{Date: "2020-11-11", Max: 750, Min: 72})
+	require.NotNil(s.T(), anomaly)
+	require.True(s.T(), anomaly.Score > services.AnomalyThreshold)
+	require.Contains(s.T(), anomaly.Message, "since 2020-11-10")
+}
+
+func (s *Suite) Test_DetectAnomaly_When_Following_Trend() {
+	anomaly := services.DetectAnomaly(steadyWeights(10), models.Weight{Date: "2020-11-11", Max: 73, Min: 71})
+	require.Nil(s.T(), anomaly)
+}
+
+func (s *Suite) Test_DetectAnomaly_When_History_Is_Short() {
+	anomaly := services.DetectAnomaly(steadyWeights(3), models.Weight{Date: "2020-11-04", Max: 750, Min: 72})
+	require.Nil(s.T(), anomaly)
+}
+
+func (s *Suite) Test_FindOutliers_Flag_Only_The_Typo() {
+	weights := steadyWeights(12)
+	weights[9].Max = 750
+
+	outliers := services.FindOutliers(weights)
+	require.Len(s.T(), outliers, 1)
+	require.Contains(s.T(), outliers, uint64(10))
+}
+
+func (s *Suite) Test_CheckAnomaly_Ignore_The_Weight_Being_Edited() {
+	weights := steadyWeights(10)
+	weights[9].Max = 750
+	s.repo.On("FindAll").Return(&weights, nil).Once()
+
+	anomaly, err := s.service.CheckAnomaly(10, &models.Weight{Date: "2020-11-11", Max: 73, Min: 71})
+	require.NoError(s.T(), err)
+	require.Nil(s.T(), anomaly)
+}
+
+func (s *Suite) Test_Stats_Exclude_Outliers() {
+	s.service.ExcludeOutliers = true
+	defer func() { s.service.ExcludeOutliers = false }()
+
+	weights := steadyWeights(10)
+	weights[9].Max = 750
+	s.repo.On("FindAll").Return(&weights, nil).Once()
+	s.repo.On("FindAllMeasurementTypes").Return(&[]models.MeasurementType{}, nil).Once()
+	s.repo.On("FindAllMeasurements").Return(&[]models.Measurement{}, nil).Once()
+	s.repo.On("FindProfile").Return(nil, gorm.ErrRecordNotFound).Once()
+
+	stats, err := s.service.Stats()
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 9, stats.Count)
+	require.Equal(s.T(), 1, stats.ExcludedOutliers)
+}
@@ -0,0 +1,526 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/jinzhu/gorm"
+)
+
+// ArchiveVersion is the version of the backup archive format written by
+// WriteArchive. ReadArchive only reads archives of this version
+const ArchiveVersion = 1
+
+var (
+	// ErrArchiveVersion is returned when the archive was written
+	// in a format version that could not be read
+	ErrArchiveVersion = errors.New("Unsupported backup archive version")
+
+	// ErrArchiveChecksum is returned when the data of the archive
+	// does not match its checksum, it was changed or corrupted
+	ErrArchiveChecksum = errors.New("Backup archive checksum does not match, the archive is corrupted")
+
+	// ErrRestoreConflict is returned when the archive conflicts with
+	// the data in the database under RestoreFail policy
+	ErrRestoreConflict = errors.New("Backup archive conflicts with the data in the database")
+)
+
+// Policies of a restore for the archive data conflicting with the data in
+// the database, which is data with the same date or name but other values.
+// RestoreFail restores nothing, RestoreSkip keeps the data in the database
+// and RestoreOverwrite replaces it with the archive data
+const (
+	RestoreFail      = "fail"
+	RestoreSkip      = "skip"
+	RestoreOverwrite = "overwrite"
+)
+
+// ArchiveData is all the data of the application. The weight data in the
+// trash are included with their tags, the ids only link the data together
+type ArchiveData struct {
+	Weights          []models.Weight          `json:"weights"`
+	Readings         []models.Reading         `json:"readings"`
+	MeasurementTypes []models.MeasurementType `json:"measurement_types"`
+	Measurements     []models.Measurement     `json:"measurements"`
+	Profile          *models.Profile          `json:"profile"`
+}
+
+// archive is the file written by WriteArchive. Checksum is the SHA-256
+// of the compacted JSON of Data, so the archive could be reformatted
+type archive struct {
+	Version   int             `json:"version"`
+	CreatedAt time.Time       `json:"created_at"`
+	Checksum  string          `json:"checksum"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// RestoreCount is how the archive data of one kind was restored
+type RestoreCount struct {
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Skipped   int `json:"skipped"`
+}
+
+// RestoreReport is the outcome of a restore. Conflicts lists the archive
+// data conflicting with the database, whatever the policy did with them
+type RestoreReport struct {
+	Policy           string       `json:"policy"`
+	Verify           bool         `json:"verify"`
+	Weights          RestoreCount `json:"weights"`
+	Readings         RestoreCount `json:"readings"`
+	MeasurementTypes RestoreCount `json:"measurement_types"`
+	Measurements     RestoreCount `json:"measurements"`
+	Profile          RestoreCount `json:"profile"`
+	Conflicts        []string     `json:"conflicts"`
+}
+
+// Actions planned for each archive data by a restore
+const (
+	restoreCreate = iota
+	restoreUpdate
+	restoreUnchanged
+	restoreSkip
+)
+
+// restoreStep is the planned action of one archive data, with the id
+// of the data in the database it is restored to
+type restoreStep struct {
+	action int
+	id     uint64
+}
+
+func (rc *RestoreCount) add(action int) {
+	switch action {
+	case restoreCreate:
+		rc.Created++
+	case restoreUpdate:
+		rc.Updated++
+	case restoreUnchanged:
+		rc.Unchanged++
+	case restoreSkip:
+		rc.Skipped++
+	}
+}
+
+// Backup collects all the data of the application into an ArchiveData
+func (ws *WeightService) Backup() (*ArchiveData, error) {
+	active, err := ws.List()
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := ws.Trash()
+	if err != nil {
+		return nil, err
+	}
+
+	data := &ArchiveData{Weights: append(append([]models.Weight{}, *active...), *deleted...)}
+	if err := ws.LoadTags(data.Weights); err != nil {
+		return nil, err
+	}
+
+	data.Readings = []models.Reading{}
+	for _, weight := range data.Weights {
+		readings, err := ws.WeightRepo.FindReadingsByWeightID(weight.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		data.Readings = append(data.Readings, *readings...)
+	}
+
+	types, err := ws.MeasurementTypes()
+	if err != nil {
+		return nil, err
+	}
+	data.MeasurementTypes = *types
+
+	measurements, err := ws.Measurements()
+	if err != nil {
+		return nil, err
+	}
+	data.Measurements = *measurements
+
+	data.Profile, err = ws.optionalProfile()
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// WriteArchive writes the data as a versioned and checksummed JSON archive
+func WriteArchive(w io.Writer, data *ArchiveData, now time.Time) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(archive{
+		Version:   ArchiveVersion,
+		CreatedAt: now,
+		Checksum:  checksum(raw),
+		Data:      raw,
+	})
+}
+
+// ReadArchive reads the archive written by WriteArchive. It returns
+// ErrArchiveVersion or ErrArchiveChecksum when it could not be trusted
+func ReadArchive(r io.Reader) (*ArchiveData, error) {
+	var a archive
+	if err := json.NewDecoder(r).Decode(&a); err != nil {
+		return nil, fmt.Errorf("Invalid backup archive: %s", err)
+	}
+
+	if a.Version != ArchiveVersion {
+		return nil, ErrArchiveVersion
+	}
+
+	compact := new(bytes.Buffer)
+	if err := json.Compact(compact, a.Data); err != nil {
+		return nil, fmt.Errorf("Invalid backup archive: %s", err)
+	}
+
+	if checksum(compact.Bytes()) != a.Checksum {
+		return nil, ErrArchiveChecksum
+	}
+
+	data := new(ArchiveData)
+	if err := json.Unmarshal(a.Data, data); err != nil {
+		return nil, fmt.Errorf("Invalid backup archive: %s", err)
+	}
+
+	return data, nil
+}
+
+// RestoreArchive imports the archive data into the repository. The data
+// is matched with the database by its date or name, the same data is left
+// unchanged and the conflicting data is handled by the policy. Nothing is
+// written when verify is true or when there is a conflict under
+// RestoreFail, in that case ErrRestoreConflict is returned with the report.
+// Everything is written in one transaction, so a failed write leaves the
+// database as it was
+func (ws *WeightService) RestoreArchive(data *ArchiveData, policy string, verify bool) (*RestoreReport, error) {
+	if policy == "" {
+		policy = RestoreFail
+	}
+
+	if policy != RestoreFail && policy != RestoreSkip && policy != RestoreOverwrite {
+		return nil, models.ValidationErrors{"policy": fmt.Sprintf("Please choose %s, %s or %s", RestoreFail, RestoreSkip, RestoreOverwrite)}
+	}
+
+	report := &RestoreReport{Policy: policy, Verify: verify, Conflicts: []string{}}
+	plan, err := ws.planRestore(data, report)
+	if err != nil {
+		return nil, err
+	}
+
+	if policy == RestoreFail && len(report.Conflicts) > 0 {
+		return report, ErrRestoreConflict
+	}
+
+	if verify {
+		return report, nil
+	}
+
+	err = ws.transaction(func(tx *WeightService) error {
+		return tx.applyRestore(data, plan)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// restorePlan holds the planned step of every archive data by its index
+type restorePlan struct {
+	types        []restoreStep
+	weights      []restoreStep
+	measurements []restoreStep
+	profile      restoreStep
+}
+
+// conflict records the conflicting data and returns the action of the policy
+func (report *RestoreReport) conflict(description string, id uint64) restoreStep {
+	report.Conflicts = append(report.Conflicts, description)
+
+	if report.Policy == RestoreOverwrite {
+		return restoreStep{restoreUpdate, id}
+	}
+
+	return restoreStep{restoreSkip, id}
+}
+
+func (ws *WeightService) planRestore(data *ArchiveData, report *RestoreReport) (*restorePlan, error) {
+	plan := new(restorePlan)
+
+	types, err := ws.MeasurementTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	typeByName := make(map[string]models.MeasurementType, len(*types))
+	for _, mt := range *types {
+		typeByName[mt.Name] = mt
+	}
+
+	typeSteps := make(map[uint64]restoreStep, len(data.MeasurementTypes))
+	for _, mt := range data.MeasurementTypes {
+		step := restoreStep{action: restoreCreate}
+		if existing, ok := typeByName[mt.Name]; ok {
+			step = restoreStep{restoreUnchanged, existing.ID}
+			if existing.Unit != mt.Unit || existing.MinValue != mt.MinValue || existing.MaxValue != mt.MaxValue {
+				step = report.conflict("measurement type "+mt.Name, existing.ID)
+			}
+		}
+
+		report.MeasurementTypes.add(step.action)
+		plan.types = append(plan.types, step)
+		typeSteps[mt.ID] = step
+	}
+
+	deleted, err := ws.Trash()
+	if err != nil {
+		return nil, err
+	}
+
+	tagNames, err := ws.WeightRepo.FindWeightTagNames()
+	if err != nil {
+		return nil, err
+	}
+
+	readingsOf := make(map[uint64]int)
+	for _, reading := range data.Readings {
+		readingsOf[reading.WeightID]++
+	}
+
+	for _, weight := range data.Weights {
+		step, err := ws.planWeight(weight, *deleted, tagNames, report)
+		if err != nil {
+			return nil, err
+		}
+
+		report.Weights.add(step.action)
+		for i := 0; i < readingsOf[weight.ID]; i++ {
+			report.Readings.add(step.action)
+		}
+		plan.weights = append(plan.weights, step)
+	}
+
+	for _, measurement := range data.Measurements {
+		step := restoreStep{action: restoreCreate}
+		typeStep, ok := typeSteps[measurement.TypeID]
+		if !ok {
+			return nil, fmt.Errorf("Invalid backup archive: measurement %d has no type", measurement.ID)
+		}
+
+		if typeStep.action != restoreCreate {
+			existing, err := ws.WeightRepo.FindMeasurementByTypeAndDate(typeStep.id, measurement.Date)
+			if err != nil && err != gorm.ErrRecordNotFound {
+				return nil, err
+			}
+
+			if err == nil {
+				step = restoreStep{restoreUnchanged, existing.ID}
+				if existing.Value != measurement.Value {
+					step = report.conflict(fmt.Sprintf("measurement of type %d on %s", typeStep.id, measurement.Date), existing.ID)
+				}
+			}
+		}
+
+		report.Measurements.add(step.action)
+		plan.measurements = append(plan.measurements, step)
+	}
+
+	if data.Profile != nil {
+		existing, err := ws.optionalProfile()
+		if err != nil {
+			return nil, err
+		}
+
+		plan.profile = restoreStep{action: restoreCreate}
+		if existing != nil {
+			plan.profile = restoreStep{restoreUnchanged, existing.ID}
+			if existing.Height != data.Profile.Height || existing.BirthDate != data.Profile.BirthDate || existing.Sex != data.Profile.Sex {
+				plan.profile = report.conflict("profile", existing.ID)
+			}
+		}
+
+		report.Profile.add(plan.profile.action)
+	}
+
+	return plan, nil
+}
+
+// planWeight matches an active weight data by its date and a deleted one
+// by its date and deletion time, deleted weight data never conflict
+func (ws *WeightService) planWeight(weight models.Weight, deleted []models.Weight, tagNames map[uint64][]string, report *RestoreReport) (restoreStep, error) {
+	if weight.DeletedAt != nil {
+		for _, existing := range deleted {
+			if existing.Date == weight.Date && existing.DeletedAt != nil && existing.DeletedAt.Equal(*weight.DeletedAt) {
+				return restoreStep{restoreUnchanged, existing.ID}, nil
+			}
+		}
+
+		return restoreStep{action: restoreCreate}, nil
+	}
+
+	existing, err := ws.WeightRepo.FindByDate(weight.Date)
+	if err == gorm.ErrRecordNotFound {
+		return restoreStep{action: restoreCreate}, nil
+	}
+
+	if err != nil {
+		return restoreStep{}, err
+	}
+
+	if existing.Max == weight.Max && existing.Min == weight.Min && existing.Notes == weight.Notes &&
+		sameTags(tagNames[existing.ID], weight.Tags) {
+		return restoreStep{restoreUnchanged, existing.ID}, nil
+	}
+
+	return report.conflict("weight "+weight.Date, existing.ID), nil
+}
+
+// applyRestore writes the planned steps, ws must be on the repository
+// of the transaction of RestoreArchive
+func (ws *WeightService) applyRestore(data *ArchiveData, plan *restorePlan) error {
+	typeIDs := make(map[uint64]uint64, len(data.MeasurementTypes))
+	for i, mt := range data.MeasurementTypes {
+		step := plan.types[i]
+		archiveID := mt.ID
+		mt.ID = 0
+
+		switch step.action {
+		case restoreCreate:
+			newType, err := ws.WeightRepo.SaveMeasurementType(&mt)
+			if err != nil {
+				return err
+			}
+			step.id = newType.ID
+		case restoreUpdate:
+			if _, err := ws.WeightRepo.UpdateMeasurementType(step.id, &mt); err != nil {
+				return err
+			}
+		}
+
+		typeIDs[archiveID] = step.id
+	}
+
+	weightSteps := make(map[uint64]restoreStep, len(data.Weights))
+	for i, weight := range data.Weights {
+		step := plan.weights[i]
+		archiveID := weight.ID
+		weight.ID = 0
+		weight.CalculateDifference()
+
+		switch step.action {
+		case restoreCreate:
+			newWeight, err := ws.WeightRepo.Save(&weight)
+			if err != nil {
+				return err
+			}
+			step.id = newWeight.ID
+		case restoreUpdate:
+			if _, err := ws.WeightRepo.Update(step.id, &weight); err != nil {
+				return err
+			}
+
+			if err := ws.deleteReadings(step.id); err != nil {
+				return err
+			}
+		}
+
+		if step.action == restoreCreate || step.action == restoreUpdate {
+			if err := ws.WeightRepo.SetWeightTags(step.id, weight.Tags); err != nil {
+				return err
+			}
+		}
+
+		weightSteps[archiveID] = step
+	}
+
+	for _, reading := range data.Readings {
+		step := weightSteps[reading.WeightID]
+		if step.action != restoreCreate && step.action != restoreUpdate {
+			continue
+		}
+
+		reading.ID = 0
+		reading.WeightID = step.id
+		if _, err := ws.WeightRepo.SaveReading(&reading); err != nil {
+			return err
+		}
+	}
+
+	for i, measurement := range data.Measurements {
+		step := plan.measurements[i]
+		measurement.ID = 0
+		measurement.TypeID = typeIDs[measurement.TypeID]
+
+		switch step.action {
+		case restoreCreate:
+			if _, err := ws.WeightRepo.SaveMeasurement(&measurement); err != nil {
+				return err
+			}
+		case restoreUpdate:
+			if _, err := ws.WeightRepo.UpdateMeasurement(step.id, &measurement); err != nil {
+				return err
+			}
+		}
+	}
+
+	if data.Profile != nil && (plan.profile.action == restoreCreate || plan.profile.action == restoreUpdate) {
+		profile := *data.Profile
+		profile.ID = plan.profile.id
+		if _, err := ws.WeightRepo.SaveProfile(&profile); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteReadings removes the readings of the weight data without deriving
+// it again, as its values are replaced by the restore
+func (ws *WeightService) deleteReadings(weightID uint64) error {
+	readings, err := ws.WeightRepo.FindReadingsByWeightID(weightID)
+	if err != nil {
+		return err
+	}
+
+	for _, reading := range *readings {
+		if err := ws.WeightRepo.DeleteReading(reading.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func checksum(raw []byte) string {
+	sum := sha256.Sum256(raw)
+
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func sameTags(a, b []string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,155 @@
+package services_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+func archiveData() *services.ArchiveData {
+	deletedAt := time.Date(2020, 11, 11, 8, 0, 0, 0, time.UTC)
+
+	return &services.ArchiveData{
+		Weights: []models.Weight{
+			{ID: 1, Date: "2020-11-09", Max: 50, Min: 48, Difference: 2, Tags: []string{"ate out"}},
+			{ID: 2, Date: "2020-11-10", Max: 51, Min: 49, Difference: 2, DeletedAt: &deletedAt},
+		},
+		Readings:         []models.Reading{{ID: 7, WeightID: 1, TakenAt: deletedAt, Value: 50}},
+		MeasurementTypes: []models.MeasurementType{{ID: 3, Name: "waist", Unit: "cm", MinValue: 40, MaxValue: 200}},
+		Measurements:     []models.Measurement{{ID: 4, TypeID: 3, Date: "2020-11-09", Value: 80}},
+		Profile:          &models.Profile{ID: 1, Height: 170, BirthDate: "1990-01-01", Sex: "female"},
+	}
+}
+
+func (s *Suite) Test_Archive_Read_What_Was_Written() {
+	var buf bytes.Buffer
+	require.NoError(s.T(), services.WriteArchive(&buf, archiveData(), time.Now()))
+
+	data, err := services.ReadArchive(&buf)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), archiveData().Weights[0], data.Weights[0])
+	require.Equal(s.T(), archiveData().Profile, data.Profile)
+}
+
+func (s *Suite) Test_Archive_When_Data_Is_Changed() {
+	var buf bytes.Buffer
+	require.NoError(s.T(), services.WriteArchive(&buf, archiveData(), time.Now()))
+
+	changed := strings.Replace(buf.String(), `"max": 50`, `"max": 55`, 1)
+	_, err := services.ReadArchive(strings.NewReader(changed))
+	require.Equal(s.T(), services.ErrArchiveChecksum, err)
+}
+
+func (s *Suite) Test_Archive_When_Version_Is_Unknown() {
+	_, err := services.ReadArchive(strings.NewReader(`{"version": 99, "data": {}}`))
+	require.Equal(s.T(), services.ErrArchiveVersion, err)
+}
+
+func (s *Suite) Test_RestoreArchive_Into_Database_Having_Only_The_Types() {
+	data := archiveData()
+	deleted := data.Weights[1]
+	deleted.ID = 0
+	waist := data.MeasurementTypes[0]
+	waist.ID = 13
+	s.repo.On("FindAllMeasurementTypes").Return(&[]models.MeasurementType{waist}, nil).Once()
+	s.repo.On("FindDeleted").Return(&[]models.Weight{}, nil).Once()
+	s.repo.On("FindWeightTagNames").Return(map[uint64][]string{}, nil).Once()
+	s.repo.On("FindByDate", "2020-11-09").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("FindMeasurementByTypeAndDate", uint64(13), "2020-11-09").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("FindProfile").Return(nil, gorm.ErrRecordNotFound).Once()
+
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("Save", &models.Weight{Date: "2020-11-09", Max: 50, Min: 48, Difference: 2, Tags: []string{"ate out"}}).
+		Return(&models.Weight{ID: 11}, nil).Once()
+	s.repo.On("SetWeightTags", uint64(11), []string{"ate out"}).Return(nil).Once()
+	s.repo.On("Save", &deleted).Return(&models.Weight{ID: 12}, nil).Once()
+	s.repo.On("SetWeightTags", uint64(12), []string(nil)).Return(nil).Once()
+	s.repo.On("SaveReading", &models.Reading{WeightID: 11, TakenAt: data.Readings[0].TakenAt, Value: 50}).
+		Return(&models.Reading{ID: 17}, nil).Once()
+	s.repo.On("SaveMeasurement", &models.Measurement{TypeID: 13, Date: "2020-11-09", Value: 80}).
+		Return(&models.Measurement{ID: 14}, nil).Once()
+	s.repo.On("SaveProfile", &models.Profile{Height: 170, BirthDate: "1990-01-01", Sex: "female"}).
+		Return(&models.Profile{ID: 1}, nil).Once()
+
+	report, err := s.service.RestoreArchive(data, "", false)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), services.RestoreFail, report.Policy)
+	require.Equal(s.T(), services.RestoreCount{Created: 2}, report.Weights)
+	require.Equal(s.T(), services.RestoreCount{Created: 1}, report.Readings)
+	require.Equal(s.T(), services.RestoreCount{Unchanged: 1}, report.MeasurementTypes)
+	require.Equal(s.T(), services.RestoreCount{Created: 1}, report.Profile)
+	require.Empty(s.T(), report.Conflicts)
+}
+
+// conflictingDatabase mocks a database having the data of the archive,
+// except the active weight with other values
+func (s *Suite) conflictingDatabase() {
+	data := archiveData()
+	s.repo.On("FindAllMeasurementTypes").Return(&data.MeasurementTypes, nil).Once()
+	s.repo.On("FindDeleted").Return(&[]models.Weight{data.Weights[1]}, nil).Once()
+	s.repo.On("FindWeightTagNames").Return(map[uint64][]string{1: {"ate out"}}, nil).Once()
+	s.repo.On("FindByDate", "2020-11-09").
+		Return(&models.Weight{ID: 1, Date: "2020-11-09", Max: 52, Min: 48, Difference: 4}, nil).Once()
+	s.repo.On("FindMeasurementByTypeAndDate", uint64(3), "2020-11-09").Return(&data.Measurements[0], nil).Once()
+	s.repo.On("FindProfile").Return(data.Profile, nil).Once()
+}
+
+func (s *Suite) Test_RestoreArchive_Fail_On_Conflict_Without_Writing() {
+	s.conflictingDatabase()
+
+	report, err := s.service.RestoreArchive(archiveData(), services.RestoreFail, false)
+	require.Equal(s.T(), services.ErrRestoreConflict, err)
+	require.Equal(s.T(), []string{"weight 2020-11-09"}, report.Conflicts)
+	require.Equal(s.T(), services.RestoreCount{Skipped: 1, Unchanged: 1}, report.Weights)
+	require.Equal(s.T(), services.RestoreCount{Unchanged: 1}, report.Measurements)
+}
+
+func (s *Suite) Test_RestoreArchive_Verify_Does_Not_Write() {
+	s.conflictingDatabase()
+
+	report, err := s.service.RestoreArchive(archiveData(), services.RestoreOverwrite, true)
+	require.NoError(s.T(), err)
+	require.True(s.T(), report.Verify)
+	require.Equal(s.T(), services.RestoreCount{Updated: 1, Unchanged: 1}, report.Weights)
+}
+
+func (s *Suite) Test_RestoreArchive_Overwrite_Conflicting_Weight() {
+	s.conflictingDatabase()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("Update", uint64(1), &models.Weight{Date: "2020-11-09", Max: 50, Min: 48, Difference: 2, Tags: []string{"ate out"}}).
+		Return(&models.Weight{ID: 1}, nil).Once()
+	s.repo.On("FindReadingsByWeightID", uint64(1)).Return(&[]models.Reading{{ID: 8, WeightID: 1}}, nil).Once()
+	s.repo.On("DeleteReading", uint64(8)).Return(nil).Once()
+	s.repo.On("SetWeightTags", uint64(1), []string{"ate out"}).Return(nil).Once()
+	s.repo.On("SaveReading", &models.Reading{WeightID: 1, TakenAt: archiveData().Readings[0].TakenAt, Value: 50}).
+		Return(&models.Reading{ID: 9}, nil).Once()
+
+	report, err := s.service.RestoreArchive(archiveData(), services.RestoreOverwrite, false)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), services.RestoreCount{Updated: 1}, report.Readings)
+}
+
+func (s *Suite) Test_RestoreArchive_Stop_At_The_Failed_Write() {
+	s.conflictingDatabase()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("Update", uint64(1), &models.Weight{Date: "2020-11-09", Max: 50, Min: 48, Difference: 2, Tags: []string{"ate out"}}).
+		Return(&models.Weight{ID: 1}, nil).Once()
+	s.repo.On("FindReadingsByWeightID", uint64(1)).Return(&[]models.Reading{{ID: 8, WeightID: 1}}, nil).Once()
+	s.repo.On("DeleteReading", uint64(8)).Return(errors.New("connection reset")).Once()
+
+	report, err := s.service.RestoreArchive(archiveData(), services.RestoreOverwrite, false)
+	require.EqualError(s.T(), err, "connection reset")
+	require.Nil(s.T(), report)
+}
+
+func (s *Suite) Test_RestoreArchive_When_Policy_Is_Unknown() {
+	_, err := s.service.RestoreArchive(archiveData(), "merge", false)
+	require.IsType(s.T(), models.ValidationErrors{}, err)
+}
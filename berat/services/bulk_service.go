@@ -0,0 +1,180 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/erizkiatama/berat/models"
+)
+
+// Modes of a bulk insert. In BulkAllOrNothing nothing is saved when any
+// row fails, in BulkBestEffort the rows that pass are saved anyway
+const (
+	BulkAllOrNothing = "all_or_nothing"
+	BulkBestEffort   = "best_effort"
+)
+
+// Statuses of a row of a bulk insert. A row is BulkSkipped when it passed
+// but was not saved because another row failed in BulkAllOrNothing mode
+const (
+	BulkCreated   = "created"
+	BulkDuplicate = "duplicate"
+	BulkInvalid   = "invalid"
+	BulkSkipped   = "skipped"
+)
+
+// MaxBulkRows is the most weight data saved by one bulk insert
+const MaxBulkRows = 366
+
+// BulkRow is one weight data of a bulk insert. Line is its position in the
+// submitted grid or JSON array starting from 1, and Errors holds the values
+// that could not be parsed while binding the row
+type BulkRow struct {
+	Line   int
+	Weight *models.Weight
+	Errors models.ValidationErrors
+}
+
+// BulkResult is the outcome of one row of a bulk insert
+type BulkResult struct {
+	Line   int                     `json:"line"`
+	Date   string                  `json:"date"`
+	Status string                  `json:"status"`
+	ID     uint64                  `json:"id,omitempty"`
+	Errors models.ValidationErrors `json:"errors,omitempty"`
+}
+
+// BulkReport is the outcome of a bulk insert with the result of every row
+type BulkReport struct {
+	Mode      string       `json:"mode"`
+	Created   int          `json:"created"`
+	Duplicate int          `json:"duplicate"`
+	Invalid   int          `json:"invalid"`
+	Skipped   int          `json:"skipped"`
+	Results   []BulkResult `json:"results"`
+}
+
+// CreateAll validates every row like Create does and saves the rows that
+// pass with their tags in one transaction. A row is a duplicate when its
+// date is taken in the database or by an earlier row. The mode defaults to
+// BulkAllOrNothing. Invalid mode or number of rows are reported as
+// models.ValidationErrors, failed rows only in the report
+func (ws *WeightService) CreateAll(mode string, rows []BulkRow) (*BulkReport, error) {
+	if mode == "" {
+		mode = BulkAllOrNothing
+	}
+
+	errs := models.ValidationErrors{}
+	if mode != BulkAllOrNothing && mode != BulkBestEffort {
+		errs.Add("mode", fmt.Sprintf("Please choose %s or %s", BulkAllOrNothing, BulkBestEffort))
+	}
+
+	if len(rows) == 0 {
+		errs.Add("weights", "Required at least one weight")
+	} else if len(rows) > MaxBulkRows {
+		errs.Add("weights", fmt.Sprintf("Could not save more than %d weights at once", MaxBulkRows))
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	report := &BulkReport{Mode: mode, Results: make([]BulkResult, len(rows))}
+	var weights []models.Weight
+	var passed []int
+	seen := make(map[string]bool, len(rows))
+
+	for i, row := range rows {
+		weight := *row.Weight
+		weight.CalculateDifference()
+		result := BulkResult{Line: row.Line, Date: weight.Date}
+
+		rowErrs := models.ValidationErrors{}
+		for field, message := range row.Errors {
+			rowErrs.Add(field, message)
+		}
+
+		if err := weight.Validate(); err != nil {
+			for field, message := range err.(models.ValidationErrors) {
+				rowErrs.Add(field, message)
+			}
+		}
+
+		if len(rowErrs) == 0 {
+			err := ws.CheckRules(&weight)
+			rules, ok := err.(RuleErrors)
+			if err != nil && !ok {
+				return nil, err
+			}
+
+			for rule, message := range rules {
+				rowErrs.Add(rule, message)
+			}
+		}
+
+		if len(rowErrs) > 0 {
+			result.Status = BulkInvalid
+			result.Errors = rowErrs
+			report.Invalid++
+			report.Results[i] = result
+			continue
+		}
+
+		err := ws.checkDate(0, weight.Date)
+		if err != nil && err != ErrDuplicateDate {
+			return nil, err
+		}
+
+		if err == ErrDuplicateDate || seen[weight.Date] {
+			result.Status = BulkDuplicate
+			result.Errors = models.ValidationErrors{"date": ErrDuplicateDate.Error()}
+			report.Duplicate++
+			report.Results[i] = result
+			continue
+		}
+
+		seen[weight.Date] = true
+		weights = append(weights, weight)
+		passed = append(passed, i)
+		report.Results[i] = result
+	}
+
+	if mode == BulkAllOrNothing && len(weights) < len(rows) {
+		for _, i := range passed {
+			report.Results[i].Status = BulkSkipped
+		}
+		report.Skipped = len(passed)
+
+		return report, nil
+	}
+
+	if len(weights) > 0 {
+		err := ws.WeightRepo.Transaction(func(repo models.Repository) error {
+			if err := repo.SaveAll(weights); err != nil {
+				return err
+			}
+
+			for _, weight := range weights {
+				if len(weight.Tags) == 0 {
+					continue
+				}
+
+				if err := repo.SetWeightTags(weight.ID, weight.Tags); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for j, i := range passed {
+		report.Results[i].Status = BulkCreated
+		report.Results[i].ID = weights[j].ID
+	}
+	report.Created = len(passed)
+
+	return report, nil
+}
@@ -0,0 +1,98 @@
+package services_test
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+func bulkRows() []services.BulkRow {
+	return []services.BulkRow{
+		{Line: 1, Weight: &models.Weight{Date: "2020-11-09", Max: 50, Min: 48}},
+		{Line: 2, Weight: &models.Weight{Date: "2020-11-10", Max: 51, Min: 49}},
+		{Line: 3, Weight: &models.Weight{Date: "2020-11-10", Max: 52, Min: 50}},
+		{Line: 5, Weight: &models.Weight{Date: "2020-11-12"}, Errors: models.ValidationErrors{"max": "Please fill the max value correctly"}},
+	}
+}
+
+func (s *Suite) Test_CreateAll_All_Or_Nothing_Save_Nothing_When_A_Row_Fails() {
+	s.repo.On("FindByDate", "2020-11-09").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("FindByDate", "2020-11-10").Return(nil, gorm.ErrRecordNotFound).Twice()
+
+	report, err := s.service.CreateAll("", bulkRows())
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), services.BulkAllOrNothing, report.Mode)
+	require.Equal(s.T(), 0, report.Created)
+	require.Equal(s.T(), 2, report.Skipped)
+	require.Equal(s.T(), 1, report.Duplicate)
+	require.Equal(s.T(), 1, report.Invalid)
+	require.Equal(s.T(), services.BulkDuplicate, report.Results[2].Status)
+	require.Equal(s.T(), 5, report.Results[3].Line)
+	require.Equal(s.T(), models.ValidationErrors{
+		"max": "Please fill the max value correctly",
+		"min": "Required min weight",
+	}, report.Results[3].Errors)
+	s.repo.AssertNotCalled(s.T(), "SaveAll", mock.Anything)
+}
+
+func (s *Suite) Test_CreateAll_Best_Effort_Save_Passed_Rows() {
+	s.repo.On("FindByDate", "2020-11-09").Return(&models.Weight{ID: 3, Date: "2020-11-09"}, nil).Once()
+	s.repo.On("FindByDate", "2020-11-10").Return(nil, gorm.ErrRecordNotFound).Twice()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("SaveAll", []models.Weight{{Date: "2020-11-10", Max: 51, Min: 49, Difference: 2}}).
+		Run(func(args mock.Arguments) { args.Get(0).([]models.Weight)[0].ID = 8 }).
+		Return(nil).Once()
+
+	report, err := s.service.CreateAll(services.BulkBestEffort, bulkRows())
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 1, report.Created)
+	require.Equal(s.T(), 2, report.Duplicate)
+	require.Equal(s.T(), services.BulkResult{Line: 2, Date: "2020-11-10", Status: services.BulkCreated, ID: 8}, report.Results[1])
+}
+
+func (s *Suite) Test_CreateAll_Save_Tags_Of_The_Rows() {
+	rows := []services.BulkRow{
+		{Line: 1, Weight: &models.Weight{Date: "2020-11-09", Max: 50, Min: 48, Tags: []string{"ate out"}}},
+		{Line: 2, Weight: &models.Weight{Date: "2020-11-10", Max: 51, Min: 49}},
+	}
+
+	s.repo.On("FindByDate", "2020-11-09").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("FindByDate", "2020-11-10").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("SaveAll", mock.Anything).
+		Run(func(args mock.Arguments) {
+			weights := args.Get(0).([]models.Weight)
+			weights[0].ID, weights[1].ID = 8, 9
+		}).
+		Return(nil).Once()
+	s.repo.On("SetWeightTags", uint64(8), []string{"ate out"}).Return(nil).Once()
+
+	report, err := s.service.CreateAll(services.BulkAllOrNothing, rows)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 2, report.Created)
+}
+
+func (s *Suite) Test_CreateAll_When_Tags_Fail_In_The_Transaction() {
+	rows := []services.BulkRow{
+		{Line: 1, Weight: &models.Weight{Date: "2020-11-09", Max: 50, Min: 48, Tags: []string{"ate out"}}},
+	}
+
+	s.repo.On("FindByDate", "2020-11-09").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("SaveAll", mock.Anything).Return(nil).Once()
+	s.repo.On("SetWeightTags", uint64(0), []string{"ate out"}).Return(gorm.ErrInvalidSQL).Once()
+
+	_, err := s.service.CreateAll(services.BulkAllOrNothing, rows)
+	require.Equal(s.T(), gorm.ErrInvalidSQL, err)
+}
+
+func (s *Suite) Test_CreateAll_When_Mode_Is_Unknown_And_No_Rows() {
+	_, err := s.service.CreateAll("sometimes", nil)
+	require.Equal(s.T(), models.ValidationErrors{
+		"mode":    "Please choose all_or_nothing or best_effort",
+		"weights": "Required at least one weight",
+	}, err)
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/erizkiatama/berat/models"
+)
+
+const (
+	// CalendarSmallChange is the change per day against the trend from
+	// which a day is colored as gaining or losing
+	CalendarSmallChange = 0.5
+
+	// CalendarLargeChange is the change per day against the trend from
+	// which a day is colored as gaining or losing a lot
+	CalendarLargeChange = 1.5
+)
+
+// CalendarDay is a day of the calendar. A logged day has the ID of its
+// weight data and Change, how much the middle of its Max and Min changed
+// per day since the previous logged day minus the change per day of the
+// overall trend. Level is Change from -2, losing a lot, to 2, gaining a
+// lot. A missing day is a day without weight data after the first logged
+// day and before today
+type CalendarDay struct {
+	Date    string
+	Day     int
+	ID      uint64
+	Change  float64
+	Level   int
+	Missing bool
+	Future  bool
+}
+
+// CalendarMonth is a month of the calendar by week from Monday,
+// the days of the weeks outside the month are nil
+type CalendarMonth struct {
+	Name  string
+	Weeks [][]*CalendarDay
+}
+
+// Calendar holds the months from the month of From on
+type Calendar struct {
+	From   time.Time
+	Months []CalendarMonth
+}
+
+// Class returns the name of the CSS class coloring the day
+func (d *CalendarDay) Class() string {
+	switch {
+	case d.ID == 0 && d.Missing:
+		return "missing"
+	case d.ID == 0:
+		return "empty"
+	case d.Level > 0:
+		return fmt.Sprintf("up%d", d.Level)
+	case d.Level < 0:
+		return fmt.Sprintf("down%d", -d.Level)
+	}
+
+	return "flat"
+}
+
+// Calendar builds the calendar of the given number of months
+// from the month of from, with the missing days until today
+func (ws *WeightService) Calendar(from time.Time, months int, today time.Time) (*Calendar, error) {
+	weights, err := ws.List()
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := BuildCalendar(*weights, from, months, today)
+	return &calendar, nil
+}
+
+// BuildCalendar builds the calendar of the weight data ordered by date.
+// The weight data with an invalid date are left out
+func BuildCalendar(weights []models.Weight, from time.Time, months int, today time.Time) Calendar {
+	var values []datedValue
+	var ids []uint64
+	for _, weight := range weights {
+		if value, ok := valueOf(weight); ok {
+			values = append(values, value)
+			ids = append(ids, weight.ID)
+		}
+	}
+
+	trend := fitTrend(values)
+	days := map[string]*CalendarDay{}
+	for i, value := range values {
+		day := &CalendarDay{ID: ids[i]}
+		if i > 0 {
+			day.Change = dailyChange(values[i-1], value) - trend.slope
+			day.Level = changeLevel(day.Change)
+		}
+
+		days[value.date.Format(models.DateLayout)] = day
+	}
+
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	start := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	calendar := Calendar{From: start}
+
+	for i := 0; i < months; i++ {
+		month := start.AddDate(0, i, 0)
+		calendar.Months = append(calendar.Months, buildMonth(month, days, values, today))
+	}
+
+	return calendar
+}
+
+func buildMonth(month time.Time, days map[string]*CalendarDay, values []datedValue, today time.Time) CalendarMonth {
+	result := CalendarMonth{Name: fmt.Sprintf("%s %d", month.Month(), month.Year())}
+
+	week := make([]*CalendarDay, (int(month.Weekday())+6)%7)
+	for date := month; date.Month() == month.Month(); date = date.AddDate(0, 0, 1) {
+		key := date.Format(models.DateLayout)
+
+		day := &CalendarDay{}
+		if logged, ok := days[key]; ok {
+			*day = *logged
+		}
+		day.Date = key
+		day.Day = date.Day()
+		day.Future = date.After(today)
+		day.Missing = day.ID == 0 && len(values) > 0 && date.After(values[0].date) && date.Before(today)
+
+		week = append(week, day)
+		if len(week) == 7 {
+			result.Weeks = append(result.Weeks, week)
+			week = nil
+		}
+	}
+
+	if len(week) > 0 {
+		week = append(week, make([]*CalendarDay, 7-len(week))...)
+		result.Weeks = append(result.Weeks, week)
+	}
+
+	return result
+}
+
+func changeLevel(change float64) int {
+	level := 0
+	switch size := math.Abs(change); {
+	case size >= CalendarLargeChange:
+		level = 2
+	case size >= CalendarSmallChange:
+		level = 1
+	}
+
+	if change < 0 {
+		return -level
+	}
+
+	return level
+}
@@ -0,0 +1,66 @@
+package services_test
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+func (s *Suite) Test_BuildCalendar_Color_Change_Against_Trend() {
+	weights := []models.Weight{
+		{ID: 1, Date: "2020-11-02", Max: 50, Min: 50},
+		{ID: 2, Date: "2020-11-03", Max: 50, Min: 50},
+		{ID: 3, Date: "2020-11-04", Max: 50, Min: 50},
+		{ID: 4, Date: "2020-11-05", Max: 53, Min: 53},
+		{ID: 5, Date: "2020-11-06", Max: 53, Min: 53},
+		{ID: 6, Date: "2020-11-08", Max: 52, Min: 52},
+	}
+	today := time.Date(2020, 11, 10, 20, 0, 0, 0, time.UTC)
+
+	calendar := services.BuildCalendar(weights, time.Date(2020, 11, 15, 0, 0, 0, 0, time.UTC), 1, today)
+	require.Len(s.T(), calendar.Months, 1)
+
+	month := calendar.Months[0]
+	require.Equal(s.T(), "November 2020", month.Name)
+	require.Len(s.T(), month.Weeks, 6)
+
+	// November 2020 starts on a Sunday
+	first := month.Weeks[0]
+	require.Nil(s.T(), first[0])
+	require.Equal(s.T(), "2020-11-01", first[6].Date)
+	require.Equal(s.T(), "empty", first[6].Class())
+
+	days := map[string]*services.CalendarDay{}
+	for _, week := range month.Weeks {
+		for _, day := range week {
+			if day != nil {
+				days[day.Date] = day
+			}
+		}
+	}
+
+	require.Equal(s.T(), uint64(1), days["2020-11-02"].ID)
+	require.Equal(s.T(), "flat", days["2020-11-02"].Class())
+	require.Equal(s.T(), "up2", days["2020-11-05"].Class())
+	require.Equal(s.T(), "down1", days["2020-11-06"].Class())
+	require.Equal(s.T(), "missing", days["2020-11-07"].Class())
+	require.True(s.T(), days["2020-11-09"].Missing)
+	require.False(s.T(), days["2020-11-10"].Missing)
+	require.False(s.T(), days["2020-11-10"].Future)
+	require.True(s.T(), days["2020-11-11"].Future)
+	require.Equal(s.T(), "empty", days["2020-11-30"].Class())
+	require.Nil(s.T(), month.Weeks[5][1])
+}
+
+func (s *Suite) Test_Calendar_Of_A_Year() {
+	s.repo.On("FindAll").Return(&[]models.Weight{{ID: 1, Date: "2020-11-02", Max: 50, Min: 48}}, nil).Once()
+
+	calendar, err := s.service.Calendar(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), 12, time.Now())
+	require.NoError(s.T(), err)
+	require.Len(s.T(), calendar.Months, 12)
+	require.Equal(s.T(), "January 2020", calendar.Months[0].Name)
+	require.Equal(s.T(), "December 2020", calendar.Months[11].Name)
+}
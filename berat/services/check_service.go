@@ -0,0 +1,203 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erizkiatama/berat/models"
+)
+
+// Kinds of the issues found by CheckWeights
+const (
+	IssueDateFormat    = "date_format"
+	IssueInvalidDate   = "invalid_date"
+	IssueDuplicateDate = "duplicate_date"
+	IssueImplausible   = "implausible"
+)
+
+// dateLayouts are the date formats a weight date could be repaired from,
+// written directly in the database instead of through the application
+var dateLayouts = []string{
+	models.DateLayout,
+	"2006-1-2",
+	"2006/01/02",
+	"2006/1/2",
+	"02-01-2006",
+	"02/01/2006",
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+	"20060102",
+}
+
+// Issue is one violation of the invariants of a weight data. Repair
+// describes how it is repaired, it is empty when it could not be repaired
+// automatically and has to be fixed by hand
+type Issue struct {
+	WeightID uint64 `json:"weight_id"`
+	Date     string `json:"date"`
+	Kind     string `json:"kind"`
+	Message  string `json:"message"`
+	Repair   string `json:"repair,omitempty"`
+}
+
+// CheckReport is the outcome of CheckWeights
+type CheckReport struct {
+	Checked  int     `json:"checked"`
+	Issues   []Issue `json:"issues"`
+	Repaired bool    `json:"repaired"`
+}
+
+// Unrepairable returns the number of issues that have to be fixed by hand
+func (cr *CheckReport) Unrepairable() int {
+	count := 0
+	for _, issue := range cr.Issues {
+		if issue.Repair == "" {
+			count++
+		}
+	}
+
+	return count
+}
+
+// CheckWeights scans all the weight data, the ones in the trash included,
+// for dates not in the YYYY-MM-DD format, active weight data having the
+// same date and values failing the min weight, max weight and max
+// difference rules of the service. When repair is true the repairable
+// issues are repaired in one transaction: the dates are rewritten in the
+// YYYY-MM-DD format and of the weight data having the same date, the one
+// already in that format or else the oldest is kept and the others are
+// moved to the trash
+func (ws *WeightService) CheckWeights(repair bool) (*CheckReport, error) {
+	weights, err := ws.WeightRepo.FindAllWithDeleted()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CheckReport{Checked: len(*weights), Issues: []Issue{}}
+	repaired := make(map[uint64]*models.Weight)
+	fix := func(weight models.Weight) *models.Weight {
+		if _, ok := repaired[weight.ID]; !ok {
+			repaired[weight.ID] = &weight
+		}
+
+		return repaired[weight.ID]
+	}
+
+	byDate := make(map[string][]models.Weight)
+	for _, weight := range *weights {
+		date, ok := canonicalDate(weight.Date)
+		switch {
+		case !ok:
+			report.add(weight, IssueInvalidDate, fmt.Sprintf("Date %q could not be read", weight.Date), "")
+		case date != weight.Date:
+			report.add(weight, IssueDateFormat, fmt.Sprintf("Date %q is not in the YYYY-MM-DD format", weight.Date), "Rewrite the date as "+date)
+			fix(weight).Date = date
+		}
+
+		if ok && weight.DeletedAt == nil {
+			byDate[date] = append(byDate[date], weight)
+		}
+
+		for _, problem := range ws.implausible(weight) {
+			report.add(weight, IssueImplausible, problem, "")
+		}
+	}
+
+	var trash []uint64
+	for date, same := range byDate {
+		if len(same) < 2 {
+			continue
+		}
+
+		keep := same[0]
+		for _, weight := range same {
+			if weight.Date == date {
+				keep = weight
+				break
+			}
+		}
+
+		for _, weight := range same {
+			if weight.ID == keep.ID {
+				continue
+			}
+
+			report.add(weight, IssueDuplicateDate, fmt.Sprintf("Date %s is also the date of weight %d", date, keep.ID), "Move to the trash")
+			trash = append(trash, weight.ID)
+			delete(repaired, weight.ID)
+		}
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		return report.Issues[i].WeightID < report.Issues[j].WeightID
+	})
+
+	if !repair || (len(repaired) == 0 && len(trash) == 0) {
+		return report, nil
+	}
+
+	weightsToRepair := make([]models.Weight, 0, len(repaired))
+	for _, weight := range repaired {
+		weightsToRepair = append(weightsToRepair, *weight)
+	}
+
+	sort.Slice(weightsToRepair, func(i, j int) bool { return weightsToRepair[i].ID < weightsToRepair[j].ID })
+	sort.Slice(trash, func(i, j int) bool { return trash[i] < trash[j] })
+
+	if err := ws.WeightRepo.RepairWeights(weightsToRepair, trash); err != nil {
+		return nil, err
+	}
+
+	report.Repaired = true
+
+	return report, nil
+}
+
+func (cr *CheckReport) add(weight models.Weight, kind, message, repair string) {
+	cr.Issues = append(cr.Issues, Issue{
+		WeightID: weight.ID,
+		Date:     weight.Date,
+		Kind:     kind,
+		Message:  message,
+		Repair:   repair,
+	})
+}
+
+// canonicalDate returns the date in the YYYY-MM-DD format,
+// ok is false when it is not in any of the known formats
+func canonicalDate(date string) (string, bool) {
+	for _, layout := range dateLayouts {
+		if parsed, err := time.Parse(layout, strings.TrimSpace(date)); err == nil {
+			return parsed.Format(models.DateLayout), true
+		}
+	}
+
+	return "", false
+}
+
+// implausible returns the problems of the weight values, a Max smaller
+// than its Min and the values failing the rules of the service
+func (ws *WeightService) implausible(weight models.Weight) []string {
+	rules := ws.Rules
+	var problems []string
+
+	if weight.Max < weight.Min {
+		problems = append(problems, fmt.Sprintf("Max %d is smaller than min %d", weight.Max, weight.Min))
+	}
+
+	if rules.MinWeight > 0 && weight.Min < rules.MinWeight {
+		problems = append(problems, fmt.Sprintf("Min %d is less than %d", weight.Min, rules.MinWeight))
+	}
+
+	if rules.MaxWeight > 0 && weight.Max > rules.MaxWeight {
+		problems = append(problems, fmt.Sprintf("Max %d is more than %d", weight.Max, rules.MaxWeight))
+	}
+
+	if rules.MaxDifference > 0 && weight.Max-weight.Min > rules.MaxDifference {
+		problems = append(problems, fmt.Sprintf("Max and min are more than %d apart", rules.MaxDifference))
+	}
+
+	return problems
+}
@@ -0,0 +1,82 @@
+package services_test
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+func brokenWeights() *[]models.Weight {
+	deletedAt := time.Now()
+
+	return &[]models.Weight{
+		{ID: 1, Date: "2020-11-09", Max: 50, Min: 48, Difference: 2},
+		{ID: 2, Date: "2020/11/10", Max: 51, Min: 49, Difference: 2},
+		{ID: 3, Date: "10-11-2020", Max: 51, Min: 49, Difference: 2},
+		{ID: 4, Date: "2020-11-10", Max: 52, Min: 49, Difference: 3},
+		{ID: 5, Date: "yesterday", Max: 500, Min: 49, Difference: 451},
+		{ID: 6, Date: "2020/11/09", Max: 50, Min: 48, Difference: 2, DeletedAt: &deletedAt},
+	}
+}
+
+func (s *Suite) Test_CheckWeights_Report_Without_Repairing() {
+	defer s.withRules(services.Rules{MinWeight: 20, MaxWeight: 300, MaxDifference: 10})()
+	s.repo.On("FindAllWithDeleted").Return(brokenWeights(), nil).Once()
+
+	report, err := s.service.CheckWeights(false)
+	require.NoError(s.T(), err)
+	require.False(s.T(), report.Repaired)
+	require.Equal(s.T(), 6, report.Checked)
+
+	kinds := map[uint64][]string{}
+	for _, issue := range report.Issues {
+		kinds[issue.WeightID] = append(kinds[issue.WeightID], issue.Kind)
+	}
+	require.Equal(s.T(), map[uint64][]string{
+		2: {services.IssueDateFormat, services.IssueDuplicateDate},
+		3: {services.IssueDateFormat, services.IssueDuplicateDate},
+		5: {services.IssueInvalidDate, services.IssueImplausible, services.IssueImplausible},
+		6: {services.IssueDateFormat},
+	}, kinds)
+	require.Equal(s.T(), 3, report.Unrepairable())
+}
+
+func (s *Suite) Test_CheckWeights_Repair_In_One_Transaction() {
+	weights := brokenWeights()
+	deleted := (*weights)[5]
+	deleted.Date = "2020-11-09"
+	repaired := []models.Weight{deleted}
+	s.repo.On("FindAllWithDeleted").Return(weights, nil).Once()
+	s.repo.On("RepairWeights", repaired, []uint64{2, 3}).Return(nil).Once()
+
+	report, err := s.service.CheckWeights(true)
+	require.NoError(s.T(), err)
+	require.True(s.T(), report.Repaired)
+}
+
+func (s *Suite) Test_CheckWeights_Implausible_By_The_Rules() {
+	weights := &[]models.Weight{{ID: 1, Date: "2020-11-09", Max: 160, Min: 148, Difference: 12}}
+
+	s.repo.On("FindAllWithDeleted").Return(weights, nil).Once()
+	report, err := s.service.CheckWeights(false)
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), report.Issues)
+
+	defer s.withRules(services.Rules{MaxWeight: 150})()
+	s.repo.On("FindAllWithDeleted").Return(weights, nil).Once()
+	report, err = s.service.CheckWeights(false)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []services.Issue{{WeightID: 1, Date: "2020-11-09", Kind: services.IssueImplausible, Message: "Max 160 is more than 150"}}, report.Issues)
+}
+
+func (s *Suite) Test_CheckWeights_When_Nothing_Is_Wrong() {
+	s.repo.On("FindAllWithDeleted").Return(&[]models.Weight{{ID: 1, Date: "2020-11-09", Max: 50, Min: 48, Difference: 2}}, nil).Once()
+
+	report, err := s.service.CheckWeights(true)
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), report.Issues)
+	require.False(s.T(), report.Repaired)
+}
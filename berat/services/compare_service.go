@@ -0,0 +1,190 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/erizkiatama/berat/models"
+)
+
+// DayChange is how much the Max and Min of a day moved since
+// Previous, the weight data logged Days days before it
+type DayChange struct {
+	Previous string `json:"previous"`
+	Days     int    `json:"days"`
+	Max      int    `json:"max"`
+	Min      int    `json:"min"`
+}
+
+// Period is a range of dates, From and To included
+type Period struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// PeriodStats holds the averages of the weight data of a period
+type PeriodStats struct {
+	Period
+	Count       int     `json:"count"`
+	AverageMax  float64 `json:"average_max"`
+	AverageMin  float64 `json:"average_min"`
+	AverageDiff float64 `json:"average_difference"`
+}
+
+// Percent is a percentage of change, shown with its sign such as +2.5%
+type Percent float64
+
+// String formats the percentage with its sign and one decimal
+func (p Percent) String() string {
+	return fmt.Sprintf("%+.1f%%", float64(p))
+}
+
+// PeriodDelta is how much the averages changed from the first period
+// to the second one. A percentage is nil when the average of the first
+// period is zero
+type PeriodDelta struct {
+	Max         float64  `json:"max"`
+	Min         float64  `json:"min"`
+	Diff        float64  `json:"difference"`
+	MaxPercent  *Percent `json:"max_percent"`
+	MinPercent  *Percent `json:"min_percent"`
+	DiffPercent *Percent `json:"difference_percent"`
+}
+
+// Comparison compares the averages of two periods. Delta is nil
+// when one of the periods has no weight data
+type Comparison struct {
+	First  PeriodStats  `json:"first"`
+	Second PeriodStats  `json:"second"`
+	Delta  *PeriodDelta `json:"delta"`
+}
+
+// DayChanges returns the change of every weight data since the one
+// before it by id. The weight data must be ordered by date, so the
+// first one has no change
+func DayChanges(weights []models.Weight) map[uint64]*DayChange {
+	changes := map[uint64]*DayChange{}
+	for i := 1; i < len(weights); i++ {
+		previous, weight := weights[i-1], weights[i]
+		change := &DayChange{
+			Previous: previous.Date,
+			Max:      weight.Max - previous.Max,
+			Min:      weight.Min - previous.Min,
+		}
+
+		from, err := time.Parse(models.DateLayout, previous.Date)
+		to, toErr := time.Parse(models.DateLayout, weight.Date)
+		if err == nil && toErr == nil {
+			change.Days = int(to.Sub(from).Hours() / 24)
+		}
+
+		changes[weight.ID] = change
+	}
+
+	return changes
+}
+
+// DefaultPeriods returns the previous month and the current
+// month until today, the periods compared by default
+func DefaultPeriods(now time.Time) (Period, Period) {
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	previous := Period{
+		From: month.AddDate(0, -1, 0).Format(models.DateLayout),
+		To:   month.AddDate(0, 0, -1).Format(models.DateLayout),
+	}
+	current := Period{
+		From: month.Format(models.DateLayout),
+		To:   now.Format(models.DateLayout),
+	}
+
+	return previous, current
+}
+
+// Compare compares the averages of the weight data of the second period
+// against the first one. The outliers are left out when ExcludeOutliers
+// is true. It returns ValidationErrors keyed by first_from, first_to,
+// second_from and second_to when a period is not valid
+func (ws *WeightService) Compare(first, second Period) (*Comparison, error) {
+	errs := models.ValidationErrors{}
+	first.validate("first", errs)
+	second.validate("second", errs)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	weights, err := ws.List()
+	if err != nil {
+		return nil, err
+	}
+
+	counted := *weights
+	if ws.ExcludeOutliers {
+		counted = ExcludeOutliers(counted, FindOutliers(counted))
+	}
+
+	comparison := &Comparison{
+		First:  first.summarize(counted),
+		Second: second.summarize(counted),
+	}
+
+	if comparison.First.Count > 0 && comparison.Second.Count > 0 {
+		a, b := comparison.First, comparison.Second
+		comparison.Delta = &PeriodDelta{
+			Max:         b.AverageMax - a.AverageMax,
+			Min:         b.AverageMin - a.AverageMin,
+			Diff:        b.AverageDiff - a.AverageDiff,
+			MaxPercent:  percentChange(a.AverageMax, b.AverageMax),
+			MinPercent:  percentChange(a.AverageMin, b.AverageMin),
+			DiffPercent: percentChange(a.AverageDiff, b.AverageDiff),
+		}
+	}
+
+	return comparison, nil
+}
+
+func (p Period) validate(name string, errs models.ValidationErrors) {
+	from, fromErr := time.Parse(models.DateLayout, p.From)
+	if fromErr != nil {
+		errs.Add(name+"_from", "Please fill the date correctly (YYYY-MM-DD)")
+	}
+
+	to, toErr := time.Parse(models.DateLayout, p.To)
+	if toErr != nil {
+		errs.Add(name+"_to", "Please fill the date correctly (YYYY-MM-DD)")
+	}
+
+	if fromErr == nil && toErr == nil && to.Before(from) {
+		errs.Add(name+"_to", "The end could not be before the start")
+	}
+}
+
+// summarize calculates the averages of the weight data within the period,
+// comparing the dates as text as they all follow models.DateLayout
+func (p Period) summarize(weights []models.Weight) PeriodStats {
+	within := []models.Weight{}
+	for _, weight := range weights {
+		if weight.Date >= p.From && weight.Date <= p.To {
+			within = append(within, weight)
+		}
+	}
+
+	stats := Summarize(within)
+
+	return PeriodStats{
+		Period:      p,
+		Count:       stats.Count,
+		AverageMax:  stats.AverageMax,
+		AverageMin:  stats.AverageMin,
+		AverageDiff: stats.AverageDiff,
+	}
+}
+
+func percentChange(from, to float64) *Percent {
+	if from == 0 {
+		return nil
+	}
+
+	percent := Percent((to - from) / from * 100)
+	return &percent
+}
@@ -0,0 +1,105 @@
+package services_test
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+func (s *Suite) Test_DayChanges_Since_Previous_Weight() {
+	weights := []models.Weight{
+		{ID: 1, Date: "2020-11-08", Max: 50, Min: 48},
+		{ID: 2, Date: "2020-11-09", Max: 52, Min: 47},
+		{ID: 5, Date: "2020-11-12", Max: 51, Min: 47},
+	}
+
+	require.Equal(s.T(), map[uint64]*services.DayChange{
+		2: {Previous: "2020-11-08", Days: 1, Max: 2, Min: -1},
+		5: {Previous: "2020-11-09", Days: 3, Max: -1, Min: 0},
+	}, services.DayChanges(weights))
+}
+
+func (s *Suite) Test_DefaultPeriods_Previous_And_Current_Month() {
+	first, second := services.DefaultPeriods(time.Date(2020, 3, 15, 9, 0, 0, 0, time.UTC))
+
+	require.Equal(s.T(), services.Period{From: "2020-02-01", To: "2020-02-29"}, first)
+	require.Equal(s.T(), services.Period{From: "2020-03-01", To: "2020-03-15"}, second)
+}
+
+func (s *Suite) Test_Compare_Averages_Of_Two_Periods() {
+	s.repo.On("FindAll").Return(&[]models.Weight{
+		{Date: "2020-10-30", Max: 52, Min: 50, Difference: 2},
+		{Date: "2020-10-31", Max: 48, Min: 48, Difference: 0},
+		{Date: "2020-11-01", Max: 50, Min: 47, Difference: 3},
+		{Date: "2020-11-02", Max: 49, Min: 45, Difference: 4},
+		{Date: "2020-11-03", Max: 70, Min: 60, Difference: 10},
+	}, nil).Once()
+
+	comparison, err := s.service.Compare(
+		services.Period{From: "2020-10-01", To: "2020-10-31"},
+		services.Period{From: "2020-11-01", To: "2020-11-02"},
+	)
+	require.NoError(s.T(), err)
+
+	require.Equal(s.T(), services.PeriodStats{
+		Period:      services.Period{From: "2020-10-01", To: "2020-10-31"},
+		Count:       2,
+		AverageMax:  50,
+		AverageMin:  49,
+		AverageDiff: 1,
+	}, comparison.First)
+	require.Equal(s.T(), 2, comparison.Second.Count)
+
+	delta := comparison.Delta
+	require.InDelta(s.T(), -0.5, delta.Max, 0.001)
+	require.InDelta(s.T(), -3, delta.Min, 0.001)
+	require.InDelta(s.T(), 2.5, delta.Diff, 0.001)
+	require.InDelta(s.T(), -1, float64(*delta.MaxPercent), 0.001)
+	require.InDelta(s.T(), -6.122, float64(*delta.MinPercent), 0.001)
+	require.InDelta(s.T(), 250, float64(*delta.DiffPercent), 0.001)
+}
+
+func (s *Suite) Test_Compare_Without_Percentage_From_Zero() {
+	s.repo.On("FindAll").Return(&[]models.Weight{
+		{Date: "2020-10-31", Max: 48, Min: 48, Difference: 0},
+		{Date: "2020-11-01", Max: 50, Min: 47, Difference: 3},
+	}, nil).Once()
+
+	comparison, err := s.service.Compare(
+		services.Period{From: "2020-10-01", To: "2020-10-31"},
+		services.Period{From: "2020-11-01", To: "2020-11-30"},
+	)
+	require.NoError(s.T(), err)
+	require.InDelta(s.T(), 3, comparison.Delta.Diff, 0.001)
+	require.Nil(s.T(), comparison.Delta.DiffPercent)
+	require.NotNil(s.T(), comparison.Delta.MaxPercent)
+}
+
+func (s *Suite) Test_Compare_Without_Delta_When_Period_Is_Empty() {
+	s.repo.On("FindAll").Return(&[]models.Weight{
+		{Date: "2020-11-01", Max: 50, Min: 47, Difference: 3},
+	}, nil).Once()
+
+	comparison, err := s.service.Compare(
+		services.Period{From: "2020-10-01", To: "2020-10-31"},
+		services.Period{From: "2020-11-01", To: "2020-11-30"},
+	)
+	require.NoError(s.T(), err)
+	require.Zero(s.T(), comparison.First.Count)
+	require.Nil(s.T(), comparison.Delta)
+}
+
+func (s *Suite) Test_Compare_When_Period_Is_Invalid() {
+	comparison, err := s.service.Compare(
+		services.Period{From: "10/01/2020", To: "2020-10-31"},
+		services.Period{From: "2020-11-30", To: "2020-11-01"},
+	)
+	require.Nil(s.T(), comparison)
+	require.Equal(s.T(), models.ValidationErrors{
+		"first_from": "Please fill the date correctly (YYYY-MM-DD)",
+		"second_to":  "The end could not be before the start",
+	}, err)
+}
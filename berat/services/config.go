@@ -0,0 +1,58 @@
+package services
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/erizkiatama/berat/models"
+)
+
+// DefaultPurgeAfterDays is how many days the deleted weight data stay in
+// the trash when TRASH_PURGE_DAYS is not set
+const DefaultPurgeAfterDays = 30
+
+// Config is the configuration of the WeightService, so the web server and
+// the command line tool set it up the same way
+type Config struct {
+	PurgeAfterDays  int
+	IdempotencyTTL  time.Duration
+	ExcludeOutliers bool
+	RulesFile       string
+}
+
+// ConfigFromEnv reads the configuration from the TRASH_PURGE_DAYS,
+// IDEMPOTENCY_TTL_HOURS, EXCLUDE_OUTLIERS and RULES_FILE environment
+// variables
+func ConfigFromEnv() Config {
+	cfg := Config{PurgeAfterDays: DefaultPurgeAfterDays, RulesFile: os.Getenv("RULES_FILE")}
+
+	if days, err := strconv.Atoi(os.Getenv("TRASH_PURGE_DAYS")); err == nil {
+		cfg.PurgeAfterDays = days
+	}
+
+	if hours, err := strconv.Atoi(os.Getenv("IDEMPOTENCY_TTL_HOURS")); err == nil {
+		cfg.IdempotencyTTL = time.Duration(hours) * time.Hour
+	}
+
+	cfg.ExcludeOutliers, _ = strconv.ParseBool(os.Getenv("EXCLUDE_OUTLIERS"))
+
+	return cfg
+}
+
+// NewConfiguredWeightService creates new WeightService on top of the
+// repository with the configuration, or the error of loading its rules
+func NewConfiguredWeightService(wr models.Repository, cfg Config) (*WeightService, error) {
+	rules, err := LoadRules(cfg.RulesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	ws := NewWeightService(wr)
+	ws.PurgeAfterDays = cfg.PurgeAfterDays
+	ws.IdempotencyTTL = cfg.IdempotencyTTL
+	ws.ExcludeOutliers = cfg.ExcludeOutliers
+	ws.Rules = rules
+
+	return ws, nil
+}
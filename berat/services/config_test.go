@@ -0,0 +1,56 @@
+package services_test
+
+import (
+	"os"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/services"
+)
+
+// setenv sets the environment variables and returns the function
+// restoring them
+func setenv(values map[string]string) func() {
+	for key, value := range values {
+		os.Setenv(key, value)
+	}
+
+	return func() {
+		for key := range values {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+func (s *Suite) Test_ConfigFromEnv() {
+	defer setenv(map[string]string{
+		"TRASH_PURGE_DAYS":      "0",
+		"IDEMPOTENCY_TTL_HOURS": "2",
+		"EXCLUDE_OUTLIERS":      "true",
+		"RULES_FILE":            "rules.json",
+	})()
+
+	require.Equal(s.T(), services.Config{
+		PurgeAfterDays:  0,
+		IdempotencyTTL:  2 * time.Hour,
+		ExcludeOutliers: true,
+		RulesFile:       "rules.json",
+	}, services.ConfigFromEnv())
+}
+
+func (s *Suite) Test_ConfigFromEnv_When_Empty() {
+	require.Equal(s.T(), services.Config{PurgeAfterDays: services.DefaultPurgeAfterDays}, services.ConfigFromEnv())
+}
+
+func (s *Suite) Test_NewConfiguredWeightService() {
+	ws, err := services.NewConfiguredWeightService(s.repo, services.Config{PurgeAfterDays: 7, ExcludeOutliers: true})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 7, ws.PurgeAfterDays)
+	require.True(s.T(), ws.ExcludeOutliers)
+}
+
+func (s *Suite) Test_NewConfiguredWeightService_When_Rules_File_Is_Missing() {
+	_, err := services.NewConfiguredWeightService(s.repo, services.Config{RulesFile: "missing.json"})
+	require.Error(s.T(), err)
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/erizkiatama/berat/models"
+)
+
+const (
+	// ForecastLinear fits a straight line over all the weight data
+	ForecastLinear = "linear"
+
+	// ForecastHolt is Holt's linear trend, the exponential smoothing of the
+	// level and the trend, following recent changes more than the line
+	ForecastHolt = "holt"
+
+	// ForecastDays is how many days after today are forecast
+	ForecastDays = 90
+
+	// MinForecastHistory is the number of days needed to forecast
+	MinForecastHistory = 3
+
+	// ForecastLevel is the probability of the weight falling
+	// within the prediction interval of a day
+	ForecastLevel = 0.95
+
+	// forecastZ is the normal quantile of ForecastLevel
+	forecastZ = 1.96
+)
+
+// ForecastHorizons are the days after today highlighted in a forecast
+var ForecastHorizons = []int{30, 60, 90}
+
+// ErrNotEnoughHistory is returned when there are less
+// than MinForecastHistory days to forecast from
+var ErrNotEnoughHistory = errors.New("At least 3 days are needed to forecast")
+
+// Observation is the middle of the Max and Min of a day
+type Observation struct {
+	Date  string  `json:"date"`
+	Value float64 `json:"value"`
+}
+
+// ForecastPoint is the forecast middle of the Max and Min of a day Days
+// days after today, with its prediction interval from Lower to Upper
+type ForecastPoint struct {
+	Date  string  `json:"date"`
+	Days  int     `json:"days"`
+	Value float64 `json:"value"`
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+}
+
+// Forecast holds the forecast of every day after the last logged day
+// until ForecastDays days after today, and the ones ForecastHorizons days
+// after today in Horizons. History is what the forecast is made from
+type Forecast struct {
+	Model    string          `json:"model"`
+	Level    float64         `json:"level"`
+	History  []Observation   `json:"history"`
+	Points   []ForecastPoint `json:"points"`
+	Horizons []ForecastPoint `json:"horizons"`
+}
+
+// forecaster returns the value and the standard error
+// of the forecast h days after the last observation
+type forecaster func(h int) (value, stderr float64)
+
+// Forecast forecasts the weight from today with the model, ForecastLinear
+// when it is empty. The outliers are left out when ExcludeOutliers is true
+func (ws *WeightService) Forecast(model string, today time.Time) (*Forecast, error) {
+	if model == "" {
+		model = ForecastLinear
+	}
+
+	if model != ForecastLinear && model != ForecastHolt {
+		return nil, models.ValidationErrors{"model": "The model must be linear or holt"}
+	}
+
+	weights, err := ws.List()
+	if err != nil {
+		return nil, err
+	}
+
+	counted := *weights
+	if ws.ExcludeOutliers {
+		counted = ExcludeOutliers(counted, FindOutliers(counted))
+	}
+
+	return MakeForecast(counted, model, today)
+}
+
+// MakeForecast forecasts the weight data ordered by date with the model
+// from the day after the last weight data until ForecastDays days after
+// today. The weight data with an invalid date are left out
+func MakeForecast(weights []models.Weight, model string, today time.Time) (*Forecast, error) {
+	var values []datedValue
+	for _, weight := range weights {
+		if value, ok := valueOf(weight); ok {
+			values = append(values, value)
+		}
+	}
+
+	if len(values) < MinForecastHistory {
+		return nil, ErrNotEnoughHistory
+	}
+
+	forecast := &Forecast{Model: model, Level: ForecastLevel}
+	for _, value := range values {
+		forecast.History = append(forecast.History, Observation{
+			Date:  value.date.Format(models.DateLayout),
+			Value: value.value,
+		})
+	}
+
+	var predict forecaster
+	if model == ForecastHolt {
+		predict = holtForecaster(values)
+	} else {
+		predict = linearForecaster(values)
+	}
+
+	last := values[len(values)-1].date
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	end := today.AddDate(0, 0, ForecastDays)
+
+	horizons := map[int]bool{}
+	for _, days := range ForecastHorizons {
+		horizons[days] = true
+	}
+
+	for h, day := 1, last.AddDate(0, 0, 1); !day.After(end); h, day = h+1, day.AddDate(0, 0, 1) {
+		value, stderr := predict(h)
+		point := ForecastPoint{
+			Date:  day.Format(models.DateLayout),
+			Days:  dayCount(today, day),
+			Value: value,
+			Lower: value - forecastZ*stderr,
+			Upper: value + forecastZ*stderr,
+		}
+
+		forecast.Points = append(forecast.Points, point)
+		if horizons[point.Days] {
+			forecast.Horizons = append(forecast.Horizons, point)
+		}
+	}
+
+	return forecast, nil
+}
+
+// linearForecaster extends the least squares line, with the prediction
+// interval growing with the distance from the middle of the history
+func linearForecaster(values []datedValue) forecaster {
+	trend := fitTrend(values)
+
+	size := float64(len(values))
+	var meanX float64
+	for _, value := range values {
+		meanX += trend.days(value.date)
+	}
+	meanX /= size
+
+	var squaredErrors, spread float64
+	for _, value := range values {
+		x := trend.days(value.date)
+		residual := value.value - trend.at(value.date)
+		squaredErrors += residual * residual
+		spread += (x - meanX) * (x - meanX)
+	}
+	sigma := math.Sqrt(squaredErrors / (size - 2))
+
+	last := values[len(values)-1].date
+	return func(h int) (float64, float64) {
+		date := last.AddDate(0, 0, h)
+		x := trend.days(date)
+
+		leverage := 1 / size
+		if spread > 0 {
+			leverage += (x - meanX) * (x - meanX) / spread
+		}
+
+		return trend.at(date), sigma * math.Sqrt(1+leverage)
+	}
+}
+
+// holtForecaster smooths the level and the trend per day with the
+// parameters making the smallest one day ahead errors. The missing days
+// only carry the level along the trend
+func holtForecaster(values []datedValue) forecaster {
+	best := holt{sse: math.Inf(1)}
+	for a := 1; a <= 9; a++ {
+		for b := 1; b <= 9; b++ {
+			fit := fitHolt(values, float64(a)/10, float64(b)/10)
+			if fit.sse < best.sse {
+				best = fit
+			}
+		}
+	}
+
+	sigma := 0.0
+	if best.errors > 0 {
+		sigma = math.Sqrt(best.sse / float64(best.errors))
+	}
+
+	return func(h int) (float64, float64) {
+		variance := 1.0
+		for j := 1; j < h; j++ {
+			c := best.alpha * (1 + best.beta*float64(j))
+			variance += c * c
+		}
+
+		return best.level + float64(h)*best.trend, sigma * math.Sqrt(variance)
+	}
+}
+
+// holt is a fitted Holt's linear trend, with the level and
+// the trend at the last day and its one day ahead errors
+type holt struct {
+	alpha, beta  float64
+	level, trend float64
+	sse          float64
+	errors       int
+}
+
+func fitHolt(values []datedValue, alpha, beta float64) holt {
+	fit := holt{alpha: alpha, beta: beta, level: values[0].value}
+	if days := dayCount(values[0].date, values[1].date); days > 0 {
+		fit.trend = (values[1].value - values[0].value) / float64(days)
+	}
+
+	day := values[0].date
+	for _, value := range values[1:] {
+		for days := dayCount(day, value.date); days > 1; days-- {
+			fit.level += fit.trend
+		}
+		day = value.date
+
+		predicted := fit.level + fit.trend
+		e := value.value - predicted
+		fit.sse += e * e
+		fit.errors++
+
+		fit.level = predicted + alpha*e
+		fit.trend += alpha * beta * e
+	}
+
+	return fit
+}
+
+func dayCount(from, to time.Time) int {
+	return int(math.Round(to.Sub(from).Hours() / 24))
+}
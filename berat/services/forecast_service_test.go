@@ -0,0 +1,112 @@
+package services_test
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+// lineWeights returns a weight data every step days from 2020-11-01,
+// its middle of Max and Min rising by step each time
+func lineWeights(count, step int) []models.Weight {
+	weights := []models.Weight{}
+	start := time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < count; i++ {
+		weights = append(weights, models.Weight{
+			ID:   uint64(i + 1),
+			Date: start.AddDate(0, 0, i*step).Format(models.DateLayout),
+			Max:  61 + i*step,
+			Min:  59 + i*step,
+		})
+	}
+
+	return weights
+}
+
+func (s *Suite) Test_MakeForecast_Linear_Extend_The_Line() {
+	today := time.Date(2020, 11, 10, 8, 0, 0, 0, time.UTC)
+
+	forecast, err := services.MakeForecast(lineWeights(10, 1), services.ForecastLinear, today)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), services.ForecastLinear, forecast.Model)
+	require.Len(s.T(), forecast.History, 10)
+	require.Len(s.T(), forecast.Points, services.ForecastDays)
+
+	require.Len(s.T(), forecast.Horizons, 3)
+	horizon := forecast.Horizons[0]
+	require.Equal(s.T(), "2020-12-10", horizon.Date)
+	require.Equal(s.T(), 30, horizon.Days)
+	require.InDelta(s.T(), 99, horizon.Value, 0.001)
+	require.InDelta(s.T(), 99, horizon.Lower, 0.001)
+	require.InDelta(s.T(), 99, horizon.Upper, 0.001)
+	require.Equal(s.T(), 90, forecast.Horizons[2].Days)
+}
+
+func (s *Suite) Test_MakeForecast_Interval_Grow_With_Horizon() {
+	weights := lineWeights(10, 1)
+	weights[3].Max += 2
+	weights[6].Min -= 2
+
+	forecast, err := services.MakeForecast(weights, services.ForecastLinear, time.Date(2020, 11, 10, 0, 0, 0, 0, time.UTC))
+	require.NoError(s.T(), err)
+
+	near, far := forecast.Horizons[0], forecast.Horizons[2]
+	require.Less(s.T(), near.Lower, near.Value)
+	require.Greater(s.T(), near.Upper, near.Value)
+	require.Greater(s.T(), far.Upper-far.Lower, near.Upper-near.Lower)
+}
+
+func (s *Suite) Test_MakeForecast_Holt_Follow_Trend_Over_Missing_Days() {
+	today := time.Date(2020, 11, 19, 0, 0, 0, 0, time.UTC)
+
+	forecast, err := services.MakeForecast(lineWeights(10, 2), services.ForecastHolt, today)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "2020-11-20", forecast.Points[0].Date)
+	require.Equal(s.T(), 1, forecast.Points[0].Days)
+	require.InDelta(s.T(), 79, forecast.Points[0].Value, 0.001)
+	require.InDelta(s.T(), 168, forecast.Horizons[2].Value, 0.001)
+}
+
+func (s *Suite) Test_MakeForecast_Holt_Interval_Grow_With_Horizon() {
+	weights := lineWeights(20, 1)
+	for i := range weights {
+		if i%3 == 0 {
+			weights[i].Max++
+		}
+	}
+
+	forecast, err := services.MakeForecast(weights, services.ForecastHolt, time.Date(2020, 11, 20, 0, 0, 0, 0, time.UTC))
+	require.NoError(s.T(), err)
+
+	near, far := forecast.Horizons[0], forecast.Horizons[2]
+	require.Greater(s.T(), near.Upper, near.Value)
+	require.Greater(s.T(), far.Upper-far.Lower, near.Upper-near.Lower)
+}
+
+func (s *Suite) Test_MakeForecast_When_Not_Enough_History() {
+	forecast, err := services.MakeForecast(lineWeights(2, 1), services.ForecastLinear, time.Now())
+	require.Nil(s.T(), forecast)
+	require.Equal(s.T(), services.ErrNotEnoughHistory, err)
+}
+
+func (s *Suite) Test_Forecast_Default_To_Linear() {
+	s.repo.On("FindAll").Return(&[]models.Weight{
+		{Date: "2020-11-01", Max: 61, Min: 59},
+		{Date: "2020-11-02", Max: 62, Min: 60},
+		{Date: "2020-11-03", Max: 63, Min: 61},
+	}, nil).Once()
+
+	forecast, err := s.service.Forecast("", time.Date(2020, 11, 3, 0, 0, 0, 0, time.UTC))
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), services.ForecastLinear, forecast.Model)
+	require.InDelta(s.T(), 92, forecast.Horizons[0].Value, 0.001)
+}
+
+func (s *Suite) Test_Forecast_When_Model_Is_Unknown() {
+	forecast, err := s.service.Forecast("arima", time.Now())
+	require.Nil(s.T(), forecast)
+	require.Equal(s.T(), models.ValidationErrors{"model": "The model must be linear or holt"}, err)
+}
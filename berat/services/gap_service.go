@@ -0,0 +1,157 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/notify"
+)
+
+// MaxListedGaps is how many of the most recent missing dates are listed
+// on the index
+const MaxListedGaps = 7
+
+// Gaps is the logging history of the weight data until today. Missing
+// are the dates without weight data from the first logged date until
+// yesterday, the most recent first. Streak is the number of days logged
+// in a row until today, or until yesterday while today is not logged yet
+type Gaps struct {
+	Today       string   `json:"today"`
+	Missing     []string `json:"missing"`
+	Streak      int      `json:"streak"`
+	LoggedToday bool     `json:"logged_today"`
+}
+
+// FindGaps analyses the dates of the weight data until today,
+// the dates that could not be read are ignored
+func FindGaps(weights []models.Weight, today time.Time) Gaps {
+	today = startOfDay(today)
+	gaps := Gaps{Today: today.Format(models.DateLayout), Missing: []string{}}
+
+	logged := make(map[string]bool, len(weights))
+	var first time.Time
+	for _, weight := range weights {
+		date, err := time.ParseInLocation(models.DateLayout, weight.Date, today.Location())
+		if err != nil || date.After(today) {
+			continue
+		}
+
+		logged[weight.Date] = true
+		if first.IsZero() || date.Before(first) {
+			first = date
+		}
+	}
+
+	if first.IsZero() {
+		return gaps
+	}
+
+	gaps.LoggedToday = logged[today.Format(models.DateLayout)]
+
+	day := today
+	if !gaps.LoggedToday {
+		day = day.AddDate(0, 0, -1)
+	}
+	for logged[day.Format(models.DateLayout)] {
+		gaps.Streak++
+		day = day.AddDate(0, 0, -1)
+	}
+
+	for day := today.AddDate(0, 0, -1); !day.Before(first); day = day.AddDate(0, 0, -1) {
+		if date := day.Format(models.DateLayout); !logged[date] {
+			gaps.Missing = append(gaps.Missing, date)
+		}
+	}
+
+	return gaps
+}
+
+// Recent returns the most recent missing dates listed on the index
+func (g *Gaps) Recent() []string {
+	if len(g.Missing) > MaxListedGaps {
+		return g.Missing[:MaxListedGaps]
+	}
+
+	return g.Missing
+}
+
+// Gaps returns the logging history of all the weight data until today
+func (ws *WeightService) Gaps(today time.Time) (*Gaps, error) {
+	weights, err := ws.List()
+	if err != nil {
+		return nil, err
+	}
+
+	gaps := FindGaps(*weights, today)
+
+	return &gaps, nil
+}
+
+// Reminder notifies once a day when today has no weight data by the
+// time of the day At, which is the duration since midnight
+type Reminder struct {
+	Service  *WeightService
+	Notifier notify.Notifier
+	At       time.Duration
+
+	lastNotified string
+}
+
+// ParseReminderTime reads the time of the day in the HH:MM format
+// as the duration since midnight
+func ParseReminderTime(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid reminder time %q, it has to be HH:MM", value)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Next returns when the reminder has to be checked next after now
+func (rm *Reminder) Next(now time.Time) time.Time {
+	next := startOfDay(now).Add(rm.At)
+	if !next.After(now) {
+		next = startOfDay(now.AddDate(0, 0, 1)).Add(rm.At)
+	}
+
+	return next
+}
+
+// Check notifies when it is already past the reminder time, today has no
+// weight data and it did not notify today yet. It returns whether it did
+func (rm *Reminder) Check(now time.Time) (bool, error) {
+	today := now.Format(models.DateLayout)
+	if now.Before(startOfDay(now).Add(rm.At)) || rm.lastNotified == today {
+		return false, nil
+	}
+
+	gaps, err := rm.Service.Gaps(now)
+	if err != nil {
+		return false, err
+	}
+
+	if gaps.LoggedToday {
+		return false, nil
+	}
+
+	message := fmt.Sprintf("No weight logged for %s yet", today)
+	if gaps.Streak > 0 {
+		message += fmt.Sprintf(", log it to keep the %d days streak", gaps.Streak)
+	}
+
+	if err := rm.Notifier.Notify(message); err != nil {
+		return false, err
+	}
+
+	rm.lastNotified = today
+
+	return true, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
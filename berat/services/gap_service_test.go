@@ -0,0 +1,84 @@
+package services_test
+
+import (
+	"bytes"
+	"log"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/notify"
+	"github.com/erizkiatama/berat/services"
+)
+
+func (s *Suite) Test_FindGaps_Missing_Dates_And_Streak() {
+	weights := []models.Weight{
+		{Date: "2020-11-05"},
+		{Date: "2020-11-08"},
+		{Date: "2020-11-09"},
+		{Date: "2020-11-10"},
+		{Date: "11/10/2020"},
+		{Date: "2020-11-20"},
+	}
+	today := time.Date(2020, 11, 11, 21, 0, 0, 0, time.UTC)
+
+	gaps := services.FindGaps(weights, today)
+	require.Equal(s.T(), services.Gaps{
+		Today:   "2020-11-11",
+		Missing: []string{"2020-11-07", "2020-11-06"},
+		Streak:  3,
+	}, gaps)
+}
+
+func (s *Suite) Test_FindGaps_Streak_Include_Today() {
+	weights := []models.Weight{{Date: "2020-11-10"}, {Date: "2020-11-11"}}
+
+	gaps := services.FindGaps(weights, time.Date(2020, 11, 11, 7, 0, 0, 0, time.UTC))
+	require.True(s.T(), gaps.LoggedToday)
+	require.Equal(s.T(), 2, gaps.Streak)
+	require.Empty(s.T(), gaps.Missing)
+}
+
+func (s *Suite) Test_FindGaps_When_Nothing_Is_Logged() {
+	gaps := services.FindGaps(nil, time.Now())
+	require.Empty(s.T(), gaps.Missing)
+	require.Zero(s.T(), gaps.Streak)
+}
+
+func (s *Suite) Test_Reminder_Notify_Once_When_Today_Is_Not_Logged() {
+	var buf bytes.Buffer
+	reminder := &services.Reminder{
+		Service:  s.service,
+		Notifier: &notify.LogNotifier{Logger: log.New(&buf, "", 0)},
+		At:       20 * time.Hour,
+	}
+	s.repo.On("FindAll").Return(&[]models.Weight{{Date: "2020-11-10"}}, nil).Once()
+
+	notified, err := reminder.Check(time.Date(2020, 11, 11, 19, 0, 0, 0, time.UTC))
+	require.NoError(s.T(), err)
+	require.False(s.T(), notified)
+
+	notified, err = reminder.Check(time.Date(2020, 11, 11, 20, 0, 0, 0, time.UTC))
+	require.NoError(s.T(), err)
+	require.True(s.T(), notified)
+	require.Equal(s.T(), "No weight logged for 2020-11-11 yet, log it to keep the 1 days streak\n", buf.String())
+
+	notified, err = reminder.Check(time.Date(2020, 11, 11, 22, 0, 0, 0, time.UTC))
+	require.NoError(s.T(), err)
+	require.False(s.T(), notified)
+}
+
+func (s *Suite) Test_Reminder_Next_Tomorrow_When_Time_Passed() {
+	reminder := &services.Reminder{At: 20*time.Hour + 30*time.Minute}
+
+	require.Equal(s.T(), time.Date(2020, 11, 11, 20, 30, 0, 0, time.UTC),
+		reminder.Next(time.Date(2020, 11, 11, 8, 0, 0, 0, time.UTC)))
+	require.Equal(s.T(), time.Date(2020, 11, 12, 20, 30, 0, 0, time.UTC),
+		reminder.Next(time.Date(2020, 11, 11, 20, 30, 0, 0, time.UTC)))
+}
+
+func (s *Suite) Test_ParseReminderTime_When_Invalid() {
+	_, err := services.ParseReminderTime("8pm")
+	require.Error(s.T(), err)
+}
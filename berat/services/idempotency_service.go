@@ -0,0 +1,114 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/jinzhu/gorm"
+)
+
+var (
+	// ErrIdempotencyKeyReused is returned when an idempotency key
+	// is sent again with another request
+	ErrIdempotencyKeyReused = errors.New("Idempotency key was already used for another request")
+
+	// ErrIdempotencyKeyInProgress is returned when an idempotency key is
+	// sent again while its first request is still being handled
+	ErrIdempotencyKeyInProgress = errors.New("A request with this idempotency key is still in progress")
+)
+
+// DefaultIdempotencyTTL is how long the response of an idempotency key is
+// kept when WeightService.IdempotencyTTL is zero
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// ReserveIdempotencyKey reserves the key for the request identified by its
+// hash. It returns nil when the request should be handled, or the stored
+// key with the response to replay when the same request was already
+// handled. An expired key is reserved again as if it was never used
+func (ws *WeightService) ReserveIdempotencyKey(key, requestHash string, now time.Time) (*models.IdempotencyKey, error) {
+	stored, err := ws.findIdempotencyKey(key, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if stored == nil {
+		ik := &models.IdempotencyKey{Key: key, RequestHash: requestHash, ExpiresAt: now.Add(ws.idempotencyTTL())}
+		if err := ws.WeightRepo.SaveIdempotencyKey(ik); err == nil {
+			return nil, nil
+		}
+
+		// Another request reserved the key meanwhile
+		stored, err = ws.findIdempotencyKey(key, now)
+		if err != nil {
+			return nil, err
+		}
+
+		if stored == nil {
+			return nil, ErrIdempotencyKeyInProgress
+		}
+	}
+
+	if stored.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	if stored.Status == 0 {
+		return nil, ErrIdempotencyKeyInProgress
+	}
+
+	return stored, nil
+}
+
+// CompleteIdempotencyKey stores the response given to the request of the key
+func (ws *WeightService) CompleteIdempotencyKey(key string, status int, location, body string) error {
+	return ws.WeightRepo.UpdateIdempotencyKey(&models.IdempotencyKey{
+		Key:      key,
+		Status:   status,
+		Location: location,
+		Body:     body,
+	})
+}
+
+// ReleaseIdempotencyKey forgets the key, so the request could be retried.
+// It is used when the request failed on the server side
+func (ws *WeightService) ReleaseIdempotencyKey(key string) error {
+	return ws.WeightRepo.DeleteIdempotencyKey(key)
+}
+
+// PurgeExpiredIdempotencyKeys deletes the expired idempotency keys
+// and returns how many were deleted
+func (ws *WeightService) PurgeExpiredIdempotencyKeys(now time.Time) (int64, error) {
+	return ws.WeightRepo.DeleteExpiredIdempotencyKeys(now)
+}
+
+// findIdempotencyKey returns the stored key, or nil when it was never
+// used. An expired key is deleted and reported as never used
+func (ws *WeightService) findIdempotencyKey(key string, now time.Time) (*models.IdempotencyKey, error) {
+	stored, err := ws.WeightRepo.FindIdempotencyKey(key)
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if stored.ExpiresAt.After(now) {
+		return stored, nil
+	}
+
+	if err := ws.WeightRepo.DeleteIdempotencyKey(key); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (ws *WeightService) idempotencyTTL() time.Duration {
+	if ws.IdempotencyTTL <= 0 {
+		return DefaultIdempotencyTTL
+	}
+
+	return ws.IdempotencyTTL
+}
@@ -0,0 +1,69 @@
+package services_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+func (s *Suite) Test_ReserveIdempotencyKey_When_Key_Is_New() {
+	now := time.Date(2020, 11, 9, 7, 0, 0, 0, time.UTC)
+	s.repo.On("FindIdempotencyKey", "abc").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("SaveIdempotencyKey", &models.IdempotencyKey{
+		Key:         "abc",
+		RequestHash: "hash",
+		ExpiresAt:   now.Add(services.DefaultIdempotencyTTL),
+	}).Return(nil).Once()
+
+	stored, err := s.service.ReserveIdempotencyKey("abc", "hash", now)
+	require.NoError(s.T(), err)
+	require.Nil(s.T(), stored)
+}
+
+func (s *Suite) Test_ReserveIdempotencyKey_Replay_Same_Request() {
+	now := time.Now()
+	stored := &models.IdempotencyKey{Key: "abc", RequestHash: "hash", Status: 201, Body: "{}", ExpiresAt: now.Add(time.Hour)}
+	s.repo.On("FindIdempotencyKey", "abc").Return(stored, nil).Once()
+
+	res, err := s.service.ReserveIdempotencyKey("abc", "hash", now)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), stored, res)
+}
+
+func (s *Suite) Test_ReserveIdempotencyKey_When_Reused_For_Another_Request() {
+	now := time.Now()
+	s.repo.On("FindIdempotencyKey", "abc").
+		Return(&models.IdempotencyKey{Key: "abc", RequestHash: "hash", Status: 201, ExpiresAt: now.Add(time.Hour)}, nil).Once()
+
+	_, err := s.service.ReserveIdempotencyKey("abc", "other", now)
+	require.Equal(s.T(), services.ErrIdempotencyKeyReused, err)
+}
+
+func (s *Suite) Test_ReserveIdempotencyKey_When_Reserved_Meanwhile() {
+	now := time.Now()
+	s.repo.On("FindIdempotencyKey", "abc").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("SaveIdempotencyKey", mock.Anything).Return(errors.New("duplicate key value")).Once()
+	s.repo.On("FindIdempotencyKey", "abc").
+		Return(&models.IdempotencyKey{Key: "abc", RequestHash: "hash", ExpiresAt: now.Add(time.Hour)}, nil).Once()
+
+	_, err := s.service.ReserveIdempotencyKey("abc", "hash", now)
+	require.Equal(s.T(), services.ErrIdempotencyKeyInProgress, err)
+}
+
+func (s *Suite) Test_ReserveIdempotencyKey_When_Expired() {
+	now := time.Now()
+	s.repo.On("FindIdempotencyKey", "abc").
+		Return(&models.IdempotencyKey{Key: "abc", RequestHash: "other", Status: 201, ExpiresAt: now.Add(-time.Minute)}, nil).Once()
+	s.repo.On("DeleteIdempotencyKey", "abc").Return(nil).Once()
+	s.repo.On("SaveIdempotencyKey", mock.Anything).Return(nil).Once()
+
+	stored, err := s.service.ReserveIdempotencyKey("abc", "hash", now)
+	require.NoError(s.T(), err)
+	require.Nil(s.T(), stored)
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/jinzhu/gorm"
+)
+
+var (
+	// ErrMeasurementNotFound is returned when the measurement does not exist
+	ErrMeasurementNotFound = errors.New("Measurement not found")
+
+	// ErrMeasurementTypeNotFound is returned when the measurement type does not exist
+	ErrMeasurementTypeNotFound = errors.New("Measurement type not found")
+
+	// ErrDuplicateMeasurement is returned when a measurement of
+	// the same type already exists for the same date
+	ErrDuplicateMeasurement = errors.New("Measurement of this type already in the database for the date")
+)
+
+// MeasurementStats is the summary of the measurements of one type
+type MeasurementStats struct {
+	Type    models.MeasurementType `json:"type"`
+	Count   int                    `json:"count"`
+	Average float64                `json:"average"`
+}
+
+// MeasurementTypes returns all the measurement types ordered by name
+func (ws *WeightService) MeasurementTypes() (*[]models.MeasurementType, error) {
+	return ws.WeightRepo.FindAllMeasurementTypes()
+}
+
+// GetMeasurementType returns the measurement type based on the id,
+// or ErrMeasurementTypeNotFound when it does not exist
+func (ws *WeightService) GetMeasurementType(id uint64) (*models.MeasurementType, error) {
+	mt, err := ws.WeightRepo.FindMeasurementTypeByID(id)
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrMeasurementTypeNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return mt, nil
+}
+
+// CreateMeasurementType validates and saves a new measurement type
+func (ws *WeightService) CreateMeasurementType(mt *models.MeasurementType) (*models.MeasurementType, error) {
+	if err := mt.Validate(); err != nil {
+		return nil, err
+	}
+
+	return ws.WeightRepo.SaveMeasurementType(mt)
+}
+
+// UpdateMeasurementType validates and saves the new values of an existing
+// measurement type. Its existing measurements are kept as they are
+func (ws *WeightService) UpdateMeasurementType(id uint64, mt *models.MeasurementType) (*models.MeasurementType, error) {
+	mt.ID = id
+
+	if err := mt.Validate(); err != nil {
+		return nil, err
+	}
+
+	if _, err := ws.GetMeasurementType(id); err != nil {
+		return nil, err
+	}
+
+	return ws.WeightRepo.UpdateMeasurementType(id, mt)
+}
+
+// DeleteMeasurementType removes an existing measurement type
+// together with all of its measurements
+func (ws *WeightService) DeleteMeasurementType(id uint64) error {
+	if _, err := ws.GetMeasurementType(id); err != nil {
+		return err
+	}
+
+	return ws.WeightRepo.DeleteMeasurementType(id)
+}
+
+// SeedMeasurementTypes creates the default measurement types
+// whose name does not exist yet
+func (ws *WeightService) SeedMeasurementTypes() error {
+	types, err := ws.MeasurementTypes()
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool, len(*types))
+	for _, mt := range *types {
+		existing[mt.Name] = true
+	}
+
+	for _, mt := range models.DefaultMeasurementTypes {
+		if existing[mt.Name] {
+			continue
+		}
+
+		mt := mt
+		if _, err := ws.WeightRepo.SaveMeasurementType(&mt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Measurements returns all the measurements ordered by date
+func (ws *WeightService) Measurements() (*[]models.Measurement, error) {
+	return ws.WeightRepo.FindAllMeasurements()
+}
+
+// GetMeasurement returns the measurement based on the id,
+// or ErrMeasurementNotFound when it does not exist
+func (ws *WeightService) GetMeasurement(id uint64) (*models.Measurement, error) {
+	measurement, err := ws.WeightRepo.FindMeasurementByID(id)
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrMeasurementNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return measurement, nil
+}
+
+// CreateMeasurement validates the new measurement against the range of its
+// type, makes sure the type has no measurement on that date yet and saves it
+func (ws *WeightService) CreateMeasurement(measurement *models.Measurement) (*models.Measurement, error) {
+	if err := ws.validateMeasurement(measurement); err != nil {
+		return nil, err
+	}
+
+	if err := ws.checkMeasurementDate(0, measurement); err != nil {
+		return nil, err
+	}
+
+	return ws.WeightRepo.SaveMeasurement(measurement)
+}
+
+// UpdateMeasurement validates and saves the new values of an existing measurement
+func (ws *WeightService) UpdateMeasurement(id uint64, measurement *models.Measurement) (*models.Measurement, error) {
+	measurement.ID = id
+
+	if err := ws.validateMeasurement(measurement); err != nil {
+		return nil, err
+	}
+
+	if _, err := ws.GetMeasurement(id); err != nil {
+		return nil, err
+	}
+
+	if err := ws.checkMeasurementDate(id, measurement); err != nil {
+		return nil, err
+	}
+
+	return ws.WeightRepo.UpdateMeasurement(id, measurement)
+}
+
+// DeleteMeasurement removes an existing measurement,
+// or returns ErrMeasurementNotFound when it does not exist
+func (ws *WeightService) DeleteMeasurement(id uint64) error {
+	if _, err := ws.GetMeasurement(id); err != nil {
+		return err
+	}
+
+	return ws.WeightRepo.DeleteMeasurement(id)
+}
+
+// SummarizeMeasurements calculates the average of the measurements
+// of every type, in the same order as the given types
+func SummarizeMeasurements(types []models.MeasurementType, measurements []models.Measurement) []MeasurementStats {
+	stats := make([]MeasurementStats, len(types))
+	index := make(map[uint64]int, len(types))
+	for i, mt := range types {
+		stats[i].Type = mt
+		index[mt.ID] = i
+	}
+
+	for _, measurement := range measurements {
+		i, ok := index[measurement.TypeID]
+		if !ok {
+			continue
+		}
+
+		stats[i].Count++
+		stats[i].Average += measurement.Value
+	}
+
+	for i := range stats {
+		if stats[i].Count > 0 {
+			stats[i].Average /= float64(stats[i].Count)
+		}
+	}
+
+	return stats
+}
+
+// GroupMeasurements indexes the measurements by date and then by type id
+func GroupMeasurements(measurements []models.Measurement) map[string]map[uint64]*models.Measurement {
+	grouped := make(map[string]map[uint64]*models.Measurement)
+	for i := range measurements {
+		measurement := &measurements[i]
+		if grouped[measurement.Date] == nil {
+			grouped[measurement.Date] = make(map[uint64]*models.Measurement)
+		}
+
+		grouped[measurement.Date][measurement.TypeID] = measurement
+	}
+
+	return grouped
+}
+
+// validateMeasurement checks the measurement against its type, a type
+// that does not exist is reported as a failure of the type_id field
+func (ws *WeightService) validateMeasurement(measurement *models.Measurement) error {
+	mt, err := ws.GetMeasurementType(measurement.TypeID)
+	if err == ErrMeasurementTypeNotFound {
+		return models.ValidationErrors{"type_id": "Please choose a measurement type"}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return measurement.Validate(mt)
+}
+
+// checkMeasurementDate returns ErrDuplicateMeasurement when the type already
+// has a measurement other than the given id on the same date
+func (ws *WeightService) checkMeasurementDate(id uint64, measurement *models.Measurement) error {
+	found, err := ws.WeightRepo.FindMeasurementByTypeAndDate(measurement.TypeID, measurement.Date)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	if found != nil && found.ID != id {
+		return ErrDuplicateMeasurement
+	}
+
+	return nil
+}
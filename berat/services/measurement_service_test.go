@@ -0,0 +1,97 @@
+package services_test
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+func (s *Suite) bodyFat() *models.MeasurementType {
+	return &models.MeasurementType{ID: 1, Name: "Body Fat", Unit: "%", MinValue: 2, MaxValue: 75}
+}
+
+func (s *Suite) Test_CreateMeasurement_Success() {
+	measurement := &models.Measurement{TypeID: 1, Date: "2020-11-09", Value: 21.4}
+
+	s.repo.On("FindMeasurementTypeByID", uint64(1)).Return(s.bodyFat(), nil).Once()
+	s.repo.On("FindMeasurementByTypeAndDate", uint64(1), "2020-11-09").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("SaveMeasurement", measurement).Return(measurement, nil).Once()
+
+	res, err := s.service.CreateMeasurement(measurement)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), measurement, res)
+}
+
+func (s *Suite) Test_CreateMeasurement_When_Value_Out_Of_Range() {
+	measurement := &models.Measurement{TypeID: 1, Date: "2020-11-09", Value: 1}
+
+	s.repo.On("FindMeasurementTypeByID", uint64(1)).Return(s.bodyFat(), nil).Once()
+
+	res, err := s.service.CreateMeasurement(measurement)
+	require.Nil(s.T(), res)
+	require.Equal(s.T(), models.ValidationErrors{"value": "Body Fat must be between 2 and 75 %"}, err)
+}
+
+func (s *Suite) Test_CreateMeasurement_When_Type_Not_Found() {
+	measurement := &models.Measurement{TypeID: 9, Date: "2020-11-09", Value: 21.4}
+
+	s.repo.On("FindMeasurementTypeByID", uint64(9)).Return(&models.MeasurementType{}, gorm.ErrRecordNotFound).Once()
+
+	res, err := s.service.CreateMeasurement(measurement)
+	require.Nil(s.T(), res)
+	require.Equal(s.T(), models.ValidationErrors{"type_id": "Please choose a measurement type"}, err)
+}
+
+func (s *Suite) Test_CreateMeasurement_When_Date_Already_Taken() {
+	measurement := &models.Measurement{TypeID: 1, Date: "2020-11-09", Value: 21.4}
+
+	s.repo.On("FindMeasurementTypeByID", uint64(1)).Return(s.bodyFat(), nil).Once()
+	s.repo.On("FindMeasurementByTypeAndDate", uint64(1), "2020-11-09").
+		Return(&models.Measurement{ID: 4, TypeID: 1, Date: "2020-11-09", Value: 22}, nil).Once()
+
+	res, err := s.service.CreateMeasurement(measurement)
+	require.Nil(s.T(), res)
+	require.Equal(s.T(), services.ErrDuplicateMeasurement, err)
+}
+
+func (s *Suite) Test_UpdateMeasurement_When_Not_Found() {
+	measurement := &models.Measurement{TypeID: 1, Date: "2020-11-09", Value: 21.4}
+
+	s.repo.On("FindMeasurementTypeByID", uint64(1)).Return(s.bodyFat(), nil).Once()
+	s.repo.On("FindMeasurementByID", uint64(3)).Return(&models.Measurement{}, gorm.ErrRecordNotFound).Once()
+
+	res, err := s.service.UpdateMeasurement(3, measurement)
+	require.Nil(s.T(), res)
+	require.Equal(s.T(), services.ErrMeasurementNotFound, err)
+}
+
+func (s *Suite) Test_SeedMeasurementTypes_Create_Missing_Types_Only() {
+	existing := []models.MeasurementType{*s.bodyFat()}
+	s.repo.On("FindAllMeasurementTypes").Return(&existing, nil).Once()
+	for _, mt := range models.DefaultMeasurementTypes[1:] {
+		mt := mt
+		s.repo.On("SaveMeasurementType", &mt).Return(&mt, nil).Once()
+	}
+
+	err := s.service.SeedMeasurementTypes()
+	require.NoError(s.T(), err)
+	s.repo.AssertNumberOfCalls(s.T(), "SaveMeasurementType", len(models.DefaultMeasurementTypes)-1)
+}
+
+func (s *Suite) Test_SummarizeMeasurements_Per_Type() {
+	types := []models.MeasurementType{*s.bodyFat(), {ID: 2, Name: "Waist", Unit: "cm", MinValue: 40, MaxValue: 200}}
+	measurements := []models.Measurement{
+		{TypeID: 1, Date: "2020-11-09", Value: 20},
+		{TypeID: 2, Date: "2020-11-09", Value: 80},
+		{TypeID: 1, Date: "2020-11-10", Value: 21},
+		{TypeID: 9, Date: "2020-11-10", Value: 99},
+	}
+
+	stats := services.SummarizeMeasurements(types, measurements)
+	require.Equal(s.T(), []services.MeasurementStats{
+		{Type: types[0], Count: 2, Average: 20.5},
+		{Type: types[1], Count: 1, Average: 80},
+	}, stats)
+}
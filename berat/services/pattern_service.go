@@ -0,0 +1,160 @@
+package services
+
+import (
+	"time"
+
+	"github.com/erizkiatama/berat/models"
+)
+
+// PatternStats holds the averages of the weight data of a day of the week
+// or a month of the year. Deviation is how far the middle of Max and Min
+// of those days is from the overall trend on average, zero without data
+type PatternStats struct {
+	Name        string  `json:"name"`
+	Count       int     `json:"count"`
+	AverageMax  float64 `json:"average_max"`
+	AverageMin  float64 `json:"average_min"`
+	AverageDiff float64 `json:"average_difference"`
+	Deviation   float64 `json:"deviation"`
+}
+
+// Patterns groups the weight data by day of the week, from Monday, and by
+// month of the year. TrendPerDay is the slope of the overall trend, the
+// straight line fitted over the middle of Max and Min of every day
+type Patterns struct {
+	Count       int            `json:"count"`
+	TrendPerDay float64        `json:"trend_per_day"`
+	Weekdays    []PatternStats `json:"weekdays"`
+	Months      []PatternStats `json:"months"`
+}
+
+// trendLine is a straight line over the days since start
+type trendLine struct {
+	start     time.Time
+	intercept float64
+	slope     float64
+}
+
+// Patterns finds the weekday and month patterns of all the weight data.
+// The outliers are left out when ExcludeOutliers is true
+func (ws *WeightService) Patterns() (*Patterns, error) {
+	weights, err := ws.List()
+	if err != nil {
+		return nil, err
+	}
+
+	counted := *weights
+	if ws.ExcludeOutliers {
+		counted = ExcludeOutliers(counted, FindOutliers(counted))
+	}
+
+	patterns := FindPatterns(counted)
+	return &patterns, nil
+}
+
+// FindPatterns groups the weight data by day of the week and by month of
+// the year. The weight data with an invalid date are left out
+func FindPatterns(weights []models.Weight) Patterns {
+	var values []datedValue
+	var valid []models.Weight
+	for _, weight := range weights {
+		if value, ok := valueOf(weight); ok {
+			values = append(values, value)
+			valid = append(valid, weight)
+		}
+	}
+
+	trend := fitTrend(values)
+
+	weekdays := make([][]int, 7)
+	months := make([][]int, 12)
+	for i, value := range values {
+		weekday := (int(value.date.Weekday()) + 6) % 7
+		weekdays[weekday] = append(weekdays[weekday], i)
+
+		month := int(value.date.Month()) - 1
+		months[month] = append(months[month], i)
+	}
+
+	patterns := Patterns{
+		Count:       len(valid),
+		TrendPerDay: trend.slope,
+		Weekdays:    make([]PatternStats, 7),
+		Months:      make([]PatternStats, 12),
+	}
+
+	for i, indexes := range weekdays {
+		patterns.Weekdays[i] = summarizePattern(time.Weekday((i+1)%7).String(), indexes, valid, values, trend)
+	}
+
+	for i, indexes := range months {
+		patterns.Months[i] = summarizePattern(time.Month(i+1).String(), indexes, valid, values, trend)
+	}
+
+	return patterns
+}
+
+func summarizePattern(name string, indexes []int, weights []models.Weight, values []datedValue, trend trendLine) PatternStats {
+	group := make([]models.Weight, 0, len(indexes))
+	deviation := 0.0
+	for _, i := range indexes {
+		group = append(group, weights[i])
+		deviation += values[i].value - trend.at(values[i].date)
+	}
+
+	stats := Summarize(group)
+	if stats.Count > 0 {
+		deviation /= float64(stats.Count)
+	}
+
+	return PatternStats{
+		Name:        name,
+		Count:       stats.Count,
+		AverageMax:  stats.AverageMax,
+		AverageMin:  stats.AverageMin,
+		AverageDiff: stats.AverageDiff,
+		Deviation:   deviation,
+	}
+}
+
+// fitTrend fits a straight line over the values with least squares.
+// The line is flat at the average when the values are all on one day
+func fitTrend(values []datedValue) trendLine {
+	if len(values) == 0 {
+		return trendLine{}
+	}
+
+	trend := trendLine{start: values[0].date}
+	for _, value := range values {
+		if value.date.Before(trend.start) {
+			trend.start = value.date
+		}
+	}
+
+	size := float64(len(values))
+	var sumX, sumY, sumXX, sumXY float64
+	for _, value := range values {
+		x := trend.days(value.date)
+		sumX += x
+		sumY += value.value
+		sumXX += x * x
+		sumXY += x * value.value
+	}
+
+	if spread := size*sumXX - sumX*sumX; spread > 0 {
+		trend.slope = (size*sumXY - sumX*sumY) / spread
+	}
+	trend.intercept = (sumY - trend.slope*sumX) / size
+
+	return trend
+}
+
+// days returns the days from the start of the line until the date
+func (t trendLine) days(date time.Time) float64 {
+	return date.Sub(t.start).Hours() / 24
+}
+
+// at returns the value of the line at the date
+func (t trendLine) at(date time.Time) float64 {
+	return t.intercept + t.slope*t.days(date)
+}
@@ -0,0 +1,83 @@
+package services_test
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+// weekendWeights returns two weeks from Monday 2020-11-02
+// with a Max and Min 2 higher on the weekends
+func weekendWeights() []models.Weight {
+	weights := []models.Weight{}
+	start := time.Date(2020, 11, 2, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 14; i++ {
+		date := start.AddDate(0, 0, i)
+		weight := models.Weight{ID: uint64(i + 1), Date: date.Format(models.DateLayout), Max: 50, Min: 48, Difference: 2}
+		if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+			weight.Max, weight.Min = 52, 50
+		}
+		weights = append(weights, weight)
+	}
+
+	return weights
+}
+
+func (s *Suite) Test_FindPatterns_Weekend_Above_Trend() {
+	patterns := services.FindPatterns(weekendWeights())
+	require.Equal(s.T(), 14, patterns.Count)
+	require.Len(s.T(), patterns.Weekdays, 7)
+	require.Len(s.T(), patterns.Months, 12)
+
+	monday := patterns.Weekdays[0]
+	require.Equal(s.T(), "Monday", monday.Name)
+	require.Equal(s.T(), 2, monday.Count)
+	require.Equal(s.T(), 50.0, monday.AverageMax)
+	require.Less(s.T(), monday.Deviation, 0.0)
+
+	sunday := patterns.Weekdays[6]
+	require.Equal(s.T(), "Sunday", sunday.Name)
+	require.Equal(s.T(), 52.0, sunday.AverageMax)
+	require.Equal(s.T(), 50.0, sunday.AverageMin)
+	require.Equal(s.T(), 2.0, sunday.AverageDiff)
+	require.Greater(s.T(), sunday.Deviation, 1.0)
+
+	require.Equal(s.T(), "November", patterns.Months[10].Name)
+	require.Equal(s.T(), 14, patterns.Months[10].Count)
+	require.Zero(s.T(), patterns.Months[0].Count)
+	require.Zero(s.T(), patterns.Months[0].Deviation)
+}
+
+func (s *Suite) Test_FindPatterns_Deviation_Without_The_Trend() {
+	weights := []models.Weight{
+		{Date: "2020-11-02", Max: 50, Min: 50},
+		{Date: "2020-11-03", Max: 51, Min: 51},
+		{Date: "2020-11-04", Max: 52, Min: 52},
+		{Date: "2020-11-05", Max: 53, Min: 53},
+		{Date: "bad date", Max: 90, Min: 90},
+	}
+
+	patterns := services.FindPatterns(weights)
+	require.Equal(s.T(), 4, patterns.Count)
+	require.InDelta(s.T(), 1, patterns.TrendPerDay, 0.001)
+	for _, weekday := range patterns.Weekdays {
+		require.InDelta(s.T(), 0, weekday.Deviation, 0.001)
+	}
+}
+
+func (s *Suite) Test_Patterns_Leave_Out_Outliers() {
+	weights := weekendWeights()
+	weights[9].Max = 500
+	s.repo.On("FindAll").Return(&weights, nil).Once()
+
+	s.service.ExcludeOutliers = true
+	defer func() { s.service.ExcludeOutliers = false }()
+
+	patterns, err := s.service.Patterns()
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 13, patterns.Count)
+	require.Equal(s.T(), 50.0, patterns.Weekdays[2].AverageMax)
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/jinzhu/gorm"
+)
+
+// ErrProfileNotFound is returned when the profile is not filled yet
+var ErrProfileNotFound = errors.New("Profile not found")
+
+// AdultAge is the age from which the WHO adult BMI categories apply.
+// Younger people need the BMI-for-age charts, so they get no category
+const AdultAge = 20
+
+// BMI is the body mass index of a weight with its WHO category
+type BMI struct {
+	Value    float64 `json:"value"`
+	Category string  `json:"category,omitempty"`
+}
+
+// bmiCategories are the WHO adult BMI categories,
+// each one applies below its upper limit
+var bmiCategories = []struct {
+	Below float64
+	Name  string
+}{
+	{18.5, "Underweight"},
+	{25, "Normal weight"},
+	{30, "Pre-obesity"},
+	{35, "Obesity class I"},
+	{40, "Obesity class II"},
+}
+
+// Profile returns the profile, or ErrProfileNotFound when it is not filled yet
+func (ws *WeightService) Profile() (*models.Profile, error) {
+	profile, err := ws.WeightRepo.FindProfile()
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrProfileNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+// SaveProfile validates the profile and saves it,
+// replacing the values of the existing profile
+func (ws *WeightService) SaveProfile(profile *models.Profile) (*models.Profile, error) {
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+
+	existing, err := ws.Profile()
+	if err != nil && err != ErrProfileNotFound {
+		return nil, err
+	}
+
+	profile.ID = 0
+	if existing != nil {
+		profile.ID = existing.ID
+	}
+
+	return ws.WeightRepo.SaveProfile(profile)
+}
+
+// optionalProfile returns the profile, or nil when it is not filled yet
+func (ws *WeightService) optionalProfile() (*models.Profile, error) {
+	profile, err := ws.Profile()
+	if err == ErrProfileNotFound {
+		return nil, nil
+	}
+
+	return profile, err
+}
+
+// BMICategory returns the WHO adult category of the BMI value
+func BMICategory(value float64) string {
+	for _, category := range bmiCategories {
+		if value < category.Below {
+			return category.Name
+		}
+	}
+
+	return "Obesity class III"
+}
+
+// CalculateBMI calculates the BMI of the weight from the middle of its Max
+// and Min. The category is left empty when the person was not an adult
+// on the date of the weight. It returns nil without a profile
+func CalculateBMI(profile *models.Profile, weight models.Weight) *BMI {
+	if profile == nil || profile.Height <= 0 {
+		return nil
+	}
+
+	height := profile.Height / 100
+	bmi := &BMI{Value: float64(weight.Max+weight.Min) / 2 / (height * height)}
+
+	if profile.AgeOn(weight.Date) >= AdultAge {
+		bmi.Category = BMICategory(bmi.Value)
+	}
+
+	return bmi
+}
+
+// CalculateBMIs calculates the BMI of every weight, indexed by the weight id
+func CalculateBMIs(profile *models.Profile, weights []models.Weight) map[uint64]*BMI {
+	if profile == nil {
+		return nil
+	}
+
+	bmis := make(map[uint64]*BMI, len(weights))
+	for _, weight := range weights {
+		bmis[weight.ID] = CalculateBMI(profile, weight)
+	}
+
+	return bmis
+}
+
+// SummarizeBMI calculates the average BMI of the weights with the category
+// of that average. It returns nil without a profile or without weights
+func SummarizeBMI(profile *models.Profile, weights []models.Weight) *BMI {
+	if profile == nil || len(weights) == 0 {
+		return nil
+	}
+
+	average := new(BMI)
+	adult := true
+	for _, weight := range weights {
+		bmi := CalculateBMI(profile, weight)
+		if bmi == nil {
+			return nil
+		}
+
+		average.Value += bmi.Value
+		adult = adult && bmi.Category != ""
+	}
+
+	average.Value /= float64(len(weights))
+	if adult {
+		average.Category = BMICategory(average.Value)
+	}
+
+	return average
+}
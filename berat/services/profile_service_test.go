@@ -0,0 +1,74 @@
+package services_test
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+func (s *Suite) profile() *models.Profile {
+	return &models.Profile{ID: 1, Height: 160, BirthDate: "1990-03-15", Sex: models.SexMale}
+}
+
+func (s *Suite) Test_BMICategory_WHO_Limits() {
+	require.Equal(s.T(), "Underweight", services.BMICategory(18.4))
+	require.Equal(s.T(), "Normal weight", services.BMICategory(18.5))
+	require.Equal(s.T(), "Pre-obesity", services.BMICategory(25))
+	require.Equal(s.T(), "Obesity class I", services.BMICategory(34.9))
+	require.Equal(s.T(), "Obesity class II", services.BMICategory(35))
+	require.Equal(s.T(), "Obesity class III", services.BMICategory(40))
+}
+
+func (s *Suite) Test_CalculateBMI_From_Middle_Of_Max_And_Min() {
+	bmi := services.CalculateBMI(s.profile(), models.Weight{Date: "2020-11-09", Max: 66, Min: 64})
+	require.InDelta(s.T(), 25.39, bmi.Value, 0.001)
+	require.Equal(s.T(), "Pre-obesity", bmi.Category)
+}
+
+func (s *Suite) Test_CalculateBMI_Without_Category_Before_Adult_Age() {
+	bmi := services.CalculateBMI(s.profile(), models.Weight{Date: "2005-11-09", Max: 65, Min: 63})
+	require.InDelta(s.T(), 25, bmi.Value, 0.001)
+	require.Empty(s.T(), bmi.Category)
+}
+
+func (s *Suite) Test_CalculateBMI_Without_Profile() {
+	require.Nil(s.T(), services.CalculateBMI(nil, models.Weight{Date: "2020-11-09", Max: 65, Min: 63}))
+}
+
+func (s *Suite) Test_SaveProfile_Replace_Existing_Profile() {
+	profile := &models.Profile{Height: 172, BirthDate: "1990-03-15", Sex: models.SexMale}
+
+	s.repo.On("FindProfile").Return(s.profile(), nil).Once()
+	s.repo.On("SaveProfile", &models.Profile{ID: 1, Height: 172, BirthDate: "1990-03-15", Sex: models.SexMale}).
+		Return(profile, nil).Once()
+
+	res, err := s.service.SaveProfile(profile)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), uint64(1), res.ID)
+}
+
+func (s *Suite) Test_Stats_Include_Average_BMI() {
+	weights := []models.Weight{
+		{ID: 1, Date: "2020-11-09", Max: 66, Min: 64, Difference: 2},
+		{ID: 2, Date: "2020-11-10", Max: 59, Min: 57, Difference: 2},
+	}
+	s.repo.On("FindAll").Return(&weights, nil).Once()
+	s.repo.On("FindAllMeasurementTypes").Return(&[]models.MeasurementType{}, nil).Once()
+	s.repo.On("FindAllMeasurements").Return(&[]models.Measurement{}, nil).Once()
+	s.repo.On("FindProfile").Return(s.profile(), nil).Once()
+
+	stats, err := s.service.Stats()
+	require.NoError(s.T(), err)
+	require.InDelta(s.T(), 24.0234, stats.AverageBMI.Value, 0.0001)
+	require.Equal(s.T(), "Normal weight", stats.AverageBMI.Category)
+}
+
+func (s *Suite) Test_Profile_When_Not_Filled() {
+	s.repo.On("FindProfile").Return(nil, gorm.ErrRecordNotFound).Once()
+
+	res, err := s.service.Profile()
+	require.Nil(s.T(), res)
+	require.Equal(s.T(), services.ErrProfileNotFound, err)
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/jinzhu/gorm"
+)
+
+// ErrReadingNotFound is returned when the reading does not exist
+var ErrReadingNotFound = errors.New("Reading not found")
+
+// ErrDayEnteredByHand is returned when a reading is taken on a day whose
+// weight data was entered by hand, so its Max and Min are not overwritten
+var ErrDayEnteredByHand = errors.New("The weight of this day was entered by hand, readings could only be added to days made of readings")
+
+// ErrDayMadeOfReadings is returned when the weight data of a day made of
+// readings is changed by hand, as the next reading change would undo it
+var ErrDayMadeOfReadings = errors.New("The weight of this day is derived from its readings, please change the readings instead")
+
+// Readings returns all the readings behind the weight data of given id
+func (ws *WeightService) Readings(weightID uint64) (*[]models.Reading, error) {
+	return ws.WeightRepo.FindReadingsByWeightID(weightID)
+}
+
+// GetReading returns the reading based on the id,
+// or ErrReadingNotFound when it does not exist
+func (ws *WeightService) GetReading(id uint64) (*models.Reading, error) {
+	reading, err := ws.WeightRepo.FindReadingByID(id)
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrReadingNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return reading, nil
+}
+
+// AddReading saves a new reading to the weight data of its day, creating
+// the weight data when it is the first reading of the day, and derives
+// the Max, Min and Difference of that day again, all in one transaction.
+// It returns RuleErrors
+// when the day with the reading fails the rules, and ErrDayEnteredByHand
+// when the day was entered by hand
+func (ws *WeightService) AddReading(reading *models.Reading) (*models.Reading, error) {
+	if err := reading.Validate(); err != nil {
+		return nil, err
+	}
+
+	var newReading *models.Reading
+	err := ws.transaction(func(tx *WeightService) error {
+		weight, err := tx.dayOf(reading)
+		if err != nil {
+			return err
+		}
+
+		reading.WeightID = weight.ID
+
+		if newReading, err = tx.WeightRepo.SaveReading(reading); err != nil {
+			return err
+		}
+
+		return tx.derive(weight.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newReading, nil
+}
+
+// UpdateReading changes an existing reading. When the reading moves to
+// another day both days are derived again, in the same transaction. It returns RuleErrors when the
+// day with the reading fails the rules, and ErrDayEnteredByHand when the
+// reading moves to a day entered by hand
+func (ws *WeightService) UpdateReading(id uint64, reading *models.Reading) (*models.Reading, error) {
+	reading.ID = id
+
+	if err := reading.Validate(); err != nil {
+		return nil, err
+	}
+
+	old, err := ws.GetReading(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var newReading *models.Reading
+	err = ws.transaction(func(tx *WeightService) error {
+		weight, err := tx.dayOf(reading)
+		if err != nil {
+			return err
+		}
+
+		reading.WeightID = weight.ID
+
+		if newReading, err = tx.WeightRepo.UpdateReading(id, reading); err != nil {
+			return err
+		}
+
+		if err := tx.derive(weight.ID); err != nil {
+			return err
+		}
+
+		if old.WeightID != weight.ID {
+			return tx.derive(old.WeightID)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newReading, nil
+}
+
+// DeleteReading removes an existing reading and derives its day again
+// in one transaction.
+// It returns the id of the weight data the reading belonged to
+func (ws *WeightService) DeleteReading(id uint64) (uint64, error) {
+	old, err := ws.GetReading(id)
+	if err != nil {
+		return 0, err
+	}
+
+	err = ws.transaction(func(tx *WeightService) error {
+		if err := tx.WeightRepo.DeleteReading(id); err != nil {
+			return err
+		}
+
+		return tx.derive(old.WeightID)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return old.WeightID, nil
+}
+
+// dayOf returns the weight data of the day the reading was taken,
+// creating it from the reading when the day has no weight data yet.
+// The day as derived with the reading is checked against the rules
+// before anything is written, and it returns RuleErrors when it fails.
+// A weight data without readings was entered by hand, and it returns
+// ErrDayEnteredByHand for it
+func (ws *WeightService) dayOf(reading *models.Reading) (*models.Weight, error) {
+	weight, err := ws.WeightRepo.FindByDate(reading.Date())
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	day := &models.Weight{Date: reading.Date(), Max: reading.Value, Min: reading.Value}
+	if weight != nil {
+		readings, err := ws.WeightRepo.FindReadingsByWeightID(weight.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(*readings) == 0 {
+			return nil, ErrDayEnteredByHand
+		}
+
+		for _, other := range *readings {
+			if reading.ID != 0 && other.ID == reading.ID {
+				continue
+			}
+
+			if other.Value > day.Max {
+				day.Max = other.Value
+			}
+
+			if other.Value < day.Min {
+				day.Min = other.Value
+			}
+		}
+	}
+
+	day.CalculateDifference()
+	if err := ws.CheckRules(day); err != nil {
+		return nil, err
+	}
+
+	if weight != nil {
+		return weight, nil
+	}
+
+	return ws.WeightRepo.Save(&models.Weight{
+		Date: reading.Date(),
+		Max:  reading.Value,
+		Min:  reading.Value,
+	})
+}
+
+// checkEnteredByHand returns ErrDayMadeOfReadings when the
+// weight data of given id has readings
+func (ws *WeightService) checkEnteredByHand(weightID uint64) error {
+	readings, err := ws.WeightRepo.FindReadingsByWeightID(weightID)
+	if err != nil {
+		return err
+	}
+
+	if len(*readings) > 0 {
+		return ErrDayMadeOfReadings
+	}
+
+	return nil
+}
+
+// derive calculates the Max, Min and Difference of the weight data from
+// its readings. The weight data is moved to the trash once it has no
+// readings left, as only the days made of readings have readings
+func (ws *WeightService) derive(weightID uint64) error {
+	readings, err := ws.WeightRepo.FindReadingsByWeightID(weightID)
+	if err != nil {
+		return err
+	}
+
+	if len(*readings) == 0 {
+		return ws.WeightRepo.Delete(weightID)
+	}
+
+	weight, err := ws.Get(weightID)
+	if err != nil {
+		return err
+	}
+
+	weight.Max = (*readings)[0].Value
+	weight.Min = (*readings)[0].Value
+	for _, reading := range *readings {
+		if reading.Value > weight.Max {
+			weight.Max = reading.Value
+		}
+
+		if reading.Value < weight.Min {
+			weight.Min = reading.Value
+		}
+	}
+
+	weight.CalculateDifference()
+
+	_, err = ws.WeightRepo.Update(weightID, weight)
+
+	return err
+}
@@ -0,0 +1,201 @@
+package services_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+func (s *Suite) readingAt(day, hour, value int) models.Reading {
+	return models.Reading{
+		TakenAt: time.Date(2020, 11, day, hour, 0, 0, 0, time.UTC),
+		Value:   value,
+	}
+}
+
+func (s *Suite) Test_AddReading_Derive_Max_Min_Of_Existing_Day() {
+	day := &models.Weight{ID: 1, Date: "2020-11-09", Max: 50, Min: 50}
+	reading := s.readingAt(9, 20, 52)
+	readings := []models.Reading{s.readingAt(9, 7, 50), s.readingAt(9, 13, 49), reading}
+
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("FindByDate", "2020-11-09").Return(day, nil).Once()
+	s.repo.On("FindReadingsByWeightID", uint64(1)).Return(&[]models.Reading{readings[0], readings[1]}, nil).Once()
+	s.repo.On("SaveReading", &reading).Return(&reading, nil).Once()
+	s.repo.On("FindReadingsByWeightID", uint64(1)).Return(&readings, nil).Once()
+	s.repo.On("FindByID", uint64(1)).Return(day, nil).Once()
+	s.repo.On("Update", uint64(1), &models.Weight{ID: 1, Date: "2020-11-09", Max: 52, Min: 49, Difference: 3}).
+		Return(day, nil).Once()
+
+	res, err := s.service.AddReading(&reading)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), uint64(1), res.WeightID)
+}
+
+func (s *Suite) Test_AddReading_When_Deriving_The_Day_Fails() {
+	day := &models.Weight{ID: 1, Date: "2020-11-09", Max: 50, Min: 50}
+	reading := s.readingAt(9, 20, 52)
+	readings := []models.Reading{s.readingAt(9, 7, 50), reading}
+
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("FindByDate", "2020-11-09").Return(day, nil).Once()
+	s.repo.On("FindReadingsByWeightID", uint64(1)).Return(&[]models.Reading{readings[0]}, nil).Once()
+	s.repo.On("SaveReading", &reading).Return(&reading, nil).Once()
+	s.repo.On("FindReadingsByWeightID", uint64(1)).Return(&readings, nil).Once()
+	s.repo.On("FindByID", uint64(1)).Return(day, nil).Once()
+	s.repo.On("Update", uint64(1), &models.Weight{ID: 1, Date: "2020-11-09", Max: 52, Min: 50, Difference: 2}).
+		Return(&models.Weight{}, errors.New("connection reset")).Once()
+
+	res, err := s.service.AddReading(&reading)
+	require.Nil(s.T(), res)
+	require.EqualError(s.T(), err, "connection reset")
+}
+
+func (s *Suite) Test_AddReading_When_Reading_Is_Invalid() {
+	res, err := s.service.AddReading(&models.Reading{})
+	require.Nil(s.T(), res)
+	require.IsType(s.T(), models.ValidationErrors{}, err)
+}
+
+func (s *Suite) Test_AddReading_When_Day_Was_Entered_By_Hand() {
+	day := &models.Weight{ID: 1, Date: "2020-11-09", Max: 80, Min: 78}
+	reading := s.readingAt(9, 20, 52)
+
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("FindByDate", "2020-11-09").Return(day, nil).Once()
+	s.repo.On("FindReadingsByWeightID", uint64(1)).Return(&[]models.Reading{}, nil).Once()
+
+	res, err := s.service.AddReading(&reading)
+	require.Nil(s.T(), res)
+	require.Equal(s.T(), services.ErrDayEnteredByHand, err)
+	s.repo.AssertNotCalled(s.T(), "SaveReading", &reading)
+}
+
+func (s *Suite) Test_AddReading_When_Day_With_Reading_Fails_Rules() {
+	defer s.withRules(services.Rules{MaxDifference: 3})()
+	day := &models.Weight{ID: 1, Date: "2020-11-09", Max: 50, Min: 49, Difference: 1}
+	reading := s.readingAt(9, 20, 54)
+
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("FindByDate", "2020-11-09").Return(day, nil).Once()
+	s.repo.On("FindReadingsByWeightID", uint64(1)).
+		Return(&[]models.Reading{s.readingAt(9, 7, 50), s.readingAt(9, 13, 49)}, nil).Once()
+
+	res, err := s.service.AddReading(&reading)
+	require.Nil(s.T(), res)
+	require.Equal(s.T(), services.RuleErrors{services.RuleMaxDifference: "Difference could not be more than 3"}, err)
+}
+
+func (s *Suite) Test_AddReading_When_First_Reading_Fails_Rules_Does_Not_Create_The_Day() {
+	defer s.withRules(services.Rules{MaxWeight: 150})()
+	reading := s.readingAt(9, 20, 160)
+
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("FindByDate", "2020-11-09").Return(nil, gorm.ErrRecordNotFound).Once()
+
+	res, err := s.service.AddReading(&reading)
+	require.Nil(s.T(), res)
+	require.Equal(s.T(), services.RuleErrors{services.RuleMaxWeight: "Max weight could not be more than 150"}, err)
+}
+
+func (s *Suite) Test_UpdateReading_Moved_To_Another_Day_Derive_Both_Days() {
+	oldDay := &models.Weight{ID: 1, Date: "2020-11-09", Max: 52, Min: 49, Difference: 3}
+	newDay := &models.Weight{ID: 2, Date: "2020-11-10", Max: 51, Min: 51}
+	reading := s.readingAt(10, 7, 48)
+	newDayReadings := []models.Reading{reading, s.readingAt(10, 20, 51)}
+	oldDayReadings := []models.Reading{s.readingAt(9, 7, 50)}
+
+	s.repo.On("FindReadingByID", uint64(3)).Return(&models.Reading{ID: 3, WeightID: 1}, nil).Once()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("FindByDate", "2020-11-10").Return(newDay, nil).Once()
+	s.repo.On("FindReadingsByWeightID", uint64(2)).Return(&[]models.Reading{s.readingAt(10, 20, 51)}, nil).Once()
+	s.repo.On("UpdateReading", uint64(3), &reading).Return(&reading, nil).Once()
+	s.repo.On("FindReadingsByWeightID", uint64(2)).Return(&newDayReadings, nil).Once()
+	s.repo.On("FindByID", uint64(2)).Return(newDay, nil).Once()
+	s.repo.On("Update", uint64(2), &models.Weight{ID: 2, Date: "2020-11-10", Max: 51, Min: 48, Difference: 3}).
+		Return(newDay, nil).Once()
+	s.repo.On("FindReadingsByWeightID", uint64(1)).Return(&oldDayReadings, nil).Once()
+	s.repo.On("FindByID", uint64(1)).Return(oldDay, nil).Once()
+	s.repo.On("Update", uint64(1), &models.Weight{ID: 1, Date: "2020-11-09", Max: 50, Min: 50, Difference: 0}).
+		Return(oldDay, nil).Once()
+
+	res, err := s.service.UpdateReading(3, &reading)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), uint64(2), res.WeightID)
+}
+
+func (s *Suite) Test_UpdateReading_When_Moved_To_Day_Entered_By_Hand() {
+	reading := s.readingAt(10, 7, 48)
+
+	s.repo.On("FindReadingByID", uint64(3)).Return(&models.Reading{ID: 3, WeightID: 1}, nil).Once()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("FindByDate", "2020-11-10").Return(&models.Weight{ID: 2, Date: "2020-11-10", Max: 80, Min: 78}, nil).Once()
+	s.repo.On("FindReadingsByWeightID", uint64(2)).Return(&[]models.Reading{}, nil).Once()
+
+	res, err := s.service.UpdateReading(3, &reading)
+	require.Nil(s.T(), res)
+	require.Equal(s.T(), services.ErrDayEnteredByHand, err)
+}
+
+func (s *Suite) Test_UpdateReading_When_Day_With_Reading_Fails_Rules() {
+	defer s.withRules(services.Rules{MaxDifference: 3})()
+	day := &models.Weight{ID: 1, Date: "2020-11-09", Max: 56, Min: 50, Difference: 6}
+	reading := s.readingAt(9, 20, 55)
+	reading.ID = 3
+	old := s.readingAt(9, 20, 56)
+	old.ID = 3
+	other := s.readingAt(9, 7, 50)
+	other.ID = 2
+
+	s.repo.On("FindReadingByID", uint64(3)).Return(&models.Reading{ID: 3, WeightID: 1}, nil).Once()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("FindByDate", "2020-11-09").Return(day, nil).Once()
+	s.repo.On("FindReadingsByWeightID", uint64(1)).Return(&[]models.Reading{other, old}, nil).Once()
+
+	res, err := s.service.UpdateReading(3, &reading)
+	require.Nil(s.T(), res)
+	require.Equal(s.T(), services.RuleErrors{services.RuleMaxDifference: "Difference could not be more than 3"}, err)
+}
+
+func (s *Suite) Test_UpdateReading_When_Reading_Not_Found() {
+	reading := s.readingAt(10, 7, 48)
+	s.repo.On("FindReadingByID", uint64(3)).Return(&models.Reading{}, gorm.ErrRecordNotFound).Once()
+
+	res, err := s.service.UpdateReading(3, &reading)
+	require.Equal(s.T(), services.ErrReadingNotFound, err)
+	require.Nil(s.T(), res)
+}
+
+func (s *Suite) Test_DeleteReading_Derive_Remaining_Readings() {
+	day := &models.Weight{ID: 1, Date: "2020-11-09", Max: 52, Min: 49, Difference: 3}
+	readings := []models.Reading{s.readingAt(9, 7, 50), s.readingAt(9, 13, 49)}
+
+	s.repo.On("FindReadingByID", uint64(3)).Return(&models.Reading{ID: 3, WeightID: 1}, nil).Once()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("DeleteReading", uint64(3)).Return(nil).Once()
+	s.repo.On("FindReadingsByWeightID", uint64(1)).Return(&readings, nil).Once()
+	s.repo.On("FindByID", uint64(1)).Return(day, nil).Once()
+	s.repo.On("Update", uint64(1), &models.Weight{ID: 1, Date: "2020-11-09", Max: 50, Min: 49, Difference: 1}).
+		Return(day, nil).Once()
+
+	weightID, err := s.service.DeleteReading(3)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), uint64(1), weightID)
+}
+
+func (s *Suite) Test_DeleteReading_Last_Reading_Delete_The_Day() {
+	s.repo.On("FindReadingByID", uint64(3)).Return(&models.Reading{ID: 3, WeightID: 1}, nil).Once()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("DeleteReading", uint64(3)).Return(nil).Once()
+	s.repo.On("FindReadingsByWeightID", uint64(1)).Return(&[]models.Reading{}, nil).Once()
+	s.repo.On("Delete", uint64(1)).Return(nil).Once()
+
+	_, err := s.service.DeleteReading(3)
+	require.NoError(s.T(), err)
+	s.repo.AssertNotCalled(s.T(), "Purge", uint64(1))
+}
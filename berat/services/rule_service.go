@@ -0,0 +1,151 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/erizkiatama/berat/models"
+)
+
+// Names of the plausibility rules, used as the keys of RuleErrors
+const (
+	RuleMinWeight      = "min_weight"
+	RuleMaxWeight      = "max_weight"
+	RuleMaxDifference  = "max_difference"
+	RuleMaxDailyChange = "max_daily_change"
+	RuleNoFutureDates  = "no_future_dates"
+)
+
+// Rules are the plausibility rules of a deployment, checked on top of
+// Weight.Validate. A zero value turns the rule off. MinWeight and MaxWeight
+// bound the Max and Min, MaxDifference bounds the Difference of a day and
+// MaxDailyChange bounds the change of the Max and Min per day since the
+// previous weight data
+type Rules struct {
+	MinWeight      int  `json:"min_weight"`
+	MaxWeight      int  `json:"max_weight"`
+	MaxDifference  int  `json:"max_difference"`
+	MaxDailyChange int  `json:"max_daily_change"`
+	NoFutureDates  bool `json:"no_future_dates"`
+}
+
+// RuleErrors holds the failed plausibility rules, the message
+// of each one keyed by the rule name
+type RuleErrors map[string]string
+
+// Error joins all the failures, ordered by rule name, into one message
+func (re RuleErrors) Error() string {
+	rules := make([]string, 0, len(re))
+	for rule := range re {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+
+	messages := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		messages = append(messages, re[rule])
+	}
+
+	return strings.Join(messages, ", ")
+}
+
+// LoadRules reads the rules from the JSON file, the rules are all
+// turned off when the path is empty
+func LoadRules(path string) (Rules, error) {
+	var rules Rules
+	if path == "" {
+		return rules, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return rules, err
+	}
+	defer f.Close()
+
+	decoder := json.NewDecoder(f)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rules); err != nil {
+		return rules, fmt.Errorf("Invalid rules file %s: %s", path, err)
+	}
+
+	return rules, nil
+}
+
+// CheckRules checks the valid weight data against the rules of the service
+// and returns RuleErrors with every failed rule, or nil
+func (ws *WeightService) CheckRules(weight *models.Weight) error {
+	rules := ws.Rules
+	errs := RuleErrors{}
+
+	if rules.MinWeight > 0 && weight.Min < rules.MinWeight {
+		errs[RuleMinWeight] = fmt.Sprintf("Min weight could not be less than %d", rules.MinWeight)
+	}
+
+	if rules.MaxWeight > 0 && weight.Max > rules.MaxWeight {
+		errs[RuleMaxWeight] = fmt.Sprintf("Max weight could not be more than %d", rules.MaxWeight)
+	}
+
+	if rules.MaxDifference > 0 && weight.Max-weight.Min > rules.MaxDifference {
+		errs[RuleMaxDifference] = fmt.Sprintf("Difference could not be more than %d", rules.MaxDifference)
+	}
+
+	date, err := time.ParseInLocation(models.DateLayout, weight.Date, time.Local)
+	if rules.NoFutureDates && err == nil && date.After(startOfDay(time.Now())) {
+		errs[RuleNoFutureDates] = "Date could not be in the future"
+	}
+
+	if rules.MaxDailyChange > 0 && err == nil {
+		previous, err := ws.WeightRepo.FindPrevious(weight.Date)
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+
+		if err == nil {
+			if message := dailyChangeRule(rules.MaxDailyChange, previous, weight, date); message != "" {
+				errs[RuleMaxDailyChange] = message
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// dailyChangeRule returns the failure of the max daily change rule, the
+// change allowed grows with the days since the previous weight data
+func dailyChangeRule(maxChange int, previous, weight *models.Weight, date time.Time) string {
+	previousDate, err := time.ParseInLocation(models.DateLayout, previous.Date, time.Local)
+	if err != nil {
+		return ""
+	}
+
+	days := int(date.Sub(previousDate).Hours()/24 + 0.5)
+	if days < 1 {
+		days = 1
+	}
+
+	allowed := maxChange * days
+	if abs(weight.Max-previous.Max) <= allowed && abs(weight.Min-previous.Min) <= allowed {
+		return ""
+	}
+
+	return fmt.Sprintf("Max and min could not change more than %d since %s", allowed, previous.Date)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
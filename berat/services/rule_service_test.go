@@ -0,0 +1,101 @@
+package services_test
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+func (s *Suite) withRules(rules services.Rules) func() {
+	s.service.Rules = rules
+
+	return func() { s.service.Rules = services.Rules{} }
+}
+
+func (s *Suite) Test_CheckRules_Report_Every_Failed_Rule() {
+	defer s.withRules(services.Rules{MinWeight: 40, MaxWeight: 150, MaxDifference: 5, NoFutureDates: true})()
+
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(models.DateLayout)
+	err := s.service.CheckRules(&models.Weight{Date: tomorrow, Max: 160, Min: 30})
+	require.Equal(s.T(), services.RuleErrors{
+		services.RuleMinWeight:     "Min weight could not be less than 40",
+		services.RuleMaxWeight:     "Max weight could not be more than 150",
+		services.RuleMaxDifference: "Difference could not be more than 5",
+		services.RuleNoFutureDates: "Date could not be in the future",
+	}, err)
+}
+
+func (s *Suite) Test_CheckRules_Allow_More_Change_After_Missing_Days() {
+	defer s.withRules(services.Rules{MaxDailyChange: 2})()
+	s.repo.On("FindPrevious", "2020-11-12").Return(&models.Weight{Date: "2020-11-09", Max: 50, Min: 48}, nil).Once()
+	s.repo.On("FindPrevious", "2020-11-10").Return(&models.Weight{Date: "2020-11-09", Max: 50, Min: 48}, nil).Once()
+
+	require.NoError(s.T(), s.service.CheckRules(&models.Weight{Date: "2020-11-12", Max: 55, Min: 53}))
+
+	err := s.service.CheckRules(&models.Weight{Date: "2020-11-10", Max: 55, Min: 53})
+	require.Equal(s.T(), services.RuleErrors{
+		services.RuleMaxDailyChange: "Max and min could not change more than 2 since 2020-11-09",
+	}, err)
+}
+
+func (s *Suite) Test_CheckRules_When_No_Previous_Weight() {
+	defer s.withRules(services.Rules{MaxDailyChange: 2})()
+	s.repo.On("FindPrevious", "2020-11-09").Return(nil, gorm.ErrRecordNotFound).Once()
+
+	require.NoError(s.T(), s.service.CheckRules(&models.Weight{Date: "2020-11-09", Max: 50, Min: 48}))
+}
+
+func (s *Suite) Test_Create_When_Rule_Fails() {
+	defer s.withRules(services.Rules{MaxWeight: 150})()
+
+	_, err := s.service.Create(&models.Weight{Date: "2020-11-09", Max: 750, Min: 72})
+	require.IsType(s.T(), services.RuleErrors{}, err)
+}
+
+func (s *Suite) Test_CreateAll_Report_Failed_Rules_Of_The_Row() {
+	defer s.withRules(services.Rules{MaxWeight: 150})()
+
+	report, err := s.service.CreateAll(services.BulkBestEffort, []services.BulkRow{
+		{Line: 1, Weight: &models.Weight{Date: "2020-11-09", Max: 750, Min: 72}},
+	})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), services.BulkInvalid, report.Results[0].Status)
+	require.Equal(s.T(), "Max weight could not be more than 150", report.Results[0].Errors[services.RuleMaxWeight])
+}
+
+func (s *Suite) Test_LoadRules_From_File() {
+	f, err := ioutil.TempFile("", "rules")
+	require.NoError(s.T(), err)
+	defer os.Remove(f.Name())
+
+	f.WriteString(`{"min_weight": 30, "max_daily_change": 2, "no_future_dates": true}`)
+	f.Close()
+
+	rules, err := services.LoadRules(f.Name())
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), services.Rules{MinWeight: 30, MaxDailyChange: 2, NoFutureDates: true}, rules)
+}
+
+func (s *Suite) Test_LoadRules_When_Rule_Is_Unknown() {
+	f, err := ioutil.TempFile("", "rules")
+	require.NoError(s.T(), err)
+	defer os.Remove(f.Name())
+
+	f.WriteString(`{"max_weigth": 300}`)
+	f.Close()
+
+	_, err = services.LoadRules(f.Name())
+	require.Error(s.T(), err)
+}
+
+func (s *Suite) Test_LoadRules_When_Path_Is_Empty() {
+	rules, err := services.LoadRules("")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), services.Rules{}, rules)
+}
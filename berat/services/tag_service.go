@@ -0,0 +1,106 @@
+package services
+
+import (
+	"github.com/erizkiatama/berat/models"
+)
+
+// TagStats compares the weight data with a tag against the weight data
+// without it. DiffDelta is how much bigger the average Difference of
+// the tagged days is, it is zero when one of the groups is empty
+type TagStats struct {
+	Tag                 string  `json:"tag"`
+	TaggedCount         int     `json:"tagged_count"`
+	TaggedAverageDiff   float64 `json:"tagged_average_difference"`
+	UntaggedCount       int     `json:"untagged_count"`
+	UntaggedAverageDiff float64 `json:"untagged_average_difference"`
+	DiffDelta           float64 `json:"difference_delta"`
+}
+
+// Tags returns all the tags ordered by name
+func (ws *WeightService) Tags() (*[]models.Tag, error) {
+	return ws.WeightRepo.FindAllTags()
+}
+
+// LoadTags fills the tag names of every weight data in place
+func (ws *WeightService) LoadTags(weights []models.Weight) error {
+	names, err := ws.WeightRepo.FindWeightTagNames()
+	if err != nil {
+		return err
+	}
+
+	for i := range weights {
+		weights[i].Tags = names[weights[i].ID]
+	}
+
+	return nil
+}
+
+// TagReport compares the average Difference of the days
+// with and without each tag, in the order of the tag names
+func (ws *WeightService) TagReport() ([]TagStats, error) {
+	weights, err := ws.List()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ws.LoadTags(*weights); err != nil {
+		return nil, err
+	}
+
+	tags, err := ws.Tags()
+	if err != nil {
+		return nil, err
+	}
+
+	report := make([]TagStats, 0, len(*tags))
+	for _, tag := range *tags {
+		tagged := FilterByTag(*weights, tag.Name)
+		untagged := make([]models.Weight, 0, len(*weights)-len(tagged))
+		for _, weight := range *weights {
+			if !hasTag(weight, tag.Name) {
+				untagged = append(untagged, weight)
+			}
+		}
+
+		taggedStats := Summarize(tagged)
+		untaggedStats := Summarize(untagged)
+
+		stats := TagStats{
+			Tag:                 tag.Name,
+			TaggedCount:         taggedStats.Count,
+			TaggedAverageDiff:   taggedStats.AverageDiff,
+			UntaggedCount:       untaggedStats.Count,
+			UntaggedAverageDiff: untaggedStats.AverageDiff,
+		}
+
+		if stats.TaggedCount > 0 && stats.UntaggedCount > 0 {
+			stats.DiffDelta = stats.TaggedAverageDiff - stats.UntaggedAverageDiff
+		}
+
+		report = append(report, stats)
+	}
+
+	return report, nil
+}
+
+// FilterByTag returns the weight data having the tag
+func FilterByTag(weights []models.Weight, tag string) []models.Weight {
+	filtered := []models.Weight{}
+	for _, weight := range weights {
+		if hasTag(weight, tag) {
+			filtered = append(filtered, weight)
+		}
+	}
+
+	return filtered
+}
+
+func hasTag(weight models.Weight, tag string) bool {
+	for _, name := range weight.Tags {
+		if name == tag {
+			return true
+		}
+	}
+
+	return false
+}
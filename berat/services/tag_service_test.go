@@ -0,0 +1,52 @@
+package services_test
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+func (s *Suite) Test_Create_Save_Tags_Of_New_Weight() {
+	s.weight.Tags = []string{"ate out"}
+
+	s.repo.On("FindByDate", s.weight.Date).Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("Save", s.weight).Return(&models.Weight{ID: 7}, nil).Once()
+	s.repo.On("SetWeightTags", uint64(7), []string{"ate out"}).Return(nil).Once()
+
+	res, err := s.service.Create(s.weight)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), uint64(7), res.ID)
+}
+
+func (s *Suite) Test_TagReport_Compare_Tagged_And_Untagged_Days() {
+	weights := []models.Weight{
+		{ID: 1, Date: "2020-11-09", Max: 52, Min: 48, Difference: 4},
+		{ID: 2, Date: "2020-11-10", Max: 50, Min: 49, Difference: 1},
+		{ID: 3, Date: "2020-11-11", Max: 51, Min: 49, Difference: 2},
+	}
+	s.repo.On("FindAll").Return(&weights, nil).Once()
+	s.repo.On("FindWeightTagNames").Return(map[uint64][]string{1: {"ate out"}, 3: {"ate out", "sick"}}, nil).Once()
+	s.repo.On("FindAllTags").Return(&[]models.Tag{{ID: 1, Name: "ate out"}, {ID: 2, Name: "sick"}}, nil).Once()
+
+	report, err := s.service.TagReport()
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []services.TagStats{
+		{Tag: "ate out", TaggedCount: 2, TaggedAverageDiff: 3, UntaggedCount: 1, UntaggedAverageDiff: 1, DiffDelta: 2},
+		{Tag: "sick", TaggedCount: 1, TaggedAverageDiff: 2, UntaggedCount: 2, UntaggedAverageDiff: 2.5, DiffDelta: -0.5},
+	}, report)
+}
+
+func (s *Suite) Test_FilterByTag() {
+	weights := []models.Weight{
+		{ID: 1, Tags: []string{"ate out"}},
+		{ID: 2},
+		{ID: 3, Tags: []string{"sick", "ate out"}},
+	}
+
+	filtered := services.FilterByTag(weights, "ate out")
+	require.Len(s.T(), filtered, 2)
+	require.Equal(s.T(), uint64(3), filtered[1].ID)
+}
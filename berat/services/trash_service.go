@@ -0,0 +1,70 @@
+package services
+
+import (
+	"time"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/jinzhu/gorm"
+)
+
+// Trash returns all the deleted weight data, the most recently deleted first
+func (ws *WeightService) Trash() (*[]models.Weight, error) {
+	return ws.WeightRepo.FindDeleted()
+}
+
+// GetDeleted returns the deleted weight data based on the id,
+// or ErrNotFound when it is not in the trash
+func (ws *WeightService) GetDeleted(id uint64) (*models.Weight, error) {
+	weight, err := ws.WeightRepo.FindDeletedByID(id)
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return weight, nil
+}
+
+// Restore takes the weight data out of the trash. It returns
+// ErrDuplicateDate when another weight data took its date meanwhile
+func (ws *WeightService) Restore(id uint64) (*models.Weight, error) {
+	weight, err := ws.GetDeleted(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ws.checkDate(id, weight.Date); err != nil {
+		return nil, err
+	}
+
+	if err := ws.WeightRepo.Restore(id); err != nil {
+		return nil, err
+	}
+
+	weight.DeletedAt = nil
+
+	return weight, nil
+}
+
+// Purge permanently deletes the weight data in the trash
+// together with its readings, there is no way back
+func (ws *WeightService) Purge(id uint64) error {
+	if _, err := ws.GetDeleted(id); err != nil {
+		return err
+	}
+
+	return ws.WeightRepo.Purge(id)
+}
+
+// PurgeExpired permanently deletes the weight data that have been in the
+// trash for more than PurgeAfterDays days and returns how many were
+// deleted. Nothing is deleted when PurgeAfterDays is zero
+func (ws *WeightService) PurgeExpired(now time.Time) (int64, error) {
+	if ws.PurgeAfterDays <= 0 {
+		return 0, nil
+	}
+
+	return ws.WeightRepo.PurgeDeletedBefore(now.AddDate(0, 0, -ws.PurgeAfterDays))
+}
@@ -0,0 +1,56 @@
+package services_test
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/services"
+)
+
+func (s *Suite) Test_Restore_When_Date_Is_Free() {
+	deletedAt := time.Now()
+	s.repo.On("FindDeletedByID", uint64(1)).
+		Return(&models.Weight{ID: 1, Date: "2020-11-09", DeletedAt: &deletedAt}, nil).Once()
+	s.repo.On("FindByDate", "2020-11-09").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("Restore", uint64(1)).Return(nil).Once()
+
+	weight, err := s.service.Restore(1)
+	require.NoError(s.T(), err)
+	require.Nil(s.T(), weight.DeletedAt)
+}
+
+func (s *Suite) Test_Restore_When_Date_Taken_By_Another_Weight() {
+	s.repo.On("FindDeletedByID", uint64(1)).Return(&models.Weight{ID: 1, Date: "2020-11-09"}, nil).Once()
+	s.repo.On("FindByDate", "2020-11-09").Return(&models.Weight{ID: 2, Date: "2020-11-09"}, nil).Once()
+
+	_, err := s.service.Restore(1)
+	require.Equal(s.T(), services.ErrDuplicateDate, err)
+}
+
+func (s *Suite) Test_Purge_When_Not_In_Trash() {
+	s.repo.On("FindDeletedByID", uint64(1)).Return(&models.Weight{}, gorm.ErrRecordNotFound).Once()
+
+	err := s.service.Purge(1)
+	require.Equal(s.T(), services.ErrNotFound, err)
+}
+
+func (s *Suite) Test_PurgeExpired_After_Retention_Days() {
+	s.service.PurgeAfterDays = 30
+	defer func() { s.service.PurgeAfterDays = 0 }()
+
+	now := time.Date(2020, 11, 12, 8, 0, 0, 0, time.UTC)
+	s.repo.On("PurgeDeletedBefore", time.Date(2020, 10, 13, 8, 0, 0, 0, time.UTC)).Return(int64(2), nil).Once()
+
+	purged, err := s.service.PurgeExpired(now)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), int64(2), purged)
+}
+
+func (s *Suite) Test_PurgeExpired_When_Disabled() {
+	purged, err := s.service.PurgeExpired(time.Now())
+	require.NoError(s.T(), err)
+	require.Zero(s.T(), purged)
+}
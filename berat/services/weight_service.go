@@ -0,0 +1,300 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/jinzhu/gorm"
+)
+
+var (
+	// ErrNotFound is returned when the weight data does not exist
+	ErrNotFound = errors.New("Weight not found")
+
+	// ErrDuplicateDate is returned when another weight data
+	// already exists for the same date
+	ErrDuplicateDate = errors.New("Weight already in the database")
+)
+
+// Stats is the aggregated summary of a list of weight data
+// and of the body measurements of every type. AverageBMI is only
+// filled when the profile is filled. ExcludedOutliers is the number of
+// outliers left out of the averages
+type Stats struct {
+	Count            int                `json:"count"`
+	ExcludedOutliers int                `json:"excluded_outliers"`
+	AverageMax       float64            `json:"average_max"`
+	AverageMin       float64            `json:"average_min"`
+	AverageDiff      float64            `json:"average_difference"`
+	AverageBMI       *BMI               `json:"average_bmi,omitempty"`
+	Measurements     []MeasurementStats `json:"measurements"`
+}
+
+// WeightService holds the business rules of the weight data, so the
+// HTML handlers, the JSON API and the command line tools behave the same.
+// Deleted weight data stay in the trash for PurgeAfterDays days,
+// or until they are purged by hand when it is zero. The responses of
+// idempotency keys are kept for IdempotencyTTL, DefaultIdempotencyTTL
+// when it is zero. The outliers are left out of the averages when
+// ExcludeOutliers is true. The weight data saved must follow Rules
+type WeightService struct {
+	WeightRepo      models.Repository
+	PurgeAfterDays  int
+	IdempotencyTTL  time.Duration
+	ExcludeOutliers bool
+	Rules           Rules
+}
+
+// NewWeightService creates new WeightService on top of the repository
+func NewWeightService(wr models.Repository) *WeightService {
+	return &WeightService{WeightRepo: wr}
+}
+
+// transaction runs fn with a copy of the service on the repository of one
+// transaction, committed when fn returns nil and rolled back otherwise
+func (ws *WeightService) transaction(fn func(tx *WeightService) error) error {
+	return ws.WeightRepo.Transaction(func(repo models.Repository) error {
+		tx := *ws
+		tx.WeightRepo = repo
+		return fn(&tx)
+	})
+}
+
+// List returns all the weight data ordered by date
+func (ws *WeightService) List() (*[]models.Weight, error) {
+	return ws.WeightRepo.FindAll()
+}
+
+// Get returns the weight data based on the id,
+// or ErrNotFound when it does not exist
+func (ws *WeightService) Get(id uint64) (*models.Weight, error) {
+	weight, err := ws.WeightRepo.FindByID(id)
+	if err == gorm.ErrRecordNotFound {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return weight, nil
+}
+
+// Create validates the new weight data, makes sure its date is not taken
+// yet and saves it with its tags. Invalid data is reported as
+// models.ValidationErrors and the failed rules as RuleErrors
+func (ws *WeightService) Create(weight *models.Weight) (*models.Weight, error) {
+	weight.CalculateDifference()
+
+	if err := weight.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := ws.CheckRules(weight); err != nil {
+		return nil, err
+	}
+
+	if err := ws.checkDate(0, weight.Date); err != nil {
+		return nil, err
+	}
+
+	var newWeight *models.Weight
+	err := ws.WeightRepo.Transaction(func(repo models.Repository) error {
+		var err error
+		if newWeight, err = repo.Save(weight); err != nil {
+			return err
+		}
+
+		if len(weight.Tags) > 0 {
+			return repo.SetWeightTags(newWeight.ID, weight.Tags)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newWeight, nil
+}
+
+// Update validates the new values of an existing weight data, makes sure
+// the date is not taken by another weight data and saves the changes.
+// The tags of the weight data are replaced by the given ones. A day made
+// of readings could not be changed by hand, ErrDayMadeOfReadings is
+// returned for it
+func (ws *WeightService) Update(id uint64, weight *models.Weight) (*models.Weight, error) {
+	weight.ID = id
+	weight.CalculateDifference()
+
+	if err := weight.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := ws.CheckRules(weight); err != nil {
+		return nil, err
+	}
+
+	if _, err := ws.Get(id); err != nil {
+		return nil, err
+	}
+
+	if err := ws.checkEnteredByHand(id); err != nil {
+		return nil, err
+	}
+
+	if err := ws.checkDate(id, weight.Date); err != nil {
+		return nil, err
+	}
+
+	var newWeight *models.Weight
+	err := ws.WeightRepo.Transaction(func(repo models.Repository) error {
+		var err error
+		if newWeight, err = repo.Update(id, weight); err != nil {
+			return err
+		}
+
+		return repo.SetWeightTags(id, weight.Tags)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newWeight, nil
+}
+
+// Upsert validates the weight data of the date and saves it with its
+// tags in one transaction, creating it when the date has no weight data
+// yet or replacing all the values and tags of the existing one. created
+// tells which one happened. The date of the weight must be empty or equal
+// to the date, and ErrDayMadeOfReadings is returned when the existing
+// weight data is made of readings
+func (ws *WeightService) Upsert(date string, weight *models.Weight) (newWeight *models.Weight, created bool, err error) {
+	if _, err := time.Parse(models.DateLayout, date); err != nil {
+		return nil, false, models.ValidationErrors{"date": "Please fill the date correctly (YYYY-MM-DD)"}
+	}
+
+	if weight.Date != "" && weight.Date != date {
+		return nil, false, models.ValidationErrors{"date": "The date must be the same as the date in the address"}
+	}
+
+	weight.Date = date
+	weight.CalculateDifference()
+
+	if err := weight.Validate(); err != nil {
+		return nil, false, err
+	}
+
+	if err := ws.CheckRules(weight); err != nil {
+		return nil, false, err
+	}
+
+	existing, err := ws.WeightRepo.FindByDate(date)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, false, err
+	}
+
+	if existing != nil {
+		if err := ws.checkEnteredByHand(existing.ID); err != nil {
+			return nil, false, err
+		}
+	}
+
+	err = ws.WeightRepo.Transaction(func(repo models.Repository) error {
+		var err error
+		if created, err = repo.UpsertByDate(weight); err != nil {
+			return err
+		}
+
+		return repo.SetWeightTags(weight.ID, weight.Tags)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return weight, created, nil
+}
+
+// Delete moves an existing weight data to the trash,
+// or returns ErrNotFound when it does not exist
+func (ws *WeightService) Delete(id uint64) error {
+	if _, err := ws.Get(id); err != nil {
+		return err
+	}
+
+	return ws.WeightRepo.Delete(id)
+}
+
+// Stats returns the summary of all the weight data and measurements,
+// with the average BMI when the profile is filled
+func (ws *WeightService) Stats() (*Stats, error) {
+	weights, err := ws.List()
+	if err != nil {
+		return nil, err
+	}
+
+	types, err := ws.MeasurementTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	measurements, err := ws.Measurements()
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := ws.optionalProfile()
+	if err != nil {
+		return nil, err
+	}
+
+	counted := *weights
+	if ws.ExcludeOutliers {
+		counted = ExcludeOutliers(counted, FindOutliers(counted))
+	}
+
+	stats := Summarize(counted)
+	stats.ExcludedOutliers = len(*weights) - len(counted)
+	stats.AverageBMI = SummarizeBMI(profile, counted)
+	stats.Measurements = SummarizeMeasurements(*types, *measurements)
+
+	return &stats, nil
+}
+
+// Summarize calculates the averages of the given weight data.
+// All averages are zero when there is no data
+func Summarize(weights []models.Weight) Stats {
+	stats := Stats{Count: len(weights)}
+	if stats.Count == 0 {
+		return stats
+	}
+
+	for _, weight := range weights {
+		stats.AverageMax += float64(weight.Max)
+		stats.AverageMin += float64(weight.Min)
+		stats.AverageDiff += float64(weight.Difference)
+	}
+
+	size := float64(stats.Count)
+	stats.AverageMax /= size
+	stats.AverageMin /= size
+	stats.AverageDiff /= size
+
+	return stats
+}
+
+// checkDate returns ErrDuplicateDate when the date already
+// belongs to a weight data other than the given id
+func (ws *WeightService) checkDate(id uint64, date string) error {
+	found, err := ws.WeightRepo.FindByDate(date)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	if found != nil && found.ID != id {
+		return ErrDuplicateDate
+	}
+
+	return nil
+}
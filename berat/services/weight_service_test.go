@@ -0,0 +1,233 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/erizkiatama/berat/models"
+	"github.com/erizkiatama/berat/models/mocks"
+	"github.com/erizkiatama/berat/services"
+)
+
+type Suite struct {
+	suite.Suite
+	repo    *mocks.WeightRepository
+	service *services.WeightService
+	weight  *models.Weight
+}
+
+func (s *Suite) SetupSuite() {
+	s.repo = new(mocks.WeightRepository)
+	s.service = services.NewWeightService(s.repo)
+}
+
+func (s *Suite) BeforeTest(_, _ string) {
+	s.weight = &models.Weight{
+		Date: "2020-11-09",
+		Max:  50,
+		Min:  48,
+	}
+}
+
+func (s *Suite) AfterTest(_, _ string) {
+	s.repo.AssertExpectations(s.T())
+}
+
+func TestInit(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+func (s *Suite) Test_Get_When_Weight_Not_Found() {
+	s.repo.On("FindByID", uint64(1)).Return(&models.Weight{}, gorm.ErrRecordNotFound).Once()
+
+	res, err := s.service.Get(1)
+	require.Equal(s.T(), services.ErrNotFound, err)
+	require.Nil(s.T(), res)
+}
+
+func (s *Suite) Test_Get_When_Database_Error() {
+	newError := errors.New("connection refused")
+	s.repo.On("FindByID", uint64(1)).Return(&models.Weight{}, newError).Once()
+
+	res, err := s.service.Get(1)
+	require.Equal(s.T(), newError, err)
+	require.Nil(s.T(), res)
+}
+
+func (s *Suite) Test_Create_Calculate_Difference_And_Save() {
+	s.repo.On("FindByDate", s.weight.Date).Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("Save", s.weight).Return(s.weight, nil).Once()
+
+	res, err := s.service.Create(s.weight)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 2, res.Difference)
+}
+
+func (s *Suite) Test_Create_When_Weight_Is_Invalid() {
+	s.weight.Max = s.weight.Min - 1
+
+	res, err := s.service.Create(s.weight)
+	require.Nil(s.T(), res)
+	require.IsType(s.T(), models.ValidationErrors{}, err)
+}
+
+func (s *Suite) Test_Create_When_Date_Already_Taken() {
+	s.repo.On("FindByDate", s.weight.Date).Return(&models.Weight{ID: 3}, nil).Once()
+
+	res, err := s.service.Create(s.weight)
+	require.Equal(s.T(), services.ErrDuplicateDate, err)
+	require.Nil(s.T(), res)
+}
+
+func (s *Suite) Test_Create_When_FindByDate_Is_Error() {
+	newError := errors.New("connection refused")
+	s.repo.On("FindByDate", s.weight.Date).Return(nil, newError).Once()
+
+	res, err := s.service.Create(s.weight)
+	require.Equal(s.T(), newError, err)
+	require.Nil(s.T(), res)
+}
+
+func (s *Suite) Test_Update_Keep_Own_Date() {
+	s.repo.On("FindByID", uint64(1)).Return(&models.Weight{ID: 1}, nil).Once()
+	s.repo.On("FindReadingsByWeightID", uint64(1)).Return(&[]models.Reading{}, nil).Once()
+	s.repo.On("FindByDate", s.weight.Date).Return(&models.Weight{ID: 1}, nil).Once()
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("Update", uint64(1), s.weight).Return(s.weight, nil).Once()
+	s.repo.On("SetWeightTags", uint64(1), []string(nil)).Return(nil).Once()
+
+	res, err := s.service.Update(1, s.weight)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), uint64(1), res.ID)
+	require.Equal(s.T(), 2, res.Difference)
+}
+
+func (s *Suite) Test_Update_When_Date_Belongs_To_Another_Weight() {
+	s.repo.On("FindByID", uint64(1)).Return(&models.Weight{ID: 1}, nil).Once()
+	s.repo.On("FindReadingsByWeightID", uint64(1)).Return(&[]models.Reading{}, nil).Once()
+	s.repo.On("FindByDate", s.weight.Date).Return(&models.Weight{ID: 2}, nil).Once()
+
+	res, err := s.service.Update(1, s.weight)
+	require.Equal(s.T(), services.ErrDuplicateDate, err)
+	require.Nil(s.T(), res)
+}
+
+func (s *Suite) Test_Update_When_Day_Is_Made_Of_Readings() {
+	s.repo.On("FindByID", uint64(1)).Return(&models.Weight{ID: 1}, nil).Once()
+	s.repo.On("FindReadingsByWeightID", uint64(1)).Return(&[]models.Reading{{ID: 3, WeightID: 1}}, nil).Once()
+
+	res, err := s.service.Update(1, s.weight)
+	require.Nil(s.T(), res)
+	require.Equal(s.T(), services.ErrDayMadeOfReadings, err)
+}
+
+func (s *Suite) Test_Update_When_Weight_Not_Found() {
+	s.repo.On("FindByID", uint64(1)).Return(&models.Weight{}, gorm.ErrRecordNotFound).Once()
+
+	res, err := s.service.Update(1, s.weight)
+	require.Equal(s.T(), services.ErrNotFound, err)
+	require.Nil(s.T(), res)
+}
+
+func (s *Suite) Test_Update_When_Weight_Is_Invalid() {
+	s.weight.Date = ""
+
+	res, err := s.service.Update(1, s.weight)
+	require.Nil(s.T(), res)
+	require.IsType(s.T(), models.ValidationErrors{}, err)
+}
+
+func (s *Suite) Test_Delete_When_Weight_Exist() {
+	s.repo.On("FindByID", uint64(1)).Return(&models.Weight{ID: 1}, nil).Once()
+	s.repo.On("Delete", uint64(1)).Return(nil).Once()
+
+	err := s.service.Delete(1)
+	require.NoError(s.T(), err)
+}
+
+func (s *Suite) Test_Delete_When_Weight_Not_Found() {
+	s.repo.On("FindByID", uint64(1)).Return(&models.Weight{}, gorm.ErrRecordNotFound).Once()
+
+	err := s.service.Delete(1)
+	require.Equal(s.T(), services.ErrNotFound, err)
+}
+
+func (s *Suite) Test_Stats_Average_All_Weights() {
+	weights := []models.Weight{
+		{Date: "2020-11-09", Max: 50, Min: 48, Difference: 2},
+		{Date: "2020-11-10", Max: 53, Min: 49, Difference: 4},
+	}
+	types := []models.MeasurementType{{ID: 1, Name: "Body Fat", Unit: "%", MinValue: 2, MaxValue: 75}}
+	measurements := []models.Measurement{
+		{ID: 1, TypeID: 1, Date: "2020-11-09", Value: 20.5},
+		{ID: 2, TypeID: 1, Date: "2020-11-10", Value: 21.5},
+	}
+	s.repo.On("FindAll").Return(&weights, nil).Once()
+	s.repo.On("FindAllMeasurementTypes").Return(&types, nil).Once()
+	s.repo.On("FindAllMeasurements").Return(&measurements, nil).Once()
+	s.repo.On("FindProfile").Return(nil, gorm.ErrRecordNotFound).Once()
+
+	stats, err := s.service.Stats()
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), &services.Stats{
+		Count:       2,
+		AverageMax:  51.5,
+		AverageMin:  48.5,
+		AverageDiff: 3,
+		Measurements: []services.MeasurementStats{
+			{Type: types[0], Count: 2, Average: 21},
+		},
+	}, stats)
+}
+
+func (s *Suite) Test_Summarize_When_Empty() {
+	stats := services.Summarize(nil)
+	require.Equal(s.T(), services.Stats{}, stats)
+}
+
+func (s *Suite) Test_Upsert_Create_Weight_Of_The_Date() {
+	s.weight.Date = ""
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("FindByDate", "2020-11-09").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("UpsertByDate", s.weight).Return(true, nil).Once()
+	s.repo.On("SetWeightTags", s.weight.ID, []string(nil)).Return(nil).Once()
+
+	weight, created, err := s.service.Upsert("2020-11-09", s.weight)
+	require.NoError(s.T(), err)
+	require.True(s.T(), created)
+	require.Equal(s.T(), "2020-11-09", weight.Date)
+	require.Equal(s.T(), 2, weight.Difference)
+}
+
+func (s *Suite) Test_Upsert_When_Tags_Fail_In_The_Transaction() {
+	s.weight.Tags = []string{"sick"}
+	s.repo.On("Transaction").Return().Once()
+	s.repo.On("FindByDate", "2020-11-09").Return(nil, gorm.ErrRecordNotFound).Once()
+	s.repo.On("UpsertByDate", s.weight).Return(true, nil).Once()
+	s.repo.On("SetWeightTags", s.weight.ID, []string{"sick"}).Return(errors.New("connection reset")).Once()
+
+	weight, created, err := s.service.Upsert("2020-11-09", s.weight)
+	require.EqualError(s.T(), err, "connection reset")
+	require.Nil(s.T(), weight)
+	require.False(s.T(), created)
+}
+
+func (s *Suite) Test_Upsert_When_Day_Is_Made_Of_Readings() {
+	s.repo.On("FindByDate", "2020-11-09").Return(&models.Weight{ID: 4, Date: "2020-11-09"}, nil).Once()
+	s.repo.On("FindReadingsByWeightID", uint64(4)).Return(&[]models.Reading{{ID: 3, WeightID: 4}}, nil).Once()
+
+	weight, created, err := s.service.Upsert("2020-11-09", s.weight)
+	require.Equal(s.T(), services.ErrDayMadeOfReadings, err)
+	require.Nil(s.T(), weight)
+	require.False(s.T(), created)
+}
+
+func (s *Suite) Test_Upsert_When_Body_Date_Is_Different() {
+	_, _, err := s.service.Upsert("2020-11-10", s.weight)
+	require.Equal(s.T(), models.ValidationErrors{"date": "The date must be the same as the date in the address"}, err)
+}